@@ -0,0 +1,48 @@
+// Command rotate-keys re-encrypts stored router and PPPoE passwords with the
+// active encryption key. Run it after adding a new active key to move rows
+// off the old one, which can then be removed from the config. Plain text
+// rows are also encrypted, as the server does on startup.
+package main
+
+import (
+	"log"
+
+	impl "github.com/alijayanet/gembok-backend/internal/infrastructure/repositories"
+	"github.com/alijayanet/gembok-backend/pkg/config"
+	"github.com/alijayanet/gembok-backend/pkg/database"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"github.com/alijayanet/gembok-backend/pkg/secrets"
+	"go.uber.org/zap"
+)
+
+func main() {
+	cfg, err := config.LoadConfig("./configs")
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	if err := logger.InitLogger(cfg.Server.Mode); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	keyring, err := secrets.NewKeyring(cfg.Encryption.Keys, cfg.Encryption.ActiveKey)
+	if err != nil {
+		logger.Fatal("Invalid encryption config", zap.Error(err))
+	}
+
+	if err := database.Connect(&cfg.Database); err != nil {
+		logger.Fatal("Failed to connect to database")
+	}
+	defer database.Close()
+
+	result, err := impl.RotateSecrets(database.GetDB(), keyring)
+	if err != nil {
+		logger.Fatal("Key rotation failed", zap.Error(err))
+	}
+
+	logger.Info("Key rotation finished",
+		zap.String("active_key", keyring.ActiveKey()),
+		zap.Int("router_passwords", result.RouterPasswords),
+		zap.Int("customer_passwords", result.CustomerPasswords),
+	)
+}
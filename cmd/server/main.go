@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
@@ -10,6 +11,7 @@ import (
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/gowa"
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/tripay"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/webhook"
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/whatsapp"
 	impl "github.com/alijayanet/gembok-backend/internal/infrastructure/repositories"
 	http "github.com/alijayanet/gembok-backend/internal/interface/http"
@@ -18,6 +20,7 @@ import (
 	"github.com/alijayanet/gembok-backend/pkg/config"
 	"github.com/alijayanet/gembok-backend/pkg/database"
 	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"github.com/alijayanet/gembok-backend/pkg/secrets"
 	"go.uber.org/zap"
 )
 
@@ -37,6 +40,28 @@ func main() {
 
 	db := database.GetDB()
 
+	keyring, err := secrets.NewKeyring(cfg.Encryption.Keys, cfg.Encryption.ActiveKey)
+	if err != nil {
+		logger.Fatal("Invalid encryption config", zap.Error(err))
+	}
+	impl.RegisterSecretSerializer(keyring)
+	if !keyring.Enabled() {
+		logger.Warn("No encryption key configured, router and PPPoE passwords are stored in plain text")
+	} else {
+		// Rows written before encryption was enabled are encrypted now;
+		// the server does not start while any are left in plain text.
+		encrypted, err := impl.EncryptPlaintextSecrets(db, keyring)
+		if err != nil {
+			logger.Fatal("Failed to encrypt plain text passwords", zap.Error(err))
+		}
+		if encrypted.RouterPasswords > 0 || encrypted.CustomerPasswords > 0 {
+			logger.Info("Encrypted plain text passwords",
+				zap.Int("router_passwords", encrypted.RouterPasswords),
+				zap.Int("customer_passwords", encrypted.CustomerPasswords),
+			)
+		}
+	}
+
 	// ── Repositories ──────────────────────────────────────────────
 	adminRepo := impl.NewAdminRepository(db)
 	routerRepo := impl.NewRouterRepository(db)
@@ -46,6 +71,17 @@ func main() {
 	onuRepo := impl.NewONULocationRepository(db)
 	ticketRepo := impl.NewTroubleTicketRepository(db)
 	settingRepo := impl.NewSettingRepository(db)
+	paymentTrxRepo := impl.NewPaymentTransactionRepository(db)
+	webhookEndpointRepo := impl.NewWebhookEndpointRepository(db)
+	webhookLogRepo := impl.NewWebhookLogRepository(db)
+	cashPaymentRepo := impl.NewCashPaymentRepository(db)
+	cashSettlementRepo := impl.NewCashSettlementRepository(db)
+	voucherRepo := impl.NewVoucherRepository(db)
+	routerLogRepo := impl.NewRouterLogRepository(db)
+	usageRepo := impl.NewUsageRepository(db)
+	backupRepo := impl.NewRouterBackupRepository(db)
+	routerMetricRepo := impl.NewRouterMetricRepository(db)
+	routerAlertRepo := impl.NewRouterAlertRepository(db)
 
 	// ── External clients ──────────────────────────────────────────
 	genieacsClient := genieacs.NewGenieACSClient(
@@ -54,12 +90,30 @@ func main() {
 		cfg.GenieACS.Password,
 	)
 
-	mikrotikClient := mikrotik.NewMikroTikClient(routerRepo)
-	if err := mikrotikClient.ConnectAll(); err != nil {
+	mikrotikClient := mikrotik.NewMikroTikClient(routerRepo, mikrotik.PoolConfig{
+		DialTimeout:       cfg.Mikrotik.DialTimeout,
+		CommandTimeout:    cfg.Mikrotik.CommandTimeout,
+		ReadTimeout:       cfg.Mikrotik.ReadTimeout,
+		KeepaliveInterval: cfg.Mikrotik.KeepaliveInterval,
+		MaxConcurrent:     cfg.Mikrotik.MaxConcurrent,
+		MaxBackoff:        cfg.Mikrotik.MaxBackoff,
+	})
+	if err := mikrotikClient.ConnectAll(context.Background()); err != nil {
 		logger.Warn("Failed to connect to all routers on startup", zap.Error(err))
 	}
 
-	mikrotikService := mikrotik.NewMikroTikService(mikrotikClient, customerRepo, packageRepo, routerRepo)
+	webhookDispatcher := webhook.NewDispatcher(
+		webhookEndpointRepo,
+		webhookLogRepo,
+		cfg.Webhook.Timeout,
+		cfg.Webhook.MaxAttempts,
+		cfg.Webhook.RetryBackoff,
+	)
+
+	mikrotikService := mikrotik.NewMikroTikService(mikrotikClient, customerRepo, packageRepo, routerRepo, cfg.Isolation.AddressList, webhookDispatcher)
+	queueService := mikrotik.NewQueueService(mikrotikClient, routerRepo)
+	poolService := mikrotik.NewIPPoolsService(mikrotikClient, routerRepo)
+	hotspotService := mikrotik.NewHotspotService(mikrotikClient, routerRepo)
 
 	gowaClient := gowa.NewGOWAClient(
 		cfg.WhatsApp.APIURL,
@@ -86,14 +140,43 @@ func main() {
 	authUsecase := usecase.NewAuthUsecase(adminRepo, cfg.JWT.Secret, cfg.JWT.Expiration)
 	dashboardUsecase := usecase.NewDashboardUsecase(customerRepo, invoiceRepo, packageRepo)
 	customerUsecase := usecase.NewCustomerUsecase(customerRepo, mikrotikService, whatsappService)
-	invoiceUsecase := usecase.NewInvoiceUsecase(invoiceRepo, settingRepo, whatsappService)
+	invoiceUsecase := usecase.NewInvoiceUsecase(invoiceRepo, settingRepo, whatsappService, webhookDispatcher)
 	routerUsecase := usecase.NewRouterUsecase(routerRepo, mikrotikClient)
 	mikrotikUsecase := usecase.NewMikroTikUsecase(mikrotikService)
 	genieacsUsecase := usecase.NewGenieACSUsecase(genieacsClient)
-	paymentUsecase := usecase.NewPaymentUsecase(invoiceRepo, customerRepo, tripayClient, mikrotikService, cfg.App.URL)
+	paymentUsecase := usecase.NewPaymentUsecase(invoiceRepo, customerRepo, paymentTrxRepo, tripayClient, mikrotikService, whatsappService, webhookDispatcher, cfg.App.URL)
 	onuUsecase := usecase.NewONUUsecase(onuRepo, genieacsClient)
-	ticketUsecase := usecase.NewTroubleTicketUsecase(ticketRepo, customerRepo)
-	portalUsecase := usecase.NewPortalUsecase(customerRepo, invoiceRepo, ticketRepo, cfg.JWT.Secret)
+	ticketUsecase := usecase.NewTroubleTicketUsecase(ticketRepo, customerRepo, webhookDispatcher)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookEndpointRepo, webhookLogRepo, webhookDispatcher)
+	cashUsecase := usecase.NewCashUsecase(cashPaymentRepo, cashSettlementRepo, customerRepo, invoiceRepo, adminRepo, paymentUsecase, whatsappService, cfg.App.Name)
+	reconcileUsecase := usecase.NewReconcileUsecase(routerRepo, customerRepo, mikrotikClient)
+	importUsecase := usecase.NewImportUsecase(routerRepo, customerRepo, packageRepo, mikrotikClient)
+	packageUsecase := usecase.NewPackageUsecase(packageRepo, routerRepo, mikrotikClient)
+	queueUsecase := usecase.NewQueueUsecase(routerRepo, customerRepo, packageRepo, queueService)
+	poolUsecase := usecase.NewIPPoolUsecase(routerRepo, poolService, cfg.Mikrotik.PoolWarnThreshold)
+	voucherUsecase := usecase.NewVoucherUsecase(voucherRepo, routerRepo, hotspotService, cfg.App.Name)
+	trafficUsecase := usecase.NewTrafficUsecase(routerRepo, customerRepo, mikrotikClient)
+	usageUsecase := usecase.NewUsageUsecase(routerRepo, customerRepo, usageRepo, mikrotikClient)
+	quotaUsecase := usecase.NewQuotaUsecase(customerRepo, packageRepo, usageRepo, mikrotikClient, whatsappService)
+	backupUsecase := usecase.NewBackupUsecase(routerRepo, backupRepo, mikrotikClient, cfg.Mikrotik.BackupRetention, cfg.Mikrotik.BackupKeep)
+	monitoringUsecase := usecase.NewMonitoringUsecase(routerRepo, routerMetricRepo, routerAlertRepo, mikrotikClient, whatsappService, usecase.AlertRules{
+		CPUThreshold:     cfg.Monitoring.CPUThreshold,
+		CPUDuration:      cfg.Monitoring.CPUDuration,
+		MemoryThreshold:  cfg.Monitoring.MemoryThreshold,
+		MemoryDuration:   cfg.Monitoring.MemoryDuration,
+		UnreachableAfter: cfg.Monitoring.UnreachableAfter,
+		Reboot:           cfg.Monitoring.RebootAlerts,
+	}, cfg.Monitoring.Retention)
+	migrationUsecase := usecase.NewMigrationUsecase(routerRepo, customerRepo, mikrotikClient, queueService, cfg.Isolation.AddressList)
+	scheduleUsecase := usecase.NewScheduleUsecase(packageRepo, routerRepo, mikrotikClient, cfg.Mikrotik.ScheduleReconnect)
+	logUsecase := usecase.NewLogUsecase(routerRepo, routerLogRepo, mikrotikClient)
+	isolationUsecase := usecase.NewIsolationUsecase(routerRepo, customerRepo, invoiceRepo, paymentUsecase, mikrotikClient, mikrotik.IsolationFirewall{
+		AddressList:    cfg.Isolation.AddressList,
+		CaptiveAddress: cfg.Isolation.CaptiveAddress,
+		CaptivePort:    cfg.Isolation.CaptivePort,
+		AllowedHosts:   cfg.Isolation.AllowedHosts,
+	}, cfg.App.Name)
+	portalUsecase := usecase.NewPortalUsecase(customerRepo, invoiceRepo, ticketRepo, paymentUsecase, webhookDispatcher, cfg.JWT.Secret)
 
 	// ── Handlers ─────────────────────────────────────────────────
 	authHandler := handlers.NewAuthHandler(authUsecase)
@@ -101,13 +184,29 @@ func main() {
 	customerHandler := handlers.NewCustomerHandler(customerUsecase)
 	invoiceHandler := handlers.NewInvoiceHandler(invoiceUsecase)
 	routerHandler := handlers.NewRouterHandler(routerUsecase)
-	mikrotikHandler := handlers.NewMikroTikHandler(mikrotikUsecase)
+	mikrotikHandler := handlers.NewMikroTikHandler(mikrotikUsecase, logUsecase)
 	genieacsHandler := handlers.NewGenieACSHandler(genieacsUsecase)
 	paymentHandler := handlers.NewPaymentHandler(paymentUsecase)
 	onuHandler := handlers.NewONUHandler(onuUsecase)
 	ticketHandler := handlers.NewTroubleTicketHandler(ticketUsecase)
 	portalHandler := handlers.NewPortalHandler(portalUsecase)
-	whatsappHandler := handlers.NewWhatsAppHandler(whatsappService, cfg.WhatsApp.WebhookSecret, cfg.WhatsApp.AdminPhones)
+	webhookHandler := handlers.NewWebhookHandler(webhookUsecase)
+	cashHandler := handlers.NewCashHandler(cashUsecase)
+	reconcileHandler := handlers.NewReconcileHandler(reconcileUsecase)
+	importHandler := handlers.NewImportHandler(importUsecase)
+	packageHandler := handlers.NewPackageHandler(packageUsecase)
+	queueHandler := handlers.NewQueueHandler(queueUsecase)
+	poolHandler := handlers.NewIPPoolHandler(poolUsecase)
+	voucherHandler := handlers.NewVoucherHandler(voucherUsecase)
+	trafficHandler := handlers.NewTrafficHandler(trafficUsecase)
+	usageHandler := handlers.NewUsageHandler(usageUsecase)
+	quotaHandler := handlers.NewQuotaHandler(quotaUsecase)
+	isolationHandler := handlers.NewIsolationHandler(isolationUsecase)
+	backupHandler := handlers.NewBackupHandler(backupUsecase)
+	monitoringHandler := handlers.NewMonitoringHandler(monitoringUsecase)
+	migrationHandler := handlers.NewMigrationHandler(migrationUsecase)
+	scheduleHandler := handlers.NewScheduleHandler(scheduleUsecase)
+	whatsappHandler := handlers.NewWhatsAppHandler(whatsappService, voucherUsecase, cfg.WhatsApp.WebhookSecret, cfg.WhatsApp.AdminPhones)
 
 	// ── Router ───────────────────────────────────────────────────
 	router := http.SetupRouter(
@@ -124,8 +223,59 @@ func main() {
 		ticketHandler,
 		portalHandler,
 		whatsappHandler,
+		webhookHandler,
+		cashHandler,
+		reconcileHandler,
+		importHandler,
+		packageHandler,
+		queueHandler,
+		poolHandler,
+		voucherHandler,
+		trafficHandler,
+		usageHandler,
+		quotaHandler,
+		isolationHandler,
+		backupHandler,
+		monitoringHandler,
+		migrationHandler,
+		scheduleHandler,
 	)
 
+	stopLogShipping := func() {}
+	if cfg.Mikrotik.LogShipInterval > 0 {
+		stopLogShipping = logUsecase.StartShipping(cfg.Mikrotik.LogShipInterval, cfg.Mikrotik.LogRetention)
+	}
+
+	stopUsageCollection := func() {}
+	if cfg.Mikrotik.UsageInterval > 0 {
+		stopUsageCollection = usageUsecase.StartCollecting(cfg.Mikrotik.UsageInterval)
+	}
+
+	stopQuotaChecks := func() {}
+	if cfg.Mikrotik.QuotaInterval > 0 {
+		stopQuotaChecks = quotaUsecase.StartChecking(cfg.Mikrotik.QuotaInterval)
+	}
+
+	stopMonitoring := func() {}
+	if cfg.Monitoring.Interval > 0 {
+		stopMonitoring = monitoringUsecase.StartPolling(cfg.Monitoring.Interval)
+	}
+
+	stopSchedules := func() {}
+	if cfg.Mikrotik.ScheduleInterval > 0 {
+		stopSchedules = scheduleUsecase.StartScheduling(cfg.Mikrotik.ScheduleInterval)
+	}
+
+	stopIsolationRefresh := func() {}
+	if cfg.Isolation.RefreshInterval > 0 {
+		stopIsolationRefresh = isolationUsecase.StartRefreshing(cfg.Isolation.RefreshInterval)
+	}
+
+	stopBackups := func() {}
+	if cfg.Mikrotik.BackupInterval > 0 {
+		stopBackups = backupUsecase.StartScheduling(cfg.Mikrotik.BackupInterval)
+	}
+
 	logger.Info("Starting server", zap.String("port", cfg.Server.Port))
 
 	quit := make(chan os.Signal, 1)
@@ -140,6 +290,14 @@ func main() {
 	<-quit
 	logger.Info("Shutting down server...")
 
+	stopLogShipping()
+	stopBackups()
+	stopIsolationRefresh()
+	stopSchedules()
+	stopMonitoring()
+	stopQuotaChecks()
+	stopUsageCollection()
+	mikrotikClient.Close()
 	database.Close()
 	logger.Info("Server stopped")
 }
@@ -0,0 +1,31 @@
+-- Migration: Payment transactions
+-- Up
+
+CREATE TABLE IF NOT EXISTS `payment_transactions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `invoice_id` bigint unsigned NOT NULL,
+  `customer_id` bigint unsigned NOT NULL,
+  `reference` varchar(255) NOT NULL,
+  `merchant_ref` varchar(255) NOT NULL,
+  `method` varchar(100) NOT NULL,
+  `amount` double NOT NULL,
+  `checkout_url` varchar(500) DEFAULT NULL,
+  `pay_code` varchar(255) DEFAULT NULL,
+  `qr_string` text DEFAULT NULL,
+  `status` varchar(50) DEFAULT 'unpaid',
+  `expired_at` datetime(3) NOT NULL,
+  `paid_at` datetime(3) DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_payment_transactions_reference` (`reference`),
+  KEY `idx_payment_transactions_invoice_id` (`invoice_id`),
+  KEY `idx_payment_transactions_customer_id` (`customer_id`),
+  KEY `idx_payment_transactions_merchant_ref` (`merchant_ref`),
+  KEY `idx_payment_transactions_status` (`status`),
+  CONSTRAINT `fk_payment_transactions_invoice` FOREIGN KEY (`invoice_id`) REFERENCES `invoices` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `fk_payment_transactions_customer` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Down
+DROP TABLE IF EXISTS `payment_transactions`;
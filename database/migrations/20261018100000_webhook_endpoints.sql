@@ -0,0 +1,39 @@
+-- Migration: Outbound webhook endpoints and delivery attempts
+-- Up
+
+CREATE TABLE IF NOT EXISTS `webhook_endpoints` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(255) NOT NULL,
+  `url` varchar(500) NOT NULL,
+  `secret` varchar(255) NOT NULL,
+  `events` text DEFAULT NULL,
+  `is_active` tinyint(1) DEFAULT 1,
+  `description` text DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_webhook_endpoints_is_active` (`is_active`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE `webhook_logs`
+  ADD COLUMN `endpoint_id` bigint unsigned DEFAULT NULL AFTER `id`,
+  ADD COLUMN `delivery_id` varchar(64) DEFAULT NULL AFTER `endpoint_id`,
+  ADD COLUMN `attempt` int DEFAULT 1 AFTER `duration`,
+  ADD COLUMN `success` tinyint(1) DEFAULT 0 AFTER `attempt`,
+  ADD COLUMN `error` text DEFAULT NULL AFTER `success`,
+  ADD KEY `idx_webhook_logs_endpoint_id` (`endpoint_id`),
+  ADD KEY `idx_webhook_logs_delivery_id` (`delivery_id`),
+  ADD CONSTRAINT `fk_webhook_logs_endpoint` FOREIGN KEY (`endpoint_id`) REFERENCES `webhook_endpoints` (`id`) ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- Down
+ALTER TABLE `webhook_logs`
+  DROP FOREIGN KEY `fk_webhook_logs_endpoint`,
+  DROP KEY `idx_webhook_logs_endpoint_id`,
+  DROP KEY `idx_webhook_logs_delivery_id`,
+  DROP COLUMN `endpoint_id`,
+  DROP COLUMN `delivery_id`,
+  DROP COLUMN `attempt`,
+  DROP COLUMN `success`,
+  DROP COLUMN `error`;
+
+DROP TABLE IF EXISTS `webhook_endpoints`;
@@ -0,0 +1,60 @@
+-- Migration: Cash collection by field collectors
+-- Up
+
+ALTER TABLE `customers`
+  ADD COLUMN `collector_id` bigint unsigned DEFAULT NULL AFTER `longitude`,
+  ADD KEY `idx_customers_collector_id` (`collector_id`),
+  ADD CONSTRAINT `fk_customers_collector` FOREIGN KEY (`collector_id`) REFERENCES `admin_users` (`id`) ON DELETE SET NULL ON UPDATE CASCADE;
+
+CREATE TABLE IF NOT EXISTS `cash_settlements` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `collector_id` bigint unsigned NOT NULL,
+  `amount` double NOT NULL,
+  `payment_count` int DEFAULT 0,
+  `status` varchar(50) DEFAULT 'pending',
+  `notes` text DEFAULT NULL,
+  `confirmed_by` bigint unsigned DEFAULT NULL,
+  `confirmed_at` datetime(3) DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_cash_settlements_collector_id` (`collector_id`),
+  KEY `idx_cash_settlements_status` (`status`),
+  CONSTRAINT `fk_cash_settlements_collector` FOREIGN KEY (`collector_id`) REFERENCES `admin_users` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `cash_payments` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `receipt_number` varchar(100) NOT NULL,
+  `invoice_id` bigint unsigned NOT NULL,
+  `customer_id` bigint unsigned NOT NULL,
+  `collector_id` bigint unsigned NOT NULL,
+  `amount` double NOT NULL,
+  `latitude` double DEFAULT NULL,
+  `longitude` double DEFAULT NULL,
+  `notes` text DEFAULT NULL,
+  `settlement_id` bigint unsigned DEFAULT NULL,
+  `collected_at` datetime(3) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_cash_payments_receipt_number` (`receipt_number`),
+  KEY `idx_cash_payments_invoice_id` (`invoice_id`),
+  KEY `idx_cash_payments_customer_id` (`customer_id`),
+  KEY `idx_cash_payments_collector_id` (`collector_id`),
+  KEY `idx_cash_payments_settlement_id` (`settlement_id`),
+  KEY `idx_cash_payments_collected_at` (`collected_at`),
+  CONSTRAINT `fk_cash_payments_invoice` FOREIGN KEY (`invoice_id`) REFERENCES `invoices` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE,
+  CONSTRAINT `fk_cash_payments_customer` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE,
+  CONSTRAINT `fk_cash_payments_collector` FOREIGN KEY (`collector_id`) REFERENCES `admin_users` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE,
+  CONSTRAINT `fk_cash_payments_settlement` FOREIGN KEY (`settlement_id`) REFERENCES `cash_settlements` (`id`) ON DELETE SET NULL ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Down
+DROP TABLE IF EXISTS `cash_payments`;
+DROP TABLE IF EXISTS `cash_settlements`;
+
+ALTER TABLE `customers`
+  DROP FOREIGN KEY `fk_customers_collector`,
+  DROP KEY `idx_customers_collector_id`,
+  DROP COLUMN `collector_id`;
@@ -0,0 +1,10 @@
+-- Migration: Static IP for queue-shaped customers
+-- Up
+
+ALTER TABLE `customers`
+  ADD COLUMN `static_ip` varchar(50) DEFAULT NULL AFTER `onu_ip_address`;
+
+-- Down
+
+ALTER TABLE `customers`
+  DROP COLUMN `static_ip`;
@@ -0,0 +1,10 @@
+-- Migration: IP pool per package
+-- Up
+
+ALTER TABLE `packages`
+  ADD COLUMN `pool_name` varchar(100) DEFAULT NULL AFTER `profile_isolir`;
+
+-- Down
+
+ALTER TABLE `packages`
+  DROP COLUMN `pool_name`;
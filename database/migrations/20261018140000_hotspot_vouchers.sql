@@ -0,0 +1,46 @@
+-- Migration: Hotspot voucher batches
+-- Up
+
+CREATE TABLE IF NOT EXISTS `voucher_batches` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `router_id` bigint unsigned NOT NULL,
+  `name` varchar(255) DEFAULT NULL,
+  `profile` varchar(100) NOT NULL,
+  `server` varchar(100) DEFAULT NULL,
+  `count` int DEFAULT 0,
+  `username_pattern` varchar(100) DEFAULT NULL,
+  `password_pattern` varchar(100) DEFAULT NULL,
+  `time_limit` varchar(50) DEFAULT NULL,
+  `data_limit` bigint DEFAULT 0,
+  `price` double DEFAULT 0,
+  `login_url` varchar(255) DEFAULT NULL,
+  `created_by` bigint unsigned DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_voucher_batches_router_id` (`router_id`),
+  CONSTRAINT `fk_voucher_batches_router` FOREIGN KEY (`router_id`) REFERENCES `routers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `hotspot_vouchers` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `batch_id` bigint unsigned NOT NULL,
+  `router_id` bigint unsigned NOT NULL,
+  `username` varchar(100) NOT NULL,
+  `password` varchar(100) NOT NULL,
+  `profile` varchar(100) DEFAULT NULL,
+  `price` double DEFAULT 0,
+  `status` varchar(50) DEFAULT 'available',
+  `sold_at` datetime(3) DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_hotspot_vouchers_router_username` (`router_id`, `username`),
+  KEY `idx_hotspot_vouchers_batch_id` (`batch_id`),
+  KEY `idx_hotspot_vouchers_status` (`status`),
+  CONSTRAINT `fk_hotspot_vouchers_batch` FOREIGN KEY (`batch_id`) REFERENCES `voucher_batches` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Down
+DROP TABLE IF EXISTS `hotspot_vouchers`;
+DROP TABLE IF EXISTS `voucher_batches`;
@@ -0,0 +1,19 @@
+-- Migration: Stored RouterOS logs
+-- Up
+
+CREATE TABLE IF NOT EXISTS `router_logs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `router_id` bigint unsigned NOT NULL,
+  `logged_at` datetime(3) NOT NULL,
+  `topics` varchar(255) DEFAULT NULL,
+  `message` text DEFAULT NULL,
+  `fingerprint` varchar(40) NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_router_logs_fingerprint` (`router_id`, `fingerprint`),
+  KEY `idx_router_logs_router_time` (`router_id`, `logged_at`),
+  CONSTRAINT `fk_router_logs_router` FOREIGN KEY (`router_id`) REFERENCES `routers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Down
+DROP TABLE IF EXISTS `router_logs`;
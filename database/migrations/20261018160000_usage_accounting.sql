@@ -0,0 +1,33 @@
+-- Migration: Per-customer data usage accounting
+-- Up
+
+CREATE TABLE IF NOT EXISTS `usage_counters` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `router_id` bigint unsigned NOT NULL,
+  `username` varchar(100) NOT NULL,
+  `session_id` varchar(32) DEFAULT NULL,
+  `rx_bytes` bigint NOT NULL DEFAULT 0,
+  `tx_bytes` bigint NOT NULL DEFAULT 0,
+  `sampled_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_usage_counters_user` (`router_id`, `username`),
+  CONSTRAINT `fk_usage_counters_router` FOREIGN KEY (`router_id`) REFERENCES `routers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `usage_records` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `customer_id` bigint unsigned NOT NULL,
+  `period` varchar(10) NOT NULL,
+  `period_start` datetime(3) NOT NULL,
+  `upload_bytes` bigint NOT NULL DEFAULT 0,
+  `download_bytes` bigint NOT NULL DEFAULT 0,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_usage_records_bucket` (`customer_id`, `period`, `period_start`),
+  KEY `idx_usage_records_period` (`period`, `period_start`),
+  CONSTRAINT `fk_usage_records_customer` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Down
+DROP TABLE IF EXISTS `usage_records`;
+DROP TABLE IF EXISTS `usage_counters`;
@@ -0,0 +1,26 @@
+-- Migration: Fair-usage quota per package
+-- Up
+
+ALTER TABLE `packages`
+  ADD COLUMN `quota_gb` int NOT NULL DEFAULT 0 AFTER `pool_name`,
+  ADD COLUMN `profile_throttle` varchar(100) DEFAULT NULL AFTER `quota_gb`,
+  ADD COLUMN `throttle_speed` varchar(50) DEFAULT NULL AFTER `profile_throttle`;
+
+ALTER TABLE `customers`
+  ADD COLUMN `quota_period` varchar(7) DEFAULT NULL AFTER `static_ip`,
+  ADD COLUMN `quota_notified` int NOT NULL DEFAULT 0 AFTER `quota_period`,
+  ADD COLUMN `quota_throttled` tinyint(1) NOT NULL DEFAULT 0 AFTER `quota_notified`,
+  ADD KEY `idx_customers_quota_throttled` (`quota_throttled`);
+
+-- Down
+
+ALTER TABLE `customers`
+  DROP KEY `idx_customers_quota_throttled`,
+  DROP COLUMN `quota_throttled`,
+  DROP COLUMN `quota_notified`,
+  DROP COLUMN `quota_period`;
+
+ALTER TABLE `packages`
+  DROP COLUMN `throttle_speed`,
+  DROP COLUMN `profile_throttle`,
+  DROP COLUMN `quota_gb`;
@@ -0,0 +1,18 @@
+-- Migration: Address-list isolation mode
+-- Up
+
+ALTER TABLE `routers`
+  ADD COLUMN `isolation_mode` varchar(20) NOT NULL DEFAULT 'profile' AFTER `is_active`;
+
+ALTER TABLE `customers`
+  ADD COLUMN `isolated_ip` varchar(45) DEFAULT NULL AFTER `static_ip`,
+  ADD KEY `idx_customers_isolated_ip` (`isolated_ip`);
+
+-- Down
+
+ALTER TABLE `customers`
+  DROP KEY `idx_customers_isolated_ip`,
+  DROP COLUMN `isolated_ip`;
+
+ALTER TABLE `routers`
+  DROP COLUMN `isolation_mode`;
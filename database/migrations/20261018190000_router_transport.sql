@@ -0,0 +1,10 @@
+-- Migration: Router transport (binary API or REST)
+-- Up
+
+ALTER TABLE `routers`
+  ADD COLUMN `transport` varchar(10) NOT NULL DEFAULT 'api' AFTER `port`;
+
+-- Down
+
+ALTER TABLE `routers`
+  DROP COLUMN `transport`;
@@ -0,0 +1,14 @@
+-- Migration: TLS settings for router connections
+-- Up
+
+ALTER TABLE `routers`
+  ADD COLUMN `use_tls` tinyint(1) NOT NULL DEFAULT 0 AFTER `transport`,
+  ADD COLUMN `tls_ca_cert` text DEFAULT NULL AFTER `use_tls`,
+  ADD COLUMN `tls_fingerprint` varchar(64) DEFAULT NULL AFTER `tls_ca_cert`;
+
+-- Down
+
+ALTER TABLE `routers`
+  DROP COLUMN `tls_fingerprint`,
+  DROP COLUMN `tls_ca_cert`,
+  DROP COLUMN `use_tls`;
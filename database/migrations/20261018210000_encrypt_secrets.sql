@@ -0,0 +1,21 @@
+-- Migration: Room for encrypted router and PPPoE passwords
+-- Up
+-- Existing values stay plain text until encryption keys are configured.
+-- The server then encrypts them on startup and refuses to start if it
+-- cannot; `go run ./cmd/rotate-keys` does the same without starting it.
+
+ALTER TABLE `routers`
+  MODIFY COLUMN `password` varchar(512) NOT NULL;
+
+ALTER TABLE `customers`
+  MODIFY COLUMN `pppoe_password` varchar(512) NOT NULL;
+
+-- Down
+-- Encrypted values may not fit in 255 characters. Reset the passwords
+-- before shrinking the columns or they will be truncated.
+
+ALTER TABLE `customers`
+  MODIFY COLUMN `pppoe_password` varchar(255) NOT NULL;
+
+ALTER TABLE `routers`
+  MODIFY COLUMN `password` varchar(255) NOT NULL;
@@ -0,0 +1,22 @@
+-- Migration: Scheduled router configuration backups
+-- Up
+
+CREATE TABLE IF NOT EXISTS `router_backups` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `router_id` bigint unsigned NOT NULL,
+  `export` longtext,
+  `backup` longblob,
+  `export_size` int NOT NULL DEFAULT 0,
+  `backup_size` int NOT NULL DEFAULT 0,
+  `export_hash` varchar(64) DEFAULT NULL,
+  `changed` tinyint(1) NOT NULL DEFAULT 0,
+  `error` text,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_router_backups_router_time` (`router_id`, `created_at`),
+  CONSTRAINT `fk_router_backups_router` FOREIGN KEY (`router_id`) REFERENCES `routers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Down
+
+DROP TABLE IF EXISTS `router_backups`;
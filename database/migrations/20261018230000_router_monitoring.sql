@@ -0,0 +1,37 @@
+-- Migration: Router resource metrics and threshold alerts
+-- Up
+
+CREATE TABLE IF NOT EXISTS `router_metrics` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `router_id` bigint unsigned NOT NULL,
+  `reachable` tinyint(1) NOT NULL DEFAULT 0,
+  `cpu` double NOT NULL DEFAULT 0,
+  `memory` int NOT NULL DEFAULT 0,
+  `uptime_seconds` bigint NOT NULL DEFAULT 0,
+  `active_users` int NOT NULL DEFAULT 0,
+  `sampled_at` datetime(3) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_router_metrics_router_time` (`router_id`, `sampled_at`),
+  KEY `idx_router_metrics_time` (`sampled_at`),
+  CONSTRAINT `fk_router_metrics_router` FOREIGN KEY (`router_id`) REFERENCES `routers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `router_alerts` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `router_id` bigint unsigned NOT NULL,
+  `rule` varchar(20) NOT NULL,
+  `message` text,
+  `value` double NOT NULL DEFAULT 0,
+  `started_at` datetime(3) NOT NULL,
+  `fired_at` datetime(3) NULL,
+  `resolved_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_router_alerts_open` (`router_id`, `rule`, `resolved_at`),
+  KEY `idx_router_alerts_fired` (`fired_at`),
+  CONSTRAINT `fk_router_alerts_router` FOREIGN KEY (`router_id`) REFERENCES `routers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Down
+
+DROP TABLE IF EXISTS `router_alerts`;
+DROP TABLE IF EXISTS `router_metrics`;
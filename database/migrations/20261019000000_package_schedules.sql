@@ -0,0 +1,21 @@
+-- Migration: Time-of-day speed schedules for packages
+-- Up
+
+CREATE TABLE IF NOT EXISTS `package_schedules` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `package_id` bigint unsigned NOT NULL,
+  `name` varchar(255) DEFAULT NULL,
+  `start_time` varchar(5) NOT NULL,
+  `end_time` varchar(5) NOT NULL,
+  `days` varchar(30) DEFAULT NULL,
+  `speed` varchar(255) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_package_schedules_package_id` (`package_id`),
+  CONSTRAINT `fk_packages_schedules` FOREIGN KEY (`package_id`) REFERENCES `packages` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Down
+
+DROP TABLE IF EXISTS `package_schedules`;
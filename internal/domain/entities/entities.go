@@ -22,32 +22,63 @@ type Customer struct {
 	PackageID      uint       `json:"package_id"`
 	Package        *Package   `gorm:"foreignKey:PackageID" json:"package,omitempty"`
 	PPPoEUsername  string     `gorm:"uniqueIndex" json:"pppoe_username"`
-	PPPoEPassword  string     `gorm:"not null" json:"-"`
+	PPPoEPassword  string     `gorm:"not null;serializer:secret" json:"-"`
 	Status         string     `gorm:"default:'active'" json:"status"`
 	RouterID       uint       `json:"router_id"`
 	ONUID          string     `json:"onu_id"`
 	ONUSerial      string     `json:"onu_serial"`
 	ONUMacAddress  string     `json:"onu_mac_address"`
 	ONUIPAddress   string     `json:"onu_ip_address"`
+	StaticIP       string     `gorm:"column:static_ip" json:"static_ip"`
+	IsolatedIP     string     `gorm:"column:isolated_ip;index" json:"isolated_ip,omitempty"`
+	QuotaPeriod    string     `gorm:"column:quota_period" json:"quota_period,omitempty"`
+	QuotaNotified  int        `gorm:"column:quota_notified;default:0" json:"quota_notified"`
+	QuotaThrottled bool       `gorm:"column:quota_throttled;default:false" json:"quota_throttled"`
 	Latitude       float64    `json:"latitude"`
 	Longitude      float64    `json:"longitude"`
+	CollectorID    *uint      `gorm:"index" json:"collector_id,omitempty"`
 	IsolationDate  *time.Time `json:"isolation_date,omitempty"`
 	ActivationDate *time.Time `json:"activation_date,omitempty"`
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
+// Package is a service plan. QuotaGB is its monthly fair-usage allowance
+// (0 means unlimited); customers past it are moved to ProfileThrottle, which
+// is limited to ThrottleSpeed.
 type Package struct {
-	ID            uint      `gorm:"primaryKey" json:"id"`
-	Name          string    `gorm:"uniqueIndex;not null" json:"name"`
-	Price         float64   `gorm:"not null" json:"price"`
-	Speed         string    `json:"speed"`
-	Description   string    `gorm:"type:text" json:"description"`
-	ProfileNormal string    `gorm:"column:profile_normal" json:"profile_normal"`
-	ProfileIsolir string    `gorm:"column:profile_isolir" json:"profile_isolir"`
-	Status        string    `gorm:"default:'active'" json:"status"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Name            string    `gorm:"uniqueIndex;not null" json:"name"`
+	Price           float64   `gorm:"not null" json:"price"`
+	Speed           string    `json:"speed"`
+	Description     string    `gorm:"type:text" json:"description"`
+	ProfileNormal   string    `gorm:"column:profile_normal" json:"profile_normal"`
+	ProfileIsolir   string    `gorm:"column:profile_isolir" json:"profile_isolir"`
+	PoolName        string    `gorm:"column:pool_name" json:"pool_name"`
+	QuotaGB         int       `gorm:"column:quota_gb;default:0" json:"quota_gb"`
+	ProfileThrottle string    `gorm:"column:profile_throttle" json:"profile_throttle"`
+	ThrottleSpeed   string    `gorm:"column:throttle_speed" json:"throttle_speed"`
+	Status          string    `gorm:"default:'active'" json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	Schedules []PackageSchedule `gorm:"foreignKey:PackageID" json:"schedules,omitempty"`
+}
+
+// PackageSchedule gives a package another speed during a daily time window,
+// such as a night boost. Start and End are "HH:MM" in server time; a window
+// whose End is not after Start runs past midnight. Days lists the weekdays
+// it starts on ("mon,tue"), empty for every day.
+type PackageSchedule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PackageID uint      `gorm:"not null;index" json:"package_id"`
+	Name      string    `json:"name"`
+	StartTime string    `gorm:"column:start_time;size:5;not null" json:"start_time"`
+	EndTime   string    `gorm:"column:end_time;size:5;not null" json:"end_time"`
+	Days      string    `gorm:"size:30" json:"days"`
+	Speed     string    `gorm:"not null" json:"speed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Invoice struct {
@@ -67,17 +98,40 @@ type Invoice struct {
 }
 
 type Router struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"not null" json:"name"`
-	Host      string    `gorm:"not null" json:"host"`
-	Username  string    `gorm:"not null" json:"username"`
-	Password  string    `gorm:"not null" json:"-"`
-	Port      int       `gorm:"default:8728" json:"port"`
-	IsActive  bool      `gorm:"default:false" json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Name           string    `gorm:"not null" json:"name"`
+	Host           string    `gorm:"not null" json:"host"`
+	Username       string    `gorm:"not null" json:"username"`
+	Password       string    `gorm:"not null;serializer:secret" json:"-"`
+	Port           int       `gorm:"default:8728" json:"port"`
+	IsActive       bool      `gorm:"default:false" json:"is_active"`
+	IsolationMode  string    `gorm:"column:isolation_mode;default:'profile'" json:"isolation_mode"`
+	Transport      string    `gorm:"size:10;default:'api'" json:"transport"`
+	UseTLS         bool      `gorm:"column:use_tls;default:false" json:"use_tls"`
+	TLSCACert      string    `gorm:"column:tls_ca_cert;type:text" json:"tls_ca_cert,omitempty"`
+	TLSFingerprint string    `gorm:"column:tls_fingerprint;size:64" json:"tls_fingerprint,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// Isolation modes of a router. With IsolationProfile an isolated customer's
+// secret is moved to the package's isolation profile; with
+// IsolationAddressList their address is added to a firewall address-list
+// whose web traffic is redirected to the captive payment page.
+const (
+	IsolationProfile     = "profile"
+	IsolationAddressList = "address_list"
+)
+
+// Transports a router can be managed over: the binary API (port 8728, or
+// api-ssl on 8729 with UseTLS) or the REST API of RouterOS v7 over HTTPS
+// (port 443). TLS certificates are checked against TLSFingerprint (SHA-256)
+// when set, else TLSCACert, else the system roots.
+const (
+	TransportAPI  = "api"
+	TransportREST = "rest"
+)
+
 type ONULocation struct {
 	ID             uint      `gorm:"primaryKey" json:"id"`
 	CustomerID     uint      `gorm:"not null;index" json:"customer_id"`
@@ -142,13 +196,214 @@ type CronLog struct {
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+type WebhookEndpoint struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"not null" json:"name"`
+	URL         string    `gorm:"not null" json:"url"`
+	Secret      string    `gorm:"not null" json:"-"`
+	Events      string    `gorm:"type:text" json:"events"` // comma separated, "*" for all
+	IsActive    bool      `gorm:"default:true" json:"is_active"`
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
 type WebhookLog struct {
 	ID         uint      `gorm:"primaryKey" json:"id"`
+	EndpointID *uint     `gorm:"index" json:"endpoint_id"`
+	DeliveryID string    `gorm:"index" json:"delivery_id"`
 	Event      string    `gorm:"not null;index" json:"event"`
 	URL        string    `gorm:"not null" json:"url"`
 	Payload    string    `gorm:"type:longtext" json:"payload"`
 	Response   string    `gorm:"type:longtext" json:"response"`
 	StatusCode int       `json:"status_code"`
 	Duration   int       `json:"duration"`
+	Attempt    int       `gorm:"default:1" json:"attempt"`
+	Success    bool      `gorm:"default:false" json:"success"`
+	Error      string    `gorm:"type:text" json:"error"`
 	CreatedAt  time.Time `json:"created_at"`
 }
+
+type PaymentTransaction struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	InvoiceID   uint       `gorm:"not null;index" json:"invoice_id"`
+	Invoice     *Invoice   `gorm:"foreignKey:InvoiceID" json:"invoice,omitempty"`
+	CustomerID  uint       `gorm:"not null;index" json:"customer_id"`
+	Reference   string     `gorm:"uniqueIndex;not null" json:"reference"`
+	MerchantRef string     `gorm:"not null;index" json:"merchant_ref"`
+	Method      string     `gorm:"not null" json:"method"`
+	Amount      float64    `gorm:"not null" json:"amount"`
+	CheckoutURL string     `json:"checkout_url"`
+	PayCode     string     `json:"pay_code"`
+	QRString    string     `gorm:"type:text" json:"qr_string,omitempty"`
+	Status      string     `gorm:"default:'unpaid';index" json:"status"`
+	ExpiredAt   time.Time  `json:"expired_at"`
+	PaidAt      *time.Time `json:"paid_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type CashPayment struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ReceiptNumber string     `gorm:"uniqueIndex;not null" json:"receipt_number"`
+	InvoiceID     uint       `gorm:"not null;index" json:"invoice_id"`
+	Invoice       *Invoice   `gorm:"foreignKey:InvoiceID" json:"invoice,omitempty"`
+	CustomerID    uint       `gorm:"not null;index" json:"customer_id"`
+	Customer      *Customer  `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	CollectorID   uint       `gorm:"not null;index" json:"collector_id"`
+	Collector     *AdminUser `gorm:"foreignKey:CollectorID" json:"collector,omitempty"`
+	Amount        float64    `gorm:"not null" json:"amount"`
+	Latitude      *float64   `json:"latitude,omitempty"`
+	Longitude     *float64   `json:"longitude,omitempty"`
+	Notes         string     `gorm:"type:text" json:"notes"`
+	SettlementID  *uint      `gorm:"index" json:"settlement_id,omitempty"`
+	CollectedAt   time.Time  `json:"collected_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type CashSettlement struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	CollectorID  uint       `gorm:"not null;index" json:"collector_id"`
+	Collector    *AdminUser `gorm:"foreignKey:CollectorID" json:"collector,omitempty"`
+	Amount       float64    `gorm:"not null" json:"amount"`
+	PaymentCount int        `json:"payment_count"`
+	Status       string     `gorm:"default:'pending';index" json:"status"`
+	Notes        string     `gorm:"type:text" json:"notes"`
+	ConfirmedBy  *uint      `json:"confirmed_by,omitempty"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// VoucherBatch is a set of hotspot users generated together with the same
+// profile, limits and price.
+type VoucherBatch struct {
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	RouterID        uint              `gorm:"not null;index" json:"router_id"`
+	Router          *Router           `gorm:"foreignKey:RouterID" json:"router,omitempty"`
+	Name            string            `json:"name"`
+	Profile         string            `gorm:"not null" json:"profile"`
+	Server          string            `json:"server"`
+	Count           int               `json:"count"`
+	UsernamePattern string            `json:"username_pattern"`
+	PasswordPattern string            `json:"password_pattern"`
+	TimeLimit       string            `json:"time_limit"`
+	DataLimit       int64             `json:"data_limit"`
+	Price           float64           `json:"price"`
+	LoginURL        string            `json:"login_url"`
+	CreatedBy       *uint             `json:"created_by,omitempty"`
+	Vouchers        []*HotspotVoucher `gorm:"foreignKey:BatchID" json:"vouchers,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+type HotspotVoucher struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	BatchID   uint       `gorm:"not null;index" json:"batch_id"`
+	RouterID  uint       `gorm:"not null;uniqueIndex:idx_hotspot_vouchers_router_username" json:"router_id"`
+	Username  string     `gorm:"not null;uniqueIndex:idx_hotspot_vouchers_router_username" json:"username"`
+	Password  string     `gorm:"not null" json:"password"`
+	Profile   string     `json:"profile"`
+	Price     float64    `json:"price"`
+	Status    string     `gorm:"default:'available';index" json:"status"`
+	SoldAt    *time.Time `json:"sold_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// RouterLog is a RouterOS /log entry copied into the database so it
+// survives router reboots. Fingerprint deduplicates repeated shipments.
+type RouterLog struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	RouterID    uint      `gorm:"not null;index:idx_router_logs_router_time;uniqueIndex:idx_router_logs_fingerprint" json:"router_id"`
+	LoggedAt    time.Time `gorm:"not null;index:idx_router_logs_router_time" json:"logged_at"`
+	Topics      string    `json:"topics"`
+	Message     string    `gorm:"type:text" json:"message"`
+	Fingerprint string    `gorm:"size:40;not null;uniqueIndex:idx_router_logs_fingerprint" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RouterBackup is a snapshot of a router's configuration: the text of
+// /export and the binary /system/backup. Either may be missing when taking
+// it failed, with the reason in Error. ExportHash ignores the timestamp
+// RouterOS writes at the top of every export, so equal hashes mean an
+// unchanged config.
+type RouterBackup struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	RouterID   uint      `gorm:"not null;index:idx_router_backups_router_time" json:"router_id"`
+	Export     string    `gorm:"type:longtext" json:"-"`
+	Backup     []byte    `gorm:"type:longblob" json:"-"`
+	ExportSize int       `json:"export_size"`
+	BackupSize int       `json:"backup_size"`
+	ExportHash string    `gorm:"size:64" json:"export_hash"`
+	Changed    bool      `json:"changed"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt  time.Time `gorm:"index:idx_router_backups_router_time" json:"created_at"`
+}
+
+// RouterMetric is one poll of a router's resources. A poll that could not
+// reach the router is stored with Reachable false and zero readings.
+type RouterMetric struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	RouterID      uint      `gorm:"not null;index:idx_router_metrics_router_time" json:"router_id"`
+	Reachable     bool      `json:"reachable"`
+	CPU           float64   `json:"cpu"`
+	Memory        int       `json:"memory"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+	ActiveUsers   int       `json:"active_users"`
+	SampledAt     time.Time `gorm:"not null;index:idx_router_metrics_router_time;index:idx_router_metrics_time" json:"sampled_at"`
+}
+
+// Rules a RouterAlert can be raised for.
+const (
+	AlertRuleCPU         = "cpu"
+	AlertRuleMemory      = "memory"
+	AlertRuleUnreachable = "unreachable"
+	AlertRuleReboot      = "reboot"
+)
+
+// RouterAlert tracks one occurrence of a threshold rule on a router. It is
+// open while ResolvedAt is nil and pending until the condition has held long
+// enough to set FiredAt, which is when admins are notified. At most one
+// alert per router and rule is open at a time.
+type RouterAlert struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	RouterID   uint       `gorm:"not null;index:idx_router_alerts_open" json:"router_id"`
+	Rule       string     `gorm:"size:20;not null;index:idx_router_alerts_open" json:"rule"`
+	Message    string     `gorm:"type:text" json:"message"`
+	Value      float64    `json:"value"`
+	StartedAt  time.Time  `gorm:"not null" json:"started_at"`
+	FiredAt    *time.Time `gorm:"index:idx_router_alerts_fired" json:"fired_at,omitempty"`
+	ResolvedAt *time.Time `gorm:"index:idx_router_alerts_open" json:"resolved_at,omitempty"`
+}
+
+// UsageCounter is the last byte count seen for a PPPoE user on a router, so
+// the next sample only adds what changed since.
+type UsageCounter struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	RouterID  uint      `gorm:"not null;uniqueIndex:idx_usage_counters_user" json:"router_id"`
+	Username  string    `gorm:"size:100;not null;uniqueIndex:idx_usage_counters_user" json:"username"`
+	SessionID string    `gorm:"size:32" json:"session_id"`
+	RxBytes   int64     `json:"rx_bytes"`
+	TxBytes   int64     `json:"tx_bytes"`
+	SampledAt time.Time `json:"sampled_at"`
+}
+
+// Usage periods a UsageRecord can cover.
+const (
+	UsageHour  = "hour"
+	UsageDay   = "day"
+	UsageMonth = "month"
+)
+
+// UsageRecord is a customer's traffic within one hour, day or month.
+type UsageRecord struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	CustomerID    uint      `gorm:"not null;uniqueIndex:idx_usage_records_bucket" json:"customer_id"`
+	Period        string    `gorm:"size:10;not null;uniqueIndex:idx_usage_records_bucket" json:"period"`
+	PeriodStart   time.Time `gorm:"not null;uniqueIndex:idx_usage_records_bucket" json:"period_start"`
+	UploadBytes   int64     `gorm:"not null;default:0" json:"upload_bytes"`
+	DownloadBytes int64     `gorm:"not null;default:0" json:"download_bytes"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
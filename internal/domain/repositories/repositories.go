@@ -1,9 +1,16 @@
 package repositories
 
 import (
+	"errors"
+	"time"
+
 	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 )
 
+// ErrInvoiceAlreadyPaid is returned when a payment is recorded against an
+// invoice that is paid or already has a cash payment.
+var ErrInvoiceAlreadyPaid = errors.New("invoice is already paid")
+
 type AdminRepository interface {
 	Create(admin *entities.AdminUser) error
 	FindByID(id uint) (*entities.AdminUser, error)
@@ -11,6 +18,7 @@ type AdminRepository interface {
 	Update(admin *entities.AdminUser) error
 	Delete(id uint) error
 	FindAll() ([]*entities.AdminUser, error)
+	FindByRole(role string) ([]*entities.AdminUser, error)
 }
 
 type CustomerRepository interface {
@@ -19,10 +27,19 @@ type CustomerRepository interface {
 	FindByPhone(phone string) (*entities.Customer, error)
 	FindByPPPoEUsername(username string) (*entities.Customer, error)
 	Update(customer *entities.Customer) error
+	// UpdateIsolatedIP writes only the isolated_ip column of a customer.
+	UpdateIsolatedIP(id uint, ip string) error
+	// UpdateQuota writes only the quota_throttled, quota_notified and
+	// quota_period columns of a customer.
+	UpdateQuota(customer *entities.Customer) error
 	Delete(id uint) error
 	FindAll(page, perPage int, search string) ([]*entities.Customer, int64, error)
 	FindByStatus(status string, page, perPage int) ([]*entities.Customer, int64, error)
 	FindByPackageID(packageID uint, page, perPage int) ([]*entities.Customer, int64, error)
+	FindByCollectorID(collectorID uint) ([]*entities.Customer, error)
+	FindByRouterID(routerID uint) ([]*entities.Customer, error)
+	FindQuotaThrottled() ([]*entities.Customer, error)
+	FindIsolatedByIP(ip string) ([]*entities.Customer, error)
 }
 
 type PackageRepository interface {
@@ -32,6 +49,8 @@ type PackageRepository interface {
 	Update(pkg *entities.Package) error
 	Delete(id uint) error
 	FindAll() ([]*entities.Package, error)
+	// ReplaceSchedules swaps a package's speed schedules for schedules.
+	ReplaceSchedules(packageID uint, schedules []entities.PackageSchedule) error
 }
 
 type InvoiceRepository interface {
@@ -44,6 +63,16 @@ type InvoiceRepository interface {
 	Delete(id uint) error
 	FindAll(page, perPage int) ([]*entities.Invoice, int64, error)
 	FindByStatus(status string, page, perPage int) ([]*entities.Invoice, int64, error)
+	FindUnpaidByCustomerIDs(customerIDs []uint) ([]*entities.Invoice, error)
+}
+
+type PaymentTransactionRepository interface {
+	Create(trx *entities.PaymentTransaction) error
+	FindByID(id uint) (*entities.PaymentTransaction, error)
+	FindByReference(reference string) (*entities.PaymentTransaction, error)
+	FindByInvoiceID(invoiceID uint) ([]*entities.PaymentTransaction, error)
+	FindLatestByInvoiceAndMethod(invoiceID uint, method string) (*entities.PaymentTransaction, error)
+	Update(trx *entities.PaymentTransaction) error
 }
 
 type RouterRepository interface {
@@ -90,3 +119,116 @@ type CronScheduleRepository interface {
 	Update(schedule *entities.CronSchedule) error
 	Delete(id uint) error
 }
+
+type WebhookEndpointRepository interface {
+	Create(endpoint *entities.WebhookEndpoint) error
+	FindByID(id uint) (*entities.WebhookEndpoint, error)
+	FindAll() ([]*entities.WebhookEndpoint, error)
+	FindActive() ([]*entities.WebhookEndpoint, error)
+	Update(endpoint *entities.WebhookEndpoint) error
+	Delete(id uint) error
+}
+
+type WebhookLogRepository interface {
+	Create(log *entities.WebhookLog) error
+	FindByID(id uint) (*entities.WebhookLog, error)
+	FindAll(page, perPage int, event string, endpointID uint) ([]*entities.WebhookLog, int64, error)
+}
+
+type CashPaymentRepository interface {
+	Create(payment *entities.CashPayment) error
+	FindByID(id uint) (*entities.CashPayment, error)
+	FindByCollectorID(collectorID uint, from, to time.Time) ([]*entities.CashPayment, error)
+	FindUnsettledByCollectorID(collectorID uint) ([]*entities.CashPayment, error)
+	SetSettlement(ids []uint, settlementID uint) error
+	SumCollected(collectorID uint, from, to time.Time) (float64, int64, error)
+	SumOutstanding(collectorID uint) (float64, error)
+	// CreateForInvoice numbers the receipt <receiptPrefix><seq> and inserts
+	// the payment while holding locks on its invoice and collector.
+	CreateForInvoice(payment *entities.CashPayment, receiptPrefix string) error
+	Delete(id uint) error
+}
+
+type CashSettlementRepository interface {
+	Create(settlement *entities.CashSettlement) error
+	FindByID(id uint) (*entities.CashSettlement, error)
+	FindAll(page, perPage int, collectorID uint, status string) ([]*entities.CashSettlement, int64, error)
+	Update(settlement *entities.CashSettlement) error
+	SumConfirmed(collectorID uint, from, to time.Time) (float64, error)
+	SumPending(collectorID uint) (float64, error)
+}
+
+type VoucherRepository interface {
+	CreateBatch(batch *entities.VoucherBatch) error
+	CreateVouchers(vouchers []*entities.HotspotVoucher) error
+	UpdateBatch(batch *entities.VoucherBatch) error
+	FindBatchByID(id uint) (*entities.VoucherBatch, error)
+	FindBatches(page, perPage int, routerID uint) ([]*entities.VoucherBatch, int64, error)
+	DeleteBatch(id uint) error
+	FindByID(id uint) (*entities.HotspotVoucher, error)
+	FindAll(page, perPage int, batchID uint, status string) ([]*entities.HotspotVoucher, int64, error)
+	FindExistingUsernames(routerID uint, usernames []string) ([]string, error)
+	Update(voucher *entities.HotspotVoucher) error
+}
+
+// RouterLogFilter narrows stored router logs. Zero values match everything.
+type RouterLogFilter struct {
+	RouterID uint
+	Topics   []string
+	Since    time.Time
+	Until    time.Time
+	Search   string
+	Limit    int
+}
+
+type RouterLogRepository interface {
+	CreateMany(logs []*entities.RouterLog) (int64, error)
+	Find(filter RouterLogFilter) ([]*entities.RouterLog, error)
+	LatestTime(routerID uint) (time.Time, error)
+	DeleteBefore(t time.Time) (int64, error)
+}
+
+type RouterMetricRepository interface {
+	Create(metric *entities.RouterMetric) error
+	// FindLatest returns a router's newest reachable sample.
+	FindLatest(routerID uint) (*entities.RouterMetric, error)
+	Find(routerID uint, from, to time.Time) ([]*entities.RouterMetric, error)
+	DeleteBefore(t time.Time) (int64, error)
+}
+
+type RouterAlertRepository interface {
+	Create(alert *entities.RouterAlert) error
+	Update(alert *entities.RouterAlert) error
+	Delete(id uint) error
+	// FindOpen returns the open alerts of a router by rule.
+	FindOpen(routerID uint) (map[string]*entities.RouterAlert, error)
+	// Find lists fired alerts, newest first. A zero routerID lists every
+	// router; openOnly leaves out resolved alerts.
+	Find(routerID uint, openOnly bool, page, perPage int) ([]*entities.RouterAlert, int64, error)
+}
+
+type RouterBackupRepository interface {
+	Create(backup *entities.RouterBackup) error
+	// FindByID loads a backup with its export and backup file.
+	FindByID(id uint) (*entities.RouterBackup, error)
+	// FindByRouterID lists a router's backups, newest first, without the
+	// export and backup file.
+	FindByRouterID(routerID uint, page, perPage int) ([]*entities.RouterBackup, int64, error)
+	// FindLatestExport returns a router's newest backup that has an
+	// export, without the export and backup file.
+	FindLatestExport(routerID uint) (*entities.RouterBackup, error)
+	// FindPrevious returns the router's last backup with an export taken
+	// before backup.
+	FindPrevious(backup *entities.RouterBackup) (*entities.RouterBackup, error)
+	// DeleteExpired removes a router's backups taken before t, always
+	// keeping the newest keep.
+	DeleteExpired(routerID uint, before time.Time, keep int) (int64, error)
+}
+
+type UsageRepository interface {
+	FindCounters(routerID uint) ([]*entities.UsageCounter, error)
+	SaveCounters(counters []*entities.UsageCounter) error
+	AddUsage(customerID uint, period string, start time.Time, upload, download int64) error
+	FindUsage(customerID uint, period string, from, to time.Time) ([]*entities.UsageRecord, error)
+	FindTop(period string, start time.Time, limit int) ([]*entities.UsageRecord, error)
+}
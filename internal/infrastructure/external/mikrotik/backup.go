@@ -0,0 +1,136 @@
+package mikrotik
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// backupFilePrefix starts the names of the files ExportConfig and
+	// SaveBackup create on the router. They are removed once read.
+	backupFilePrefix = "gembok-backup"
+
+	fileReadChunk = 32 * 1024
+	fileWaitTries = 5
+)
+
+// ExportConfig returns the text of /export. RouterOS only returns the
+// export as a file, so it is written to the router, read back and removed.
+func (c *MikroTikClient) ExportConfig(ctx context.Context, routerID uint) (string, error) {
+	name := backupFileName()
+	if _, err := c.run(ctx, routerID, "/export", "=file="+name); err != nil {
+		return "", fmt.Errorf("ExportConfig failed: %w", err)
+	}
+	data, err := c.takeFile(ctx, routerID, name+".rsc")
+	if err != nil {
+		return "", fmt.Errorf("ExportConfig failed: %w", err)
+	}
+	return string(data), nil
+}
+
+// SaveBackup returns a binary /system/backup of the router.
+func (c *MikroTikClient) SaveBackup(ctx context.Context, routerID uint) ([]byte, error) {
+	name := backupFileName()
+	if _, err := c.run(ctx, routerID, "/system/backup/save", "=name="+name); err != nil {
+		return nil, fmt.Errorf("SaveBackup failed: %w", err)
+	}
+	data, err := c.takeFile(ctx, routerID, name+".backup")
+	if err != nil {
+		return nil, fmt.Errorf("SaveBackup failed: %w", err)
+	}
+	return data, nil
+}
+
+// backupFileName is unique per call so overlapping backups of one router do
+// not read each other's files.
+func backupFileName() string {
+	return fmt.Sprintf("%s-%d", backupFilePrefix, time.Now().UnixNano())
+}
+
+// takeFile reads a file from the router and removes it, even when reading
+// failed, so no config copies are left behind.
+func (c *MikroTikClient) takeFile(ctx context.Context, routerID uint, name string) ([]byte, error) {
+	size, err := c.waitForFile(ctx, routerID, name)
+	if err != nil {
+		return nil, err
+	}
+	data, readErr := c.readFile(ctx, routerID, name, size)
+	if _, err := c.removeMatching(ctx, routerID, "/file", "/file/print", "=.proplist=.id", "?name="+name); err != nil && readErr == nil {
+		return nil, err
+	}
+	return data, readErr
+}
+
+// waitForFile returns the size of a file, waiting briefly for RouterOS to
+// finish writing it.
+func (c *MikroTikClient) waitForFile(ctx context.Context, routerID uint, name string) (int, error) {
+	for try := 1; ; try++ {
+		reply, err := c.run(ctx, routerID, "/file/print", "=.proplist=size", "?name="+name)
+		if err != nil {
+			return 0, err
+		}
+		if len(reply.Re) > 0 {
+			size, _ := strconv.Atoi(reply.Re[0].Map["size"])
+			return size, nil
+		}
+		if try == fileWaitTries {
+			return 0, fmt.Errorf("file %s was not created", name)
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// readFile reads a file in chunks with /file/read (RouterOS 7.13+). Older
+// versions only expose the contents property of /file, which is cut off at
+// 4 KiB, so larger files cannot be read there.
+func (c *MikroTikClient) readFile(ctx context.Context, routerID uint, name string, size int) ([]byte, error) {
+	data := make([]byte, 0, size)
+	for len(data) < size {
+		reply, err := c.run(ctx, routerID, "/file/read",
+			"=file="+name,
+			"=offset="+strconv.Itoa(len(data)),
+			"=chunk-size="+strconv.Itoa(fileReadChunk),
+		)
+		if err != nil {
+			if len(data) == 0 && !isTransportError(err) {
+				return c.readFileContents(ctx, routerID, name, size)
+			}
+			return nil, err
+		}
+		var chunk string
+		if len(reply.Re) > 0 {
+			chunk = reply.Re[0].Map["data"]
+		} else if reply.Done != nil {
+			chunk = reply.Done.Map["data"]
+		}
+		if chunk == "" {
+			break
+		}
+		data = append(data, chunk...)
+	}
+	if len(data) != size {
+		return nil, fmt.Errorf("read %d of %d bytes of %s", len(data), size, name)
+	}
+	return data, nil
+}
+
+func (c *MikroTikClient) readFileContents(ctx context.Context, routerID uint, name string, size int) ([]byte, error) {
+	reply, err := c.run(ctx, routerID, "/file/print", "=.proplist=contents", "?name="+name)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply.Re) == 0 {
+		return nil, fmt.Errorf("file %s not found", name)
+	}
+	contents := reply.Re[0].Map["contents"]
+	if len(contents) != size {
+		return nil, fmt.Errorf("%s is %d bytes, too large to read before RouterOS 7.13", name, size)
+	}
+	return []byte(contents), nil
+}
@@ -1,137 +1,129 @@
 package mikrotik
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
 	"github.com/alijayanet/gembok-backend/pkg/logger"
 	routeros "github.com/go-routeros/routeros/v3"
 	"go.uber.org/zap"
 )
 
-// MikroTikClient manages RouterOS API connections per router.
+// MikroTikClient manages RouterOS connections per router over the binary API
+// or the REST API, as configured on the router. Sessions live in a
+// self-healing pool that reconnects with backoff after failures.
 type MikroTikClient struct {
-	connections map[uint]*routeros.Client
-	connInfo    map[uint]*MikroTikConnectionInfo
-	mu          sync.RWMutex
-	routerRepo  repositories.RouterRepository
+	pool       *connPool
+	routerRepo repositories.RouterRepository
 }
 
 // MikroTikConnectionInfo holds connection parameters.
 type MikroTikConnectionInfo struct {
-	Host     string
-	Username string
-	Password string
-	Port     int
+	Host      string
+	Username  string
+	Password  string
+	Port      int
+	Transport string
+
+	// TLS enables api-ssl on the binary transport; REST always uses
+	// HTTPS. TLSFingerprint pins the certificate's SHA-256 hash and
+	// TLSCACert is a PEM CA bundle to verify it against.
+	TLS            bool
+	TLSCACert      string
+	TLSFingerprint string
 }
 
 // MikroTikConnection is kept for backward compatibility with other files.
 type MikroTikConnection = MikroTikConnectionInfo
 
-func NewMikroTikClient(routerRepo repositories.RouterRepository) *MikroTikClient {
-	return &MikroTikClient{
-		connections: make(map[uint]*routeros.Client),
-		connInfo:    make(map[uint]*MikroTikConnectionInfo),
-		routerRepo:  routerRepo,
+func NewMikroTikClient(routerRepo repositories.RouterRepository, poolCfg PoolConfig) *MikroTikClient {
+	c := &MikroTikClient{
+		routerRepo: routerRepo,
 	}
+	c.pool = newConnPool(poolCfg, c.loadInfo)
+	go c.pool.keepalive()
+	return c
 }
 
-// dial opens a new RouterOS API connection.
-func (c *MikroTikClient) dial(info *MikroTikConnectionInfo) (*routeros.Client, error) {
-	addr := fmt.Sprintf("%s:%d", info.Host, info.Port)
-	return routeros.Dial(addr, info.Username, info.Password)
-}
-
-// Connect loads router config from DB and opens a connection.
-func (c *MikroTikClient) Connect(routerID uint) (*MikroTikConnectionInfo, error) {
+// loadInfo reads connection parameters for a router from the DB.
+func (c *MikroTikClient) loadInfo(routerID uint) (*MikroTikConnectionInfo, error) {
 	router, err := c.routerRepo.FindByID(routerID)
 	if err != nil {
 		return nil, fmt.Errorf("router not found: %w", err)
 	}
+	return &MikroTikConnectionInfo{
+		Host:           router.Host,
+		Username:       router.Username,
+		Password:       router.Password,
+		Port:           router.Port,
+		Transport:      router.Transport,
+		TLS:            router.UseTLS,
+		TLSCACert:      router.TLSCACert,
+		TLSFingerprint: router.TLSFingerprint,
+	}, nil
+}
 
-	info := &MikroTikConnectionInfo{
-		Host:     router.Host,
-		Username: router.Username,
-		Password: router.Password,
-		Port:     router.Port,
+// withTimeout bounds ctx by the default deadline for the command. Listings
+// get the longer read timeout; a shorter deadline on ctx always wins.
+func (c *MikroTikClient) withTimeout(ctx context.Context, command string) (context.Context, context.CancelFunc) {
+	timeout := c.pool.cfg.CommandTimeout
+	if strings.HasSuffix(command, "/print") {
+		timeout = c.pool.cfg.ReadTimeout
 	}
+	return context.WithTimeout(ctx, timeout)
+}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.connInfo[routerID] = info
+// run executes a command on the router through the pool.
+func (c *MikroTikClient) run(ctx context.Context, routerID uint, sentence ...string) (*routeros.Reply, error) {
+	ctx, cancel := c.withTimeout(ctx, sentence[0])
+	defer cancel()
+	return c.pool.run(ctx, routerID, false, sentence...)
+}
 
-	// Try to establish connection
-	client, err := c.dial(info)
+// Connect loads router config from DB and opens a connection.
+func (c *MikroTikClient) Connect(ctx context.Context, routerID uint) (*MikroTikConnectionInfo, error) {
+	info, err := c.loadInfo(routerID)
 	if err != nil {
-		logger.Warn("MikroTik connection failed",
-			zap.Uint("router_id", routerID),
-			zap.String("host", info.Host),
-			zap.Error(err),
-		)
-		return info, nil // Return info even if connection failed; will retry on use
-	}
-
-	c.connections[routerID] = client
-	logger.Info("MikroTik connected",
-		zap.Uint("router_id", routerID),
-		zap.String("host", info.Host),
-	)
+		return nil, err
+	}
+	c.pool.setInfo(routerID, info)
+
+	// Failures are recorded in the pool and retried with backoff by the
+	// keepalive loop, so the info is returned either way.
+	c.pool.client(ctx, routerID, false)
 	return info, nil
 }
 
-// getClient returns a live RouterOS client, re-dialing if necessary.
-func (c *MikroTikClient) getClient(routerID uint) (*routeros.Client, error) {
-	c.mu.RLock()
-	client, exists := c.connections[routerID]
-	info := c.connInfo[routerID]
-	c.mu.RUnlock()
-
-	if exists && client != nil {
-		return client, nil
-	}
-
-	if info == nil {
-		// Not connected yet — load from DB
-		router, err := c.routerRepo.FindByID(routerID)
-		if err != nil {
-			return nil, fmt.Errorf("router not found: %w", err)
-		}
-		info = &MikroTikConnectionInfo{
-			Host:     router.Host,
-			Username: router.Username,
-			Password: router.Password,
-			Port:     router.Port,
-		}
-		c.mu.Lock()
-		c.connInfo[routerID] = info
-		c.mu.Unlock()
-	}
+// Reset drops the pooled session for a router so changed credentials are
+// picked up on the next command.
+func (c *MikroTikClient) Reset(routerID uint) {
+	c.pool.reset(routerID)
+}
 
-	client, err := c.dial(info)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to router %d (%s): %w", routerID, info.Host, err)
-	}
+// Remove closes and forgets the pooled session of a deleted router.
+func (c *MikroTikClient) Remove(routerID uint) {
+	c.pool.remove(routerID)
+}
 
-	c.mu.Lock()
-	c.connections[routerID] = client
-	c.mu.Unlock()
+// ConnectionState returns the pool state of a router.
+func (c *MikroTikClient) ConnectionState(routerID uint) ConnectionState {
+	return c.pool.state(routerID)
+}
 
-	return client, nil
+// Close stops keepalives and closes every pooled session.
+func (c *MikroTikClient) Close() {
+	c.pool.close()
 }
 
 // GetClient returns the connection info (for backward compat).
 func (c *MikroTikClient) GetClient(routerID uint) (*MikroTikConnectionInfo, error) {
-	c.mu.RLock()
-	info, exists := c.connInfo[routerID]
-	c.mu.RUnlock()
-	if exists {
-		return info, nil
-	}
-	return c.Connect(routerID)
+	return c.pool.info(routerID)
 }
 
 func (c *MikroTikClient) GetActiveRouter() (*MikroTikConnectionInfo, uint, error) {
@@ -143,40 +135,59 @@ func (c *MikroTikClient) GetActiveRouter() (*MikroTikConnectionInfo, uint, error
 	return info, router.ID, err
 }
 
-func (c *MikroTikClient) ConnectAll() error {
+// CustomerRouterID returns the router serving a customer: the assigned one,
+// or the active router for customers created without a router.
+func (c *MikroTikClient) CustomerRouterID(customer *entities.Customer) (uint, error) {
+	if customer.RouterID != 0 {
+		return customer.RouterID, nil
+	}
+	router, err := c.routerRepo.FindActive()
+	if err != nil {
+		return 0, fmt.Errorf("customer has no router assigned and no active router found: %w", err)
+	}
+	return router.ID, nil
+}
+
+// ConnectAll dials every router concurrently so one unreachable router does
+// not hold up the rest.
+func (c *MikroTikClient) ConnectAll(ctx context.Context) error {
 	routers, err := c.routerRepo.FindAll()
 	if err != nil {
 		return err
 	}
+
+	var wg sync.WaitGroup
 	for _, router := range routers {
-		if _, err := c.Connect(router.ID); err != nil {
-			logger.Warn("Failed to pre-connect to router",
-				zap.Uint("router_id", router.ID),
-				zap.Error(err),
-			)
-		}
+		wg.Add(1)
+		go func(routerID uint) {
+			defer wg.Done()
+			if _, err := c.Connect(ctx, routerID); err != nil {
+				logger.Warn("Failed to pre-connect to router",
+					zap.Uint("router_id", routerID),
+					zap.Error(err),
+				)
+			}
+		}(router.ID)
 	}
+	wg.Wait()
+
 	logger.Info("MikroTik connect-all finished", zap.Int("count", len(routers)))
 	return nil
 }
 
-func (c *MikroTikClient) HealthCheck(routerID uint) error {
-	client, err := c.getClient(routerID)
-	if err != nil {
-		return err
-	}
-	_, err = client.Run("/system/resource/print")
+// HealthCheck runs a command on the router, bypassing any reconnect backoff
+// so an explicit connection test always dials.
+func (c *MikroTikClient) HealthCheck(ctx context.Context, routerID uint) error {
+	ctx, cancel := c.withTimeout(ctx, "/system/resource/print")
+	defer cancel()
+	_, err := c.pool.run(ctx, routerID, true, "/system/resource/print")
 	return err
 }
 
 // ─── PPPoE Secrets ───────────────────────────────────────────────────────────
 
-func (c *MikroTikClient) AddUser(routerID uint, username, password, profile string) error {
-	client, err := c.getClient(routerID)
-	if err != nil {
-		return err
-	}
-	_, err = client.Run("/ppp/secret/add",
+func (c *MikroTikClient) AddUser(ctx context.Context, routerID uint, username, password, profile string) error {
+	_, err := c.run(ctx, routerID, "/ppp/secret/add",
 		"=name="+username,
 		"=password="+password,
 		"=profile="+profile,
@@ -189,13 +200,9 @@ func (c *MikroTikClient) AddUser(routerID uint, username, password, profile stri
 	return nil
 }
 
-func (c *MikroTikClient) RemoveUser(routerID uint, username string) error {
-	client, err := c.getClient(routerID)
-	if err != nil {
-		return err
-	}
+func (c *MikroTikClient) RemoveUser(ctx context.Context, routerID uint, username string) error {
 	// Find the internal ID first
-	reply, err := client.Run("/ppp/secret/print", "?name="+username)
+	reply, err := c.run(ctx, routerID, "/ppp/secret/print", "?name="+username)
 	if err != nil {
 		return fmt.Errorf("RemoveUser find failed: %w", err)
 	}
@@ -203,7 +210,7 @@ func (c *MikroTikClient) RemoveUser(routerID uint, username string) error {
 		return fmt.Errorf("PPPoE user not found: %s", username)
 	}
 	id := reply.Re[0].Map[".id"]
-	_, err = client.Run("/ppp/secret/remove", "=.id="+id)
+	_, err = c.run(ctx, routerID, "/ppp/secret/remove", "=.id="+id)
 	if err != nil {
 		return fmt.Errorf("RemoveUser failed: %w", err)
 	}
@@ -211,54 +218,94 @@ func (c *MikroTikClient) RemoveUser(routerID uint, username string) error {
 	return nil
 }
 
-func (c *MikroTikClient) UpdateUser(routerID uint, username string, args ...string) error {
-	client, err := c.getClient(routerID)
-	if err != nil {
-		return err
-	}
-	reply, err := client.Run("/ppp/secret/print", "?name="+username)
+func (c *MikroTikClient) UpdateUser(ctx context.Context, routerID uint, username string, args ...string) error {
+	reply, err := c.run(ctx, routerID, "/ppp/secret/print", "?name="+username)
 	if err != nil || len(reply.Re) == 0 {
 		return fmt.Errorf("UpdateUser: user not found: %s", username)
 	}
 	id := reply.Re[0].Map[".id"]
 	setArgs := []string{"/ppp/secret/set", "=.id=" + id}
 	setArgs = append(setArgs, args...)
-	_, err = client.Run(setArgs...)
+	_, err = c.run(ctx, routerID, setArgs...)
 	if err != nil {
 		return fmt.Errorf("UpdateUser failed: %w", err)
 	}
 	return nil
 }
 
-func (c *MikroTikClient) GetAllUsers(routerID uint) ([]PPPoEUser, error) {
-	client, err := c.getClient(routerID)
-	if err != nil {
-		return nil, err
-	}
-	reply, err := client.Run("/ppp/secret/print")
+func (c *MikroTikClient) GetAllUsers(ctx context.Context, routerID uint) ([]PPPoEUser, error) {
+	reply, err := c.run(ctx, routerID, "/ppp/secret/print")
 	if err != nil {
 		return nil, fmt.Errorf("GetAllUsers failed: %w", err)
 	}
 	users := make([]PPPoEUser, 0, len(reply.Re))
 	for _, re := range reply.Re {
-		users = append(users, PPPoEUser{
-			Name:      re.Map["name"],
-			Service:   re.Map["service"],
-			Profile:   re.Map["profile"],
-			CallerID:  re.Map["caller-id"],
-			Disabled:  re.Map["disabled"] == "true",
-			LastLogin: re.Map["last-logged-out"],
-		})
+		users = append(users, pppoeUser(re.Map))
 	}
 	return users, nil
 }
 
-func (c *MikroTikClient) GetActiveSessions(routerID uint) ([]ActiveSession, error) {
-	client, err := c.getClient(routerID)
+// GetUser returns one PPPoE secret, or nil when there is none by that name.
+func (c *MikroTikClient) GetUser(ctx context.Context, routerID uint, username string) (*PPPoEUser, error) {
+	reply, err := c.run(ctx, routerID, "/ppp/secret/print", "?name="+username)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("GetUser failed: %w", err)
+	}
+	if len(reply.Re) == 0 {
+		return nil, nil
+	}
+	user := pppoeUser(reply.Re[0].Map)
+	return &user, nil
+}
+
+// AddSecret creates a PPPoE secret with every field of user, for copying a
+// secret from another router as it is.
+func (c *MikroTikClient) AddSecret(ctx context.Context, routerID uint, user PPPoEUser) error {
+	service := user.Service
+	if service == "" {
+		service = "pppoe"
+	}
+	args := []string{"/ppp/secret/add",
+		"=name=" + user.Name,
+		"=password=" + user.Password,
+		"=profile=" + user.Profile,
+		"=service=" + service,
+	}
+	if user.Disabled {
+		args = append(args, "=disabled=yes")
+	}
+	if user.CallerID != "" {
+		args = append(args, "=caller-id="+user.CallerID)
+	}
+	if user.RemoteAddress != "" {
+		args = append(args, "=remote-address="+user.RemoteAddress)
+	}
+	if user.Comment != "" {
+		args = append(args, "=comment="+user.Comment)
+	}
+	if _, err := c.run(ctx, routerID, args...); err != nil {
+		return fmt.Errorf("AddSecret failed: %w", err)
+	}
+	logger.Info("MikroTik: AddSecret ok", zap.String("username", user.Name), zap.Uint("router_id", routerID))
+	return nil
+}
+
+func pppoeUser(m map[string]string) PPPoEUser {
+	return PPPoEUser{
+		Name:          m["name"],
+		Password:      m["password"],
+		Service:       m["service"],
+		Profile:       m["profile"],
+		CallerID:      m["caller-id"],
+		RemoteAddress: m["remote-address"],
+		Disabled:      m["disabled"] == "true" || m["disabled"] == "yes",
+		LastLogin:     m["last-logged-out"],
+		Comment:       m["comment"],
 	}
-	reply, err := client.Run("/ppp/active/print")
+}
+
+func (c *MikroTikClient) GetActiveSessions(ctx context.Context, routerID uint) ([]ActiveSession, error) {
+	reply, err := c.run(ctx, routerID, "/ppp/active/print")
 	if err != nil {
 		return nil, fmt.Errorf("GetActiveSessions failed: %w", err)
 	}
@@ -277,12 +324,8 @@ func (c *MikroTikClient) GetActiveSessions(routerID uint) ([]ActiveSession, erro
 	return sessions, nil
 }
 
-func (c *MikroTikClient) GetAllProfiles(routerID uint) ([]Profile, error) {
-	client, err := c.getClient(routerID)
-	if err != nil {
-		return nil, err
-	}
-	reply, err := client.Run("/ppp/profile/print")
+func (c *MikroTikClient) GetAllProfiles(ctx context.Context, routerID uint) ([]Profile, error) {
+	reply, err := c.run(ctx, routerID, "/ppp/profile/print")
 	if err != nil {
 		return nil, fmt.Errorf("GetAllProfiles failed: %w", err)
 	}
@@ -298,12 +341,37 @@ func (c *MikroTikClient) GetAllProfiles(routerID uint) ([]Profile, error) {
 	return profiles, nil
 }
 
-func (c *MikroTikClient) DisconnectUser(routerID uint, username string) error {
-	client, err := c.getClient(routerID)
+// UpsertProfile creates the /ppp/profile entry or updates it in place when
+// it already exists. args are RouterOS attribute words such as
+// "=rate-limit=5M/10M". It reports whether the profile was created.
+func (c *MikroTikClient) UpsertProfile(ctx context.Context, routerID uint, name string, args ...string) (bool, error) {
+	reply, err := c.run(ctx, routerID, "/ppp/profile/print", "?name="+name)
 	if err != nil {
-		return err
+		return false, fmt.Errorf("UpsertProfile find failed: %w", err)
+	}
+
+	if len(reply.Re) == 0 {
+		addArgs := append([]string{"/ppp/profile/add", "=name=" + name}, args...)
+		if _, err := c.run(ctx, routerID, addArgs...); err != nil {
+			return false, fmt.Errorf("UpsertProfile add failed: %w", err)
+		}
+		logger.Info("MikroTik: profile created", zap.String("profile", name), zap.Uint("router_id", routerID))
+		return true, nil
+	}
+
+	if len(args) == 0 {
+		return false, nil
+	}
+	setArgs := append([]string{"/ppp/profile/set", "=.id=" + reply.Re[0].Map[".id"]}, args...)
+	if _, err := c.run(ctx, routerID, setArgs...); err != nil {
+		return false, fmt.Errorf("UpsertProfile set failed: %w", err)
 	}
-	reply, err := client.Run("/ppp/active/print", "?name="+username)
+	logger.Info("MikroTik: profile updated", zap.String("profile", name), zap.Uint("router_id", routerID))
+	return false, nil
+}
+
+func (c *MikroTikClient) DisconnectUser(ctx context.Context, routerID uint, username string) error {
+	reply, err := c.run(ctx, routerID, "/ppp/active/print", "?name="+username)
 	if err != nil {
 		return fmt.Errorf("DisconnectUser find failed: %w", err)
 	}
@@ -311,7 +379,7 @@ func (c *MikroTikClient) DisconnectUser(routerID uint, username string) error {
 		return fmt.Errorf("no active session for user: %s", username)
 	}
 	id := reply.Re[0].Map[".id"]
-	_, err = client.Run("/ppp/active/remove", "=.id="+id)
+	_, err = c.run(ctx, routerID, "/ppp/active/remove", "=.id="+id)
 	if err != nil {
 		return fmt.Errorf("DisconnectUser failed: %w", err)
 	}
@@ -319,17 +387,13 @@ func (c *MikroTikClient) DisconnectUser(routerID uint, username string) error {
 	return nil
 }
 
-func (c *MikroTikClient) SetActiveProfile(routerID uint, username, profile string) error {
-	client, err := c.getClient(routerID)
-	if err != nil {
-		return err
-	}
-	reply, err := client.Run("/ppp/secret/print", "?name="+username)
+func (c *MikroTikClient) SetActiveProfile(ctx context.Context, routerID uint, username, profile string) error {
+	reply, err := c.run(ctx, routerID, "/ppp/secret/print", "?name="+username)
 	if err != nil || len(reply.Re) == 0 {
 		return fmt.Errorf("SetActiveProfile: user not found: %s", username)
 	}
 	id := reply.Re[0].Map[".id"]
-	_, err = client.Run("/ppp/secret/set", "=.id="+id, "=profile="+profile)
+	_, err = c.run(ctx, routerID, "/ppp/secret/set", "=.id="+id, "=profile="+profile)
 	if err != nil {
 		return fmt.Errorf("SetActiveProfile failed: %w", err)
 	}
@@ -342,30 +406,26 @@ func (c *MikroTikClient) SetActiveProfile(routerID uint, username, profile strin
 
 // ─── Router Status ────────────────────────────────────────────────────────────
 
-func (c *MikroTikClient) GetRouterStatus(routerID uint) (*RouterStatus, error) {
-	c.mu.RLock()
-	info := c.connInfo[routerID]
-	c.mu.RUnlock()
-
+func (c *MikroTikClient) GetRouterStatus(ctx context.Context, routerID uint) (*RouterStatus, error) {
 	status := &RouterStatus{
 		RouterID:  routerID,
 		LastCheck: time.Now(),
 	}
 
-	if info != nil {
+	if info, err := c.pool.info(routerID); err == nil {
 		status.Host = info.Host
 	}
 
-	client, err := c.getClient(routerID)
-	if err != nil {
-		status.Status = "disconnected"
-		status.Error = err.Error()
-		return status, nil
-	}
-
-	reply, err := client.Run("/system/resource/print")
+	reply, err := c.run(ctx, routerID, "/system/resource/print")
+	c.applyConnectionState(status)
 	if err != nil || len(reply.Re) == 0 {
 		status.Status = "error"
+		if isTransportError(err) {
+			status.Status = "disconnected"
+		}
+		if status.CertificateError != "" {
+			status.Status = "certificate_error"
+		}
 		if err != nil {
 			status.Error = err.Error()
 		}
@@ -389,7 +449,7 @@ func (c *MikroTikClient) GetRouterStatus(routerID uint) (*RouterStatus, error) {
 	}
 
 	// Count active PPPoE sessions
-	activeReply, err := client.Run("/ppp/active/print", "count-only=")
+	activeReply, err := c.run(ctx, routerID, "/ppp/active/print", "count-only=")
 	if err == nil && len(activeReply.Re) > 0 {
 		if count, err := strconv.Atoi(activeReply.Re[0].Map["ret"]); err == nil {
 			status.ActiveUsers = count
@@ -399,26 +459,91 @@ func (c *MikroTikClient) GetRouterStatus(routerID uint) (*RouterStatus, error) {
 	return status, nil
 }
 
-func (c *MikroTikClient) GetAllRoutersStatus() ([]RouterStatus, error) {
+// GetAllRoutersStatus queries every router concurrently; each query has its
+// own deadline so a hung router only delays its own entry.
+func (c *MikroTikClient) GetAllRoutersStatus(ctx context.Context) ([]RouterStatus, error) {
 	routers, err := c.routerRepo.FindAll()
 	if err != nil {
 		return nil, err
 	}
 
-	statuses := make([]RouterStatus, 0, len(routers))
-	for _, router := range routers {
-		s, _ := c.GetRouterStatus(router.ID)
-		if s == nil {
-			s = &RouterStatus{
-				RouterID:  router.ID,
-				Name:      router.Name,
-				Host:      router.Host,
-				Status:    "unknown",
-				LastCheck: time.Now(),
+	statuses := make([]RouterStatus, len(routers))
+	var wg sync.WaitGroup
+	for i, router := range routers {
+		wg.Add(1)
+		go func(i int, router *entities.Router) {
+			defer wg.Done()
+			s, _ := c.GetRouterStatus(ctx, router.ID)
+			if s == nil {
+				s = &RouterStatus{
+					RouterID:  router.ID,
+					Host:      router.Host,
+					Status:    "unknown",
+					LastCheck: time.Now(),
+				}
+				c.applyConnectionState(s)
 			}
-		}
-		s.Name = router.Name
-		statuses = append(statuses, *s)
+			s.Name = router.Name
+			statuses[i] = *s
+		}(i, router)
 	}
+	wg.Wait()
+
 	return statuses, nil
 }
+
+// applyConnectionState copies the pool state of the router into status.
+func (c *MikroTikClient) applyConnectionState(status *RouterStatus) {
+	state := c.pool.state(status.RouterID)
+	status.ConnectionState = state.State
+	status.LastError = state.LastError
+	status.LastErrorAt = state.LastErrorAt
+	status.ConnectedAt = state.ConnectedAt
+	status.ReconnectFailures = state.Failures
+	status.NextRetry = state.NextRetry
+	status.CertificateError = state.CertificateError
+}
+
+// ParseUptime parses a RouterOS duration such as "2w3d04:05:06" or
+// "3d4h5m6s".
+func ParseUptime(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty uptime")
+	}
+	units := map[byte]time.Duration{
+		'w': 7 * 24 * time.Hour,
+		'd': 24 * time.Hour,
+		'h': time.Hour,
+		'm': time.Minute,
+		's': time.Second,
+	}
+	var total time.Duration
+	rest := s
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid uptime %q", s)
+		}
+		if i < len(rest) && rest[i] == ':' {
+			var h, m, sec int
+			if _, err := fmt.Sscanf(rest, "%d:%d:%d", &h, &m, &sec); err != nil {
+				return 0, fmt.Errorf("invalid uptime %q", s)
+			}
+			return total + time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second, nil
+		}
+		n, _ := strconv.Atoi(rest[:i])
+		if i == len(rest) {
+			return 0, fmt.Errorf("invalid uptime %q", s)
+		}
+		unit, ok := units[rest[i]]
+		if !ok {
+			return 0, fmt.Errorf("invalid uptime %q", s)
+		}
+		total += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+	return total, nil
+}
@@ -0,0 +1,209 @@
+package mikrotik
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// IsolationRuleComment tags the firewall rules installed by
+// SetupIsolationFirewall so they can be replaced later.
+const IsolationRuleComment = "gembok-isolir"
+
+// IsolationFirewall describes the rules for address-list isolation: web
+// traffic from AddressList is redirected to the captive page at
+// CaptiveAddress:CaptivePort, DNS and AllowedHosts (e.g. the payment
+// gateway) stay reachable and everything else is dropped.
+type IsolationFirewall struct {
+	AddressList    string
+	CaptiveAddress string
+	CaptivePort    int
+	AllowedHosts   []string
+}
+
+// allowList is the address-list of destinations isolated customers may
+// still reach.
+func (f IsolationFirewall) allowList() string {
+	return f.AddressList + "-allow"
+}
+
+// GetActiveSession returns a user's active PPP session, or nil when the
+// user is not connected.
+func (c *MikroTikClient) GetActiveSession(ctx context.Context, routerID uint, username string) (*ActiveSession, error) {
+	reply, err := c.run(ctx, routerID, "/ppp/active/print", "?name="+username)
+	if err != nil {
+		return nil, fmt.Errorf("GetActiveSession failed: %w", err)
+	}
+	if len(reply.Re) == 0 {
+		return nil, nil
+	}
+	re := reply.Re[0]
+	return &ActiveSession{
+		Name:     re.Map["name"],
+		CallerID: re.Map["caller-id"],
+		Address:  re.Map["address"],
+		Uptime:   re.Map["uptime"],
+		Encoding: re.Map["encoding"],
+	}, nil
+}
+
+// AddressListEntry is one address of a firewall address-list.
+type AddressListEntry struct {
+	ID      string
+	Address string
+	Comment string
+}
+
+// GetAddressList returns the entries of a firewall address-list.
+func (c *MikroTikClient) GetAddressList(ctx context.Context, routerID uint, list string) ([]AddressListEntry, error) {
+	reply, err := c.run(ctx, routerID, "/ip/firewall/address-list/print", "=.proplist=.id,address,comment", "?list="+list)
+	if err != nil {
+		return nil, fmt.Errorf("GetAddressList failed: %w", err)
+	}
+	entries := make([]AddressListEntry, 0, len(reply.Re))
+	for _, re := range reply.Re {
+		entries = append(entries, AddressListEntry{
+			ID:      re.Map[".id"],
+			Address: re.Map["address"],
+			Comment: re.Map["comment"],
+		})
+	}
+	return entries, nil
+}
+
+// RemoveAddressListEntry removes one address-list entry by ID.
+func (c *MikroTikClient) RemoveAddressListEntry(ctx context.Context, routerID uint, id string) error {
+	if _, err := c.run(ctx, routerID, "/ip/firewall/address-list/remove", "=.id="+id); err != nil {
+		return fmt.Errorf("RemoveAddressListEntry failed: %w", err)
+	}
+	return nil
+}
+
+// AddToAddressList adds address to a firewall address-list.
+func (c *MikroTikClient) AddToAddressList(ctx context.Context, routerID uint, list, address, comment string) error {
+	args := []string{"/ip/firewall/address-list/add", "=list=" + list, "=address=" + address}
+	if comment != "" {
+		args = append(args, "=comment="+comment)
+	}
+	if _, err := c.run(ctx, routerID, args...); err != nil {
+		return fmt.Errorf("AddToAddressList failed: %w", err)
+	}
+	logger.Info("MikroTik: AddToAddressList ok",
+		zap.String("list", list),
+		zap.String("address", address),
+	)
+	return nil
+}
+
+// RemoveFromAddressList removes the entries of list carrying comment, or
+// every entry of list when comment is empty. It returns how many were
+// removed.
+func (c *MikroTikClient) RemoveFromAddressList(ctx context.Context, routerID uint, list, comment string) (int, error) {
+	query := []string{"/ip/firewall/address-list/print", "=.proplist=.id", "?list=" + list}
+	if comment != "" {
+		query = append(query, "?comment="+comment)
+	}
+	return c.removeMatching(ctx, routerID, "/ip/firewall/address-list", query...)
+}
+
+// removeMatching removes every item of menu returned by the print query.
+func (c *MikroTikClient) removeMatching(ctx context.Context, routerID uint, menu string, query ...string) (int, error) {
+	reply, err := c.run(ctx, routerID, query...)
+	if err != nil {
+		return 0, fmt.Errorf("%s lookup failed: %w", menu, err)
+	}
+	for _, re := range reply.Re {
+		if _, err := c.run(ctx, routerID, menu+"/remove", "=.id="+re.Map[".id"]); err != nil {
+			return 0, fmt.Errorf("%s remove failed: %w", menu, err)
+		}
+	}
+	return len(reply.Re), nil
+}
+
+// firstRuleID returns the ID of the first rule of a firewall chain, or ""
+// when the chain is empty.
+func (c *MikroTikClient) firstRuleID(ctx context.Context, routerID uint, menu, chain string) (string, error) {
+	reply, err := c.run(ctx, routerID, menu+"/print", "=.proplist=.id", "?chain="+chain)
+	if err != nil {
+		return "", fmt.Errorf("%s lookup failed: %w", menu, err)
+	}
+	if len(reply.Re) == 0 {
+		return "", nil
+	}
+	return reply.Re[0].Map[".id"], nil
+}
+
+// addRules adds rules to the top of a chain, keeping their order.
+func (c *MikroTikClient) addRules(ctx context.Context, routerID uint, menu, chain string, rules [][]string) error {
+	before, err := c.firstRuleID(ctx, routerID, menu, chain)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		args := append([]string{menu + "/add", "=chain=" + chain}, rule...)
+		args = append(args, "=comment="+IsolationRuleComment)
+		if before != "" {
+			args = append(args, "=place-before="+before)
+		}
+		if _, err := c.run(ctx, routerID, args...); err != nil {
+			return fmt.Errorf("%s add failed: %w", menu, err)
+		}
+	}
+	return nil
+}
+
+// SetupIsolationFirewall replaces the isolation rules on a router. Existing
+// rules tagged IsolationRuleComment are removed first, so it is safe to run
+// again after changing the captive address or allowed hosts.
+func (c *MikroTikClient) SetupIsolationFirewall(ctx context.Context, routerID uint, f IsolationFirewall) error {
+	if f.AddressList == "" {
+		return fmt.Errorf("address list is required")
+	}
+	if f.CaptiveAddress == "" || f.CaptivePort == 0 {
+		return fmt.Errorf("captive page address and port are required")
+	}
+
+	for _, menu := range []string{"/ip/firewall/nat", "/ip/firewall/filter"} {
+		if _, err := c.removeMatching(ctx, routerID, menu, menu+"/print", "=.proplist=.id", "?comment="+IsolationRuleComment); err != nil {
+			return err
+		}
+	}
+	if _, err := c.RemoveFromAddressList(ctx, routerID, f.allowList(), ""); err != nil {
+		return err
+	}
+
+	for _, host := range append([]string{f.CaptiveAddress}, f.AllowedHosts...) {
+		if err := c.AddToAddressList(ctx, routerID, f.allowList(), host, IsolationRuleComment); err != nil {
+			return err
+		}
+	}
+
+	src := "=src-address-list=" + f.AddressList
+	err := c.addRules(ctx, routerID, "/ip/firewall/nat", "dstnat", [][]string{
+		{src, "=dst-address-list=!" + f.allowList(), "=protocol=tcp", "=dst-port=80",
+			"=action=dst-nat", "=to-addresses=" + f.CaptiveAddress, "=to-ports=" + strconv.Itoa(f.CaptivePort)},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.addRules(ctx, routerID, "/ip/firewall/filter", "forward", [][]string{
+		{src, "=dst-address-list=" + f.allowList(), "=action=accept"},
+		{src, "=protocol=udp", "=dst-port=53", "=action=accept"},
+		{src, "=protocol=tcp", "=dst-port=53", "=action=accept"},
+		{src, "=action=drop"},
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("MikroTik: isolation firewall installed",
+		zap.Uint("router_id", routerID),
+		zap.String("list", f.AddressList),
+		zap.String("captive", f.CaptiveAddress),
+	)
+	return nil
+}
@@ -1,7 +1,13 @@
 package mikrotik
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
 )
 
 type HotspotService struct {
@@ -16,19 +22,75 @@ func NewHotspotService(client *MikroTikClient, routerRepo repositories.RouterRep
 	}
 }
 
-func (s *HotspotService) GetActiveSessions() ([]ActiveSession, error) {
+func (s *HotspotService) GetActiveSessions(ctx context.Context) ([]ActiveSession, error) {
 	_, routerID, err := s.client.GetActiveRouter()
 	if err != nil {
 		return nil, err
 	}
 
-	return s.client.GetActiveSessions(routerID)
+	return s.client.GetActiveSessions(ctx, routerID)
+}
+
+func (s *HotspotService) GetActiveSessionsByRouter(ctx context.Context, routerID uint) ([]ActiveSession, error) {
+	return s.client.GetActiveSessions(ctx, routerID)
 }
 
-func (s *HotspotService) GetActiveSessionsByRouter(routerID uint) ([]ActiveSession, error) {
-	return s.client.GetActiveSessions(routerID)
+func (s *HotspotService) DisconnectUser(ctx context.Context, username string, routerID uint) error {
+	return s.client.DisconnectUser(ctx, routerID, username)
 }
 
-func (s *HotspotService) DisconnectUser(username string, routerID uint) error {
-	return s.client.DisconnectUser(routerID, username)
+// AddVouchers writes vouchers to /ip/hotspot/user one by one and returns the
+// usernames that were added; errors are keyed by username.
+func (s *HotspotService) AddVouchers(ctx context.Context, routerID uint, vouchers []Voucher) ([]string, map[string]error) {
+	added := make([]string, 0, len(vouchers))
+	failed := make(map[string]error)
+	for _, v := range vouchers {
+		if err := ctx.Err(); err != nil {
+			failed[v.Username] = err
+			continue
+		}
+		args := []string{"/ip/hotspot/user/add",
+			"=name=" + v.Username,
+			"=password=" + v.Password,
+			"=profile=" + v.Profile,
+		}
+		if v.Server != "" {
+			args = append(args, "=server="+v.Server)
+		}
+		if v.LimitUptime != "" {
+			args = append(args, "=limit-uptime="+v.LimitUptime)
+		}
+		if v.LimitBytes > 0 {
+			args = append(args, "=limit-bytes-total="+strconv.FormatInt(v.LimitBytes, 10))
+		}
+		if v.Comment != "" {
+			args = append(args, "=comment="+v.Comment)
+		}
+		if _, err := s.client.run(ctx, routerID, args...); err != nil {
+			failed[v.Username] = err
+			continue
+		}
+		added = append(added, v.Username)
+	}
+
+	logger.Info("MikroTik: hotspot vouchers added",
+		zap.Uint("router_id", routerID),
+		zap.Int("added", len(added)),
+		zap.Int("failed", len(failed)),
+	)
+	return added, failed
+}
+
+func (s *HotspotService) RemoveUser(ctx context.Context, routerID uint, username string) error {
+	reply, err := s.client.run(ctx, routerID, "/ip/hotspot/user/print", "?name="+username)
+	if err != nil {
+		return fmt.Errorf("RemoveHotspotUser find failed: %w", err)
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+	if _, err := s.client.run(ctx, routerID, "/ip/hotspot/user/remove", "=.id="+reply.Re[0].Map[".id"]); err != nil {
+		return fmt.Errorf("RemoveHotspotUser failed: %w", err)
+	}
+	return nil
 }
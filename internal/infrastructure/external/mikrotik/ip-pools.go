@@ -1,7 +1,15 @@
 package mikrotik
 
 import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
 )
 
 type IPPoolsService struct {
@@ -15,3 +23,248 @@ func NewIPPoolsService(client *MikroTikClient, routerRepo repositories.RouterRep
 		routerRepo: routerRepo,
 	}
 }
+
+// ipRange is an inclusive IPv4 address range.
+type ipRange struct {
+	first, last uint32
+}
+
+func ipToUint(ip net.IP) (uint32, bool) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(ip4), true
+}
+
+// parsePoolRanges parses a RouterOS ranges value such as
+// "10.0.0.2-10.0.0.254,10.0.1.0/24,10.0.2.1".
+func parsePoolRanges(ranges string) ([]ipRange, error) {
+	var out []ipRange
+	for _, part := range strings.Split(ranges, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if strings.Contains(part, "/") {
+			_, network, err := net.ParseCIDR(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+			first, ok := ipToUint(network.IP)
+			if !ok {
+				return nil, fmt.Errorf("only IPv4 ranges are supported: %q", part)
+			}
+			ones, bits := network.Mask.Size()
+			out = append(out, ipRange{first: first, last: first | (1<<uint(bits-ones) - 1)})
+			continue
+		}
+
+		from, to := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			from, to = part[:i], part[i+1:]
+		}
+		first, ok1 := ipToUint(net.ParseIP(strings.TrimSpace(from)))
+		last, ok2 := ipToUint(net.ParseIP(strings.TrimSpace(to)))
+		if !ok1 || !ok2 || last < first {
+			return nil, fmt.Errorf("invalid range %q", part)
+		}
+		out = append(out, ipRange{first: first, last: last})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("ranges are required")
+	}
+	return out, nil
+}
+
+// ValidatePoolRanges reports whether ranges is a valid IPv4 pool ranges value.
+func ValidatePoolRanges(ranges string) error {
+	_, err := parsePoolRanges(ranges)
+	return err
+}
+
+func (s *IPPoolsService) List(ctx context.Context, routerID uint) ([]IPPool, error) {
+	reply, err := s.client.run(ctx, routerID, "/ip/pool/print")
+	if err != nil {
+		return nil, fmt.Errorf("ListPools failed: %w", err)
+	}
+
+	pools := make([]IPPool, 0, len(reply.Re))
+	for _, re := range reply.Re {
+		nextPool := re.Map["next-pool"]
+		if nextPool == "none" {
+			nextPool = ""
+		}
+		pools = append(pools, IPPool{
+			ID:       re.Map[".id"],
+			Name:     re.Map["name"],
+			Ranges:   re.Map["ranges"],
+			NextPool: nextPool,
+			Comment:  re.Map["comment"],
+		})
+	}
+	return pools, nil
+}
+
+func (s *IPPoolsService) findID(ctx context.Context, routerID uint, name string) (string, error) {
+	reply, err := s.client.run(ctx, routerID, "/ip/pool/print", "?name="+name)
+	if err != nil {
+		return "", fmt.Errorf("pool lookup failed: %w", err)
+	}
+	if len(reply.Re) == 0 {
+		return "", fmt.Errorf("pool not found: %s", name)
+	}
+	return reply.Re[0].Map[".id"], nil
+}
+
+func (s *IPPoolsService) Create(ctx context.Context, routerID uint, pool IPPool) error {
+	if pool.Name == "" {
+		return fmt.Errorf("pool name is required")
+	}
+	if err := ValidatePoolRanges(pool.Ranges); err != nil {
+		return err
+	}
+
+	args := []string{"/ip/pool/add", "=name=" + pool.Name, "=ranges=" + pool.Ranges}
+	if pool.NextPool != "" {
+		args = append(args, "=next-pool="+pool.NextPool)
+	}
+	if pool.Comment != "" {
+		args = append(args, "=comment="+pool.Comment)
+	}
+	if _, err := s.client.run(ctx, routerID, args...); err != nil {
+		return fmt.Errorf("CreatePool failed: %w", err)
+	}
+	logger.Info("MikroTik: pool created", zap.String("pool", pool.Name), zap.Uint("router_id", routerID))
+	return nil
+}
+
+// Update replaces the settings of the named pool. An empty next pool or
+// comment clears it.
+func (s *IPPoolsService) Update(ctx context.Context, routerID uint, name string, pool IPPool) error {
+	if err := ValidatePoolRanges(pool.Ranges); err != nil {
+		return err
+	}
+	id, err := s.findID(ctx, routerID, name)
+	if err != nil {
+		return err
+	}
+
+	nextPool := pool.NextPool
+	if nextPool == "" {
+		nextPool = "none"
+	}
+	args := []string{"/ip/pool/set", "=.id=" + id,
+		"=ranges=" + pool.Ranges,
+		"=next-pool=" + nextPool,
+		"=comment=" + pool.Comment,
+	}
+	if pool.Name != "" && pool.Name != name {
+		args = append(args, "=name="+pool.Name)
+	}
+	if _, err := s.client.run(ctx, routerID, args...); err != nil {
+		return fmt.Errorf("UpdatePool failed: %w", err)
+	}
+	logger.Info("MikroTik: pool updated", zap.String("pool", name), zap.Uint("router_id", routerID))
+	return nil
+}
+
+func (s *IPPoolsService) Remove(ctx context.Context, routerID uint, name string) error {
+	id, err := s.findID(ctx, routerID, name)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.run(ctx, routerID, "/ip/pool/remove", "=.id="+id); err != nil {
+		return fmt.Errorf("RemovePool failed: %w", err)
+	}
+	logger.Info("MikroTik: pool removed", zap.String("pool", name), zap.Uint("router_id", routerID))
+	return nil
+}
+
+// AssignToProfile makes a PPP profile hand out remote addresses from pool.
+func (s *IPPoolsService) AssignToProfile(ctx context.Context, routerID uint, pool, profile string) error {
+	if _, err := s.findID(ctx, routerID, pool); err != nil {
+		return err
+	}
+	reply, err := s.client.run(ctx, routerID, "/ppp/profile/print", "?name="+profile)
+	if err != nil {
+		return fmt.Errorf("profile lookup failed: %w", err)
+	}
+	if len(reply.Re) == 0 {
+		return fmt.Errorf("profile not found: %s", profile)
+	}
+	if _, err := s.client.run(ctx, routerID, "/ppp/profile/set", "=.id="+reply.Re[0].Map[".id"], "=remote-address="+pool); err != nil {
+		return fmt.Errorf("AssignPool failed: %w", err)
+	}
+	logger.Info("MikroTik: pool assigned to profile",
+		zap.String("pool", pool),
+		zap.String("profile", profile),
+		zap.Uint("router_id", routerID),
+	)
+	return nil
+}
+
+// Utilization compares each pool's ranges with the addresses of active PPP
+// sessions. Pools at or above threshold percent are flagged.
+func (s *IPPoolsService) Utilization(ctx context.Context, routerID uint, threshold float64) ([]PoolUtilization, error) {
+	pools, err := s.List(ctx, routerID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.client.GetActiveSessions(ctx, routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []uint32
+	for _, session := range sessions {
+		if addr, ok := ipToUint(net.ParseIP(session.Address)); ok {
+			addresses = append(addresses, addr)
+		}
+	}
+
+	result := make([]PoolUtilization, 0, len(pools))
+	for _, pool := range pools {
+		u := PoolUtilization{
+			RouterID: routerID,
+			Name:     pool.Name,
+			Ranges:   pool.Ranges,
+			NextPool: pool.NextPool,
+		}
+
+		ranges, err := parsePoolRanges(pool.Ranges)
+		if err != nil {
+			u.Error = err.Error()
+			result = append(result, u)
+			continue
+		}
+		for _, r := range ranges {
+			u.Size += int(r.last) - int(r.first) + 1
+		}
+		for _, addr := range addresses {
+			for _, r := range ranges {
+				if addr >= r.first && addr <= r.last {
+					u.Used++
+					break
+				}
+			}
+		}
+
+		u.Free = u.Size - u.Used
+		if u.Size > 0 {
+			u.Percent = float64(u.Used) * 100 / float64(u.Size)
+		}
+		if threshold > 0 && u.Percent >= threshold {
+			u.Warning = true
+			logger.Warn("MikroTik: pool utilization above threshold",
+				zap.Uint("router_id", routerID),
+				zap.String("pool", pool.Name),
+				zap.Int("used", u.Used),
+				zap.Int("size", u.Size),
+			)
+		}
+		result = append(result, u)
+	}
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package mikrotik
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GetLogs returns the router's /log buffer, oldest first.
+func (c *MikroTikClient) GetLogs(ctx context.Context, routerID uint) ([]HotspotLog, error) {
+	reply, err := c.run(ctx, routerID, "/log/print")
+	if err != nil {
+		return nil, fmt.Errorf("GetLogs failed: %w", err)
+	}
+
+	now := time.Now()
+	logs := make([]HotspotLog, 0, len(reply.Re))
+	for _, re := range reply.Re {
+		logs = append(logs, HotspotLog{
+			ID:       re.Map[".id"],
+			RouterID: routerID,
+			Time:     parseLogTime(re.Map["time"], now),
+			Topic:    re.Map["topics"],
+			Message:  re.Map["message"],
+		})
+	}
+	return logs, nil
+}
+
+// parseLogTime parses the time column of /log/print. RouterOS shows only the
+// clock for today's entries and omits the year for this year's, so those
+// are resolved against now in the local time zone.
+func parseLogTime(value string, now time.Time) time.Time {
+	value = strings.TrimSpace(value)
+	loc := now.Location()
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "Jan/02/2006 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t
+		}
+	}
+
+	// Clock only: today, or yesterday when that would be in the future.
+	if t, err := time.ParseInLocation("15:04:05", value, loc); err == nil {
+		t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+		if t.After(now.Add(time.Minute)) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t
+	}
+
+	// Month and day without a year: this year, or last year when that would
+	// be in the future.
+	for _, layout := range []string{"Jan/02 15:04:05", "01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+			if t.After(now.Add(time.Minute)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			return t
+		}
+	}
+
+	return time.Time{}
+}
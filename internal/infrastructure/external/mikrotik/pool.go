@@ -0,0 +1,548 @@
+package mikrotik
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	routeros "github.com/go-routeros/routeros/v3"
+	"go.uber.org/zap"
+)
+
+// Connection states reported for every router in the pool.
+const (
+	StateDisconnected = "disconnected"
+	StateConnecting   = "connecting"
+	StateConnected    = "connected"
+	StateBackoff      = "backoff"
+)
+
+// PoolConfig tunes the per-router connection pool. Zero values fall back to
+// the defaults below.
+type PoolConfig struct {
+	DialTimeout       time.Duration
+	CommandTimeout    time.Duration
+	ReadTimeout       time.Duration
+	KeepaliveInterval time.Duration
+	KeepaliveTimeout  time.Duration
+	MaxConcurrent     int
+	MinBackoff        time.Duration
+	MaxBackoff        time.Duration
+}
+
+const (
+	defaultDialTimeout       = 10 * time.Second
+	defaultCommandTimeout    = 15 * time.Second
+	defaultReadTimeout       = 30 * time.Second
+	defaultKeepaliveInterval = 30 * time.Second
+	defaultKeepaliveTimeout  = 10 * time.Second
+	defaultMaxConcurrent     = 4
+	defaultMinBackoff        = 2 * time.Second
+	defaultMaxBackoff        = time.Minute
+)
+
+func (cfg PoolConfig) withDefaults() PoolConfig {
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = defaultDialTimeout
+	}
+	if cfg.CommandTimeout <= 0 {
+		cfg.CommandTimeout = defaultCommandTimeout
+	}
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = defaultReadTimeout
+	}
+	if cfg.KeepaliveInterval <= 0 {
+		cfg.KeepaliveInterval = defaultKeepaliveInterval
+	}
+	if cfg.KeepaliveTimeout <= 0 {
+		cfg.KeepaliveTimeout = defaultKeepaliveTimeout
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = defaultMaxConcurrent
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = defaultMinBackoff
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = defaultMaxBackoff
+		if cfg.MaxBackoff < cfg.MinBackoff {
+			cfg.MaxBackoff = cfg.MinBackoff
+		}
+	}
+	return cfg
+}
+
+// ConnectionState is a point-in-time view of a pooled router connection.
+// CertificateError is set while the router is down because its TLS
+// certificate was rejected.
+type ConnectionState struct {
+	State       string
+	LastError   string
+	LastErrorAt *time.Time
+	ConnectedAt *time.Time
+	Failures    int
+	NextRetry   *time.Time
+
+	CertificateError string
+}
+
+// routerConn owns the transport for one router. Up to MaxConcurrent
+// commands may run on it at once.
+type routerConn struct {
+	routerID uint
+	sem      chan struct{}
+
+	mu          sync.Mutex
+	info        *MikroTikConnectionInfo
+	client      Transport
+	state       string
+	lastError   string
+	lastErrorAt *time.Time
+	connectedAt *time.Time
+	failures    int
+	nextRetry   time.Time
+	certError   string
+	dialing     chan struct{}
+	probing     bool
+	gen         int
+}
+
+func newRouterConn(routerID uint, maxConcurrent int) *routerConn {
+	return &routerConn{
+		routerID: routerID,
+		sem:      make(chan struct{}, maxConcurrent),
+		state:    StateDisconnected,
+	}
+}
+
+// snapshot returns the current connection state.
+func (rc *routerConn) snapshot() ConnectionState {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	s := ConnectionState{
+		State:       rc.state,
+		LastError:   rc.lastError,
+		LastErrorAt: rc.lastErrorAt,
+		ConnectedAt: rc.connectedAt,
+		Failures:    rc.failures,
+
+		CertificateError: rc.certError,
+	}
+	if rc.state == StateBackoff {
+		next := rc.nextRetry
+		s.NextRetry = &next
+	}
+	return s
+}
+
+// recordError stores err as the last error seen on this router.
+// Caller must hold rc.mu.
+func (rc *routerConn) recordError(err error) {
+	now := time.Now()
+	rc.lastError = err.Error()
+	rc.lastErrorAt = &now
+}
+
+// markBroken drops client if it is still the current session so the next
+// command re-dials. Stale clients (already replaced) are only closed.
+func (rc *routerConn) markBroken(client Transport, err error) {
+	rc.mu.Lock()
+	current := rc.client == client
+	if current {
+		rc.client = nil
+		rc.connectedAt = nil
+		rc.state = StateDisconnected
+		rc.recordError(err)
+	}
+	rc.mu.Unlock()
+
+	client.Close()
+
+	if current {
+		logger.Warn("MikroTik connection lost",
+			zap.Uint("router_id", rc.routerID),
+			zap.Error(err),
+		)
+	}
+}
+
+// reset closes the session and forgets cached credentials and backoff.
+func (rc *routerConn) reset() {
+	rc.mu.Lock()
+	client := rc.client
+	rc.client = nil
+	rc.info = nil
+	rc.connectedAt = nil
+	rc.failures = 0
+	rc.nextRetry = time.Time{}
+	rc.certError = ""
+	rc.state = StateDisconnected
+	rc.gen++
+	if rc.dialing != nil {
+		close(rc.dialing)
+		rc.dialing = nil
+	}
+	rc.mu.Unlock()
+
+	if client != nil {
+		client.Close()
+	}
+}
+
+// isTransportError reports whether err means the session itself is unusable.
+// A !trap from the device leaves the connection healthy.
+func isTransportError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var devErr *routeros.DeviceError
+	return !errors.As(err, &devErr)
+}
+
+// connPool keeps one self-healing session per router.
+type connPool struct {
+	cfg     PoolConfig
+	resolve func(routerID uint) (*MikroTikConnectionInfo, error)
+
+	mu    sync.Mutex
+	conns map[uint]*routerConn
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newConnPool(cfg PoolConfig, resolve func(routerID uint) (*MikroTikConnectionInfo, error)) *connPool {
+	return &connPool{
+		cfg:     cfg.withDefaults(),
+		resolve: resolve,
+		conns:   make(map[uint]*routerConn),
+		stop:    make(chan struct{}),
+	}
+}
+
+// conn returns the pool entry for routerID, creating it on first use.
+func (p *connPool) conn(routerID uint) *routerConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rc, ok := p.conns[routerID]
+	if !ok {
+		rc = newRouterConn(routerID, p.cfg.MaxConcurrent)
+		p.conns[routerID] = rc
+	}
+	return rc
+}
+
+// lookup returns the pool entry for routerID without creating one.
+func (p *connPool) lookup(routerID uint) *routerConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.conns[routerID]
+}
+
+// setInfo replaces the cached connection parameters for a router.
+func (p *connPool) setInfo(routerID uint, info *MikroTikConnectionInfo) {
+	rc := p.conn(routerID)
+	rc.mu.Lock()
+	rc.info = info
+	rc.mu.Unlock()
+}
+
+// info returns the cached connection parameters, loading them if needed.
+func (p *connPool) info(routerID uint) (*MikroTikConnectionInfo, error) {
+	rc := p.conn(routerID)
+	rc.mu.Lock()
+	info := rc.info
+	rc.mu.Unlock()
+	if info != nil {
+		return info, nil
+	}
+
+	info, err := p.resolve(routerID)
+	if err != nil {
+		return nil, err
+	}
+	p.setInfo(routerID, info)
+	return info, nil
+}
+
+// client returns the live session for routerID, dialing when there is none.
+// While the router is in backoff the call fails fast unless force is set.
+// Concurrent callers share a single dial attempt that runs independently of
+// any one caller, so a cancelled request does not abort it for the others.
+func (p *connPool) client(ctx context.Context, routerID uint, force bool) (Transport, error) {
+	rc := p.conn(routerID)
+
+	rc.mu.Lock()
+	if rc.client != nil {
+		client := rc.client
+		rc.mu.Unlock()
+		return client, nil
+	}
+	if rc.dialing == nil {
+		if rc.state == StateBackoff && !force && time.Now().Before(rc.nextRetry) {
+			retryIn := time.Until(rc.nextRetry).Round(time.Second)
+			lastError := rc.lastError
+			rc.mu.Unlock()
+			return nil, fmt.Errorf("router %d unavailable, retrying in %s: %s", routerID, retryIn, lastError)
+		}
+		rc.dialing = make(chan struct{})
+		rc.state = StateConnecting
+		go p.connect(rc, rc.gen, rc.dialing)
+	}
+	wait := rc.dialing
+	rc.mu.Unlock()
+
+	select {
+	case <-wait:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("router %d: waiting for connection: %w", routerID, ctx.Err())
+	}
+
+	rc.mu.Lock()
+	client, lastError := rc.client, rc.lastError
+	rc.mu.Unlock()
+	if client == nil {
+		return nil, fmt.Errorf("failed to connect to router %d: %s", routerID, lastError)
+	}
+	return client, nil
+}
+
+// connect dials rc and records the outcome, then closes done to wake the
+// waiters. gen is the reset generation the dial was started under; a session
+// dialed before a reset is discarded, and done is left alone because reset
+// already closed it and rc.dialing may belong to a newer dial by then.
+func (p *connPool) connect(rc *routerConn, gen int, done chan struct{}) {
+	client, err := p.dial(rc.routerID)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if gen != rc.gen {
+		if client != nil {
+			client.Close()
+		}
+		return
+	}
+
+	close(done)
+	rc.dialing = nil
+
+	if err != nil {
+		rc.failures++
+		backoff := p.cfg.MinBackoff << (rc.failures - 1)
+		if backoff > p.cfg.MaxBackoff || backoff <= 0 {
+			backoff = p.cfg.MaxBackoff
+		}
+		rc.nextRetry = time.Now().Add(backoff)
+		rc.state = StateBackoff
+		rc.recordError(err)
+		rc.certError = ""
+		var certErr *CertificateError
+		if errors.As(err, &certErr) {
+			rc.certError = certErr.Error()
+		}
+		logger.Warn("MikroTik connection failed",
+			zap.Uint("router_id", rc.routerID),
+			zap.Int("failures", rc.failures),
+			zap.Duration("retry_in", backoff),
+			zap.Error(err),
+		)
+		return
+	}
+
+	now := time.Now()
+	rc.client = client
+	rc.state = StateConnected
+	rc.connectedAt = &now
+	rc.failures = 0
+	rc.nextRetry = time.Time{}
+	rc.certError = ""
+
+	// Treat a session that fails on its own as broken instead of waiting
+	// for the next command to notice.
+	if failed := client.Failed(); failed != nil {
+		go func() {
+			if err, ok := <-failed; ok && err != nil {
+				rc.markBroken(client, err)
+			}
+		}()
+	}
+
+	logger.Info("MikroTik connected", zap.Uint("router_id", rc.routerID))
+}
+
+// dial opens and logs in a new transport. The login exchange of the binary
+// API ignores deadlines in the RouterOS library, so the whole dial is
+// bounded here.
+func (p *connPool) dial(routerID uint) (Transport, error) {
+	info, err := p.info(routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		client Transport
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		client, err := dialTransport(info, p.cfg.DialTimeout)
+		done <- result{client, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to connect to router %d (%s): %w", routerID, info.Host, r.err)
+		}
+		return r.client, nil
+	case <-time.After(p.cfg.DialTimeout):
+		go func() {
+			if r := <-done; r.client != nil {
+				r.client.Close()
+			}
+		}()
+		return nil, fmt.Errorf("failed to connect to router %d (%s): login timed out after %s", routerID, info.Host, p.cfg.DialTimeout)
+	}
+}
+
+// run executes one command on routerID, waiting for a free slot first.
+// Every wait honours ctx. Transport failures drop the session so the next
+// call reconnects, and a missed deadline triggers an immediate keepalive
+// probe.
+func (p *connPool) run(ctx context.Context, routerID uint, force bool, sentence ...string) (*routeros.Reply, error) {
+	rc := p.conn(routerID)
+
+	select {
+	case rc.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("router %d busy: %w", routerID, ctx.Err())
+	}
+	defer func() { <-rc.sem }()
+
+	client, err := p.client(ctx, routerID, force)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := client.Run(ctx, sentence...)
+	if err != nil && ctx.Err() != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			go p.probe(rc)
+		}
+		return nil, fmt.Errorf("router %d: %s: %w", routerID, sentence[0], ctx.Err())
+	}
+	if isTransportError(err) {
+		rc.markBroken(client, err)
+	}
+	return reply, err
+}
+
+// reset closes the session for routerID and forgets its parameters, e.g.
+// after the router was edited or deleted.
+func (p *connPool) reset(routerID uint) {
+	if rc := p.lookup(routerID); rc != nil {
+		rc.reset()
+	}
+}
+
+// remove closes and drops the pool entry for routerID.
+func (p *connPool) remove(routerID uint) {
+	p.mu.Lock()
+	rc := p.conns[routerID]
+	delete(p.conns, routerID)
+	p.mu.Unlock()
+
+	if rc != nil {
+		rc.reset()
+	}
+}
+
+// state returns the connection state for routerID.
+func (p *connPool) state(routerID uint) ConnectionState {
+	if rc := p.lookup(routerID); rc != nil {
+		return rc.snapshot()
+	}
+	return ConnectionState{State: StateDisconnected}
+}
+
+// keepalive probes every connected router and retries routers whose backoff
+// has elapsed. Each router is handled in its own goroutine so a hung device
+// cannot delay the others.
+func (p *connPool) keepalive() {
+	ticker := time.NewTicker(p.cfg.KeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			conns := make([]*routerConn, 0, len(p.conns))
+			for _, rc := range p.conns {
+				conns = append(conns, rc)
+			}
+			p.mu.Unlock()
+
+			for _, rc := range conns {
+				go p.probe(rc)
+			}
+		}
+	}
+}
+
+// probe runs a cheap command on a connected router, or attempts a reconnect
+// once the backoff has elapsed.
+func (p *connPool) probe(rc *routerConn) {
+	rc.mu.Lock()
+	if rc.probing || rc.dialing != nil {
+		rc.mu.Unlock()
+		return
+	}
+	rc.probing = true
+	client := rc.client
+	due := rc.state == StateBackoff && !time.Now().Before(rc.nextRetry)
+	rc.mu.Unlock()
+
+	defer func() {
+		rc.mu.Lock()
+		rc.probing = false
+		rc.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.KeepaliveTimeout)
+	defer cancel()
+
+	if client == nil {
+		if due {
+			p.client(ctx, rc.routerID, false)
+		}
+		return
+	}
+
+	if _, err := client.Run(ctx, "/system/identity/print"); isTransportError(err) {
+		rc.markBroken(client, fmt.Errorf("keepalive failed: %w", err))
+	}
+}
+
+// close stops the keepalive loop and closes every session.
+func (p *connPool) close() {
+	p.stopOnce.Do(func() { close(p.stop) })
+
+	p.mu.Lock()
+	conns := make([]*routerConn, 0, len(p.conns))
+	for _, rc := range p.conns {
+		conns = append(conns, rc)
+	}
+	p.mu.Unlock()
+
+	for _, rc := range conns {
+		rc.reset()
+	}
+}
@@ -1,6 +1,8 @@
 package mikrotik
 
 import (
+	"context"
+
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
 )
 
@@ -18,40 +20,40 @@ func NewPPPoEService(client *MikroTikClient, customerRepo repositories.CustomerR
 	}
 }
 
-func (s *PPPoEService) GetPPPUsers() ([]PPPoEUser, error) {
+func (s *PPPoEService) GetPPPUsers(ctx context.Context) ([]PPPoEUser, error) {
 	_, routerID, err := s.client.GetActiveRouter()
 	if err != nil {
 		return nil, err
 	}
 
-	return s.client.GetAllUsers(routerID)
+	return s.client.GetAllUsers(ctx, routerID)
 }
 
-func (s *PPPoEService) GetPPPUsersByRouter(routerID uint) ([]PPPoEUser, error) {
-	return s.client.GetAllUsers(routerID)
+func (s *PPPoEService) GetPPPUsersByRouter(ctx context.Context, routerID uint) ([]PPPoEUser, error) {
+	return s.client.GetAllUsers(ctx, routerID)
 }
 
-func (s *PPPoEService) AddPPPUser(username, password, profile string, routerID uint) error {
-	return s.client.AddUser(routerID, username, password, profile)
+func (s *PPPoEService) AddPPPUser(ctx context.Context, username, password, profile string, routerID uint) error {
+	return s.client.AddUser(ctx, routerID, username, password, profile)
 }
 
-func (s *PPPoEService) RemovePPPUser(username string, routerID uint) error {
-	return s.client.RemoveUser(routerID, username)
+func (s *PPPoEService) RemovePPPUser(ctx context.Context, username string, routerID uint) error {
+	return s.client.RemoveUser(ctx, routerID, username)
 }
 
-func (s *PPPoEService) UpdatePPPUser(username string, routerID uint, args ...string) error {
-	return s.client.UpdateUser(routerID, username, args...)
+func (s *PPPoEService) UpdatePPPUser(ctx context.Context, username string, routerID uint, args ...string) error {
+	return s.client.UpdateUser(ctx, routerID, username, args...)
 }
 
-func (s *PPPoEService) GetPPPProfiles() ([]Profile, error) {
+func (s *PPPoEService) GetPPPProfiles(ctx context.Context) ([]Profile, error) {
 	_, routerID, err := s.client.GetActiveRouter()
 	if err != nil {
 		return nil, err
 	}
 
-	return s.client.GetAllProfiles(routerID)
+	return s.client.GetAllProfiles(ctx, routerID)
 }
 
-func (s *PPPoEService) GetPPPProfilesByRouter(routerID uint) ([]Profile, error) {
-	return s.client.GetAllProfiles(routerID)
+func (s *PPPoEService) GetPPPProfilesByRouter(ctx context.Context, routerID uint) ([]Profile, error) {
+	return s.client.GetAllProfiles(ctx, routerID)
 }
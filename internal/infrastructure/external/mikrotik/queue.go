@@ -1,7 +1,12 @@
 package mikrotik
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
 )
 
 type QueueService struct {
@@ -15,3 +20,157 @@ func NewQueueService(client *MikroTikClient, routerRepo repositories.RouterRepos
 		routerRepo: routerRepo,
 	}
 }
+
+// queueArgs converts the settable fields of q into RouterOS attribute words.
+// Empty fields are omitted unless clear is set, in which case parent,
+// limit-at and comment are reset.
+func queueArgs(q SimpleQueue, clear bool) []string {
+	var args []string
+	if q.Name != "" {
+		args = append(args, "=name="+q.Name)
+	}
+	if q.Target != "" {
+		args = append(args, "=target="+q.Target)
+	}
+	if q.MaxLimit != "" {
+		args = append(args, "=max-limit="+q.MaxLimit)
+	}
+	if q.Priority != "" {
+		args = append(args, "=priority="+q.Priority)
+	}
+	if q.Parent != "" || clear {
+		parent := q.Parent
+		if parent == "" {
+			parent = "none"
+		}
+		args = append(args, "=parent="+parent)
+	}
+	if q.LimitAt != "" || clear {
+		limitAt := q.LimitAt
+		if limitAt == "" {
+			limitAt = "0/0"
+		}
+		args = append(args, "=limit-at="+limitAt)
+	}
+	if q.Comment != "" || clear {
+		args = append(args, "=comment="+q.Comment)
+	}
+	return args
+}
+
+func (s *QueueService) List(ctx context.Context, routerID uint) ([]SimpleQueue, error) {
+	reply, err := s.client.run(ctx, routerID, "/queue/simple/print")
+	if err != nil {
+		return nil, fmt.Errorf("ListQueues failed: %w", err)
+	}
+
+	queues := make([]SimpleQueue, 0, len(reply.Re))
+	for _, re := range reply.Re {
+		parent := re.Map["parent"]
+		if parent == "none" {
+			parent = ""
+		}
+		queues = append(queues, SimpleQueue{
+			ID:       re.Map[".id"],
+			Name:     re.Map["name"],
+			Target:   re.Map["target"],
+			Parent:   parent,
+			MaxLimit: re.Map["max-limit"],
+			LimitAt:  re.Map["limit-at"],
+			Priority: re.Map["priority"],
+			Comment:  re.Map["comment"],
+			Disabled: re.Map["disabled"] == "true",
+			Dynamic:  re.Map["dynamic"] == "true",
+			Rate:     re.Map["rate"],
+			Bytes:    re.Map["bytes"],
+		})
+	}
+	return queues, nil
+}
+
+// Get returns the queue with the given name, or nil when there is none.
+func (s *QueueService) Get(ctx context.Context, routerID uint, name string) (*SimpleQueue, error) {
+	queues, err := s.List(ctx, routerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range queues {
+		if queues[i].Name == name {
+			return &queues[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *QueueService) findID(ctx context.Context, routerID uint, name string) (string, error) {
+	reply, err := s.client.run(ctx, routerID, "/queue/simple/print", "?name="+name)
+	if err != nil {
+		return "", fmt.Errorf("queue lookup failed: %w", err)
+	}
+	if len(reply.Re) == 0 {
+		return "", fmt.Errorf("queue not found: %s", name)
+	}
+	return reply.Re[0].Map[".id"], nil
+}
+
+func (s *QueueService) Create(ctx context.Context, routerID uint, q SimpleQueue) error {
+	if q.Name == "" {
+		return fmt.Errorf("queue name is required")
+	}
+	args := append([]string{"/queue/simple/add"}, queueArgs(q, false)...)
+	if q.Disabled {
+		args = append(args, "=disabled=yes")
+	}
+	if _, err := s.client.run(ctx, routerID, args...); err != nil {
+		return fmt.Errorf("CreateQueue failed: %w", err)
+	}
+	logger.Info("MikroTik: queue created", zap.String("queue", q.Name), zap.Uint("router_id", routerID))
+	return nil
+}
+
+// Update replaces the settings of the named queue with q. Parent, limit-at
+// and comment are cleared when left empty.
+func (s *QueueService) Update(ctx context.Context, routerID uint, name string, q SimpleQueue) error {
+	id, err := s.findID(ctx, routerID, name)
+	if err != nil {
+		return err
+	}
+	args := append([]string{"/queue/simple/set", "=.id=" + id}, queueArgs(q, true)...)
+	if _, err := s.client.run(ctx, routerID, args...); err != nil {
+		return fmt.Errorf("UpdateQueue failed: %w", err)
+	}
+	logger.Info("MikroTik: queue updated", zap.String("queue", name), zap.Uint("router_id", routerID))
+	return nil
+}
+
+func (s *QueueService) SetDisabled(ctx context.Context, routerID uint, name string, disabled bool) error {
+	id, err := s.findID(ctx, routerID, name)
+	if err != nil {
+		return err
+	}
+	command := "/queue/simple/enable"
+	if disabled {
+		command = "/queue/simple/disable"
+	}
+	if _, err := s.client.run(ctx, routerID, command, "=.id="+id); err != nil {
+		return fmt.Errorf("SetQueueDisabled failed: %w", err)
+	}
+	logger.Info("MikroTik: queue state changed",
+		zap.String("queue", name),
+		zap.Uint("router_id", routerID),
+		zap.Bool("disabled", disabled),
+	)
+	return nil
+}
+
+func (s *QueueService) Remove(ctx context.Context, routerID uint, name string) error {
+	id, err := s.findID(ctx, routerID, name)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.run(ctx, routerID, "/queue/simple/remove", "=.id="+id); err != nil {
+		return fmt.Errorf("RemoveQueue failed: %w", err)
+	}
+	logger.Info("MikroTik: queue removed", zap.String("queue", name), zap.Uint("router_id", routerID))
+	return nil
+}
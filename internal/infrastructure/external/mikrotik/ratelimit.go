@@ -0,0 +1,77 @@
+package mikrotik
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseRateLimit converts a package speed such as "10 Mbps", "20M" or
+// "20/5 Mbps" (download/upload) into a RouterOS rate-limit string.
+// RouterOS orders the value rx/tx from the router's side, which is the
+// subscriber's upload/download, so "20/5 Mbps" becomes "5M/20M". A missing
+// unit inherits the other side's unit and defaults to megabits.
+func ParseRateLimit(speed string) (string, error) {
+	s := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(speed), " ", ""))
+	if s == "" {
+		return "", fmt.Errorf("speed is empty")
+	}
+
+	parts := strings.Split(s, "/")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("invalid speed %q", speed)
+	}
+
+	values := make([]string, len(parts))
+	units := make([]string, len(parts))
+	for i, part := range parts {
+		part = strings.TrimSuffix(part, "ps")
+		part = strings.TrimSuffix(part, "b")
+		unit := ""
+		if n := len(part); n > 0 && strings.ContainsAny(part[n-1:], "kmg") {
+			unit = strings.ToUpper(part[n-1:])
+			if unit == "K" {
+				unit = "k"
+			}
+			part = part[:n-1]
+		}
+		if _, err := strconv.ParseFloat(part, 64); err != nil || part == "" {
+			return "", fmt.Errorf("invalid speed %q", speed)
+		}
+		values[i] = part
+		units[i] = unit
+	}
+
+	for i := range units {
+		if units[i] == "" {
+			for j := range units {
+				if units[j] != "" {
+					units[i] = units[j]
+					break
+				}
+			}
+		}
+		if units[i] == "" {
+			units[i] = "M"
+		}
+	}
+
+	download := formatRate(values[0], units[0])
+	upload := download
+	if len(values) == 2 {
+		upload = formatRate(values[1], units[1])
+	}
+	return upload + "/" + download, nil
+}
+
+// formatRate renders value+unit as RouterOS expects. Fractions are not
+// accepted there, so "1.5M" is stepped down to "1500k".
+func formatRate(value, unit string) string {
+	lower := map[string]string{"G": "M", "M": "k", "k": ""}
+	v, _ := strconv.ParseFloat(value, 64)
+	for v != float64(int64(v)) && unit != "" {
+		v *= 1000
+		unit = lower[unit]
+	}
+	return strconv.FormatInt(int64(v), 10) + unit
+}
@@ -0,0 +1,188 @@
+package mikrotik
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	routeros "github.com/go-routeros/routeros/v3"
+	"github.com/go-routeros/routeros/v3/proto"
+)
+
+// restTransport speaks the RouterOS v7 REST API over HTTPS with the same
+// certificate checks as api-ssl. Every sentence is sent as a POST to
+// /rest/<menu>/<command>, which accepts the same attributes and queries as
+// the binary API.
+type restTransport struct {
+	baseURL  string
+	username string
+	password string
+	http     *http.Client
+}
+
+// restError is the body RouterOS returns with a failed REST call.
+type restError struct {
+	Error   int    `json:"error"`
+	Message string `json:"message"`
+	Detail  string `json:"detail"`
+}
+
+// dialREST builds a REST transport and checks the credentials with a cheap
+// command, so a router only counts as connected once it answers.
+func dialREST(info *MikroTikConnectionInfo, timeout time.Duration) (Transport, error) {
+	cfg, err := tlsConfig(info)
+	if err != nil {
+		return nil, err
+	}
+	t := &restTransport{
+		baseURL:  "https://" + net.JoinHostPort(info.Host, fmt.Sprint(info.Port)) + "/rest",
+		username: info.Username,
+		password: info.Password,
+		http: &http.Client{
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				TLSClientConfig:     cfg,
+				TLSHandshakeTimeout: timeout,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if _, err := t.Run(ctx, "/system/identity/print"); err != nil {
+		t.Close()
+		return nil, certificateError(info.Host, err)
+	}
+	return t, nil
+}
+
+func (t *restTransport) Run(ctx context.Context, sentence ...string) (*routeros.Reply, error) {
+	body, err := json.Marshal(restBody(sentence[1:]))
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+sentence[0], bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(t.username, t.password)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("REST login failed: %s", resp.Status)
+	case resp.StatusCode >= 400:
+		var e restError
+		if json.Unmarshal(data, &e) != nil || e.Message == "" {
+			return nil, fmt.Errorf("REST call failed: %s", resp.Status)
+		}
+		// Rejected commands are reported like a !trap on the binary API
+		// so they do not count as a broken connection.
+		message := e.Detail
+		if message == "" {
+			message = e.Message
+		}
+		return nil, &routeros.DeviceError{Sentence: &proto.Sentence{
+			Word: "!trap",
+			Map:  map[string]string{"message": message},
+			List: []proto.Pair{{Key: "message", Value: message}},
+		}}
+	}
+
+	return restReply(data)
+}
+
+// Failed is nil: HTTP requests do not share a session that could break.
+func (t *restTransport) Failed() <-chan error {
+	return nil
+}
+
+func (t *restTransport) Close() {
+	t.http.CloseIdleConnections()
+}
+
+// restBody translates API words into the JSON body of a REST call.
+// "=name=value" attributes become fields, ".proplist" becomes a list and
+// "?name=value" query words are collected in ".query" in their original
+// order, so stack operations such as "?#|" keep working.
+func restBody(words []string) map[string]interface{} {
+	body := make(map[string]interface{}, len(words))
+	var query []string
+	for _, word := range words {
+		if strings.HasPrefix(word, "?") {
+			query = append(query, word[1:])
+			continue
+		}
+		key, value, _ := strings.Cut(strings.TrimPrefix(word, "="), "=")
+		if key == "" {
+			continue
+		}
+		if key == ".proplist" {
+			body[key] = strings.Split(value, ",")
+			continue
+		}
+		body[key] = value
+	}
+	if len(query) > 0 {
+		body[".query"] = query
+	}
+	return body
+}
+
+// restReply converts a REST response to the binary API reply shape. A list
+// becomes one !re sentence per item; a single object, such as the "ret" of
+// an add, becomes the !done sentence.
+func restReply(data []byte) (*routeros.Reply, error) {
+	reply := &routeros.Reply{Done: &proto.Sentence{Word: "!done", Map: map[string]string{}}}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return reply, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid REST response: %w", err)
+	}
+
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok {
+				reply.Re = append(reply.Re, restSentence("!re", obj))
+			}
+		}
+	case map[string]interface{}:
+		reply.Done = restSentence("!done", v)
+	}
+	return reply, nil
+}
+
+func restSentence(word string, obj map[string]interface{}) *proto.Sentence {
+	s := &proto.Sentence{Word: word, Map: make(map[string]string, len(obj))}
+	for key, value := range obj {
+		str := fmt.Sprint(value)
+		s.Map[key] = str
+		s.List = append(s.List, proto.Pair{Key: key, Value: str})
+	}
+	return s
+}
@@ -1,11 +1,15 @@
 package mikrotik
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/webhook"
 	"github.com/alijayanet/gembok-backend/pkg/logger"
 	"go.uber.org/zap"
 )
@@ -15,14 +19,30 @@ type MikroTikService struct {
 	customerRepo repositories.CustomerRepository
 	packageRepo  repositories.PackageRepository
 	routerRepo   repositories.RouterRepository
+	// isolationList is the firewall address-list used on routers in
+	// address-list isolation mode.
+	isolationList string
+	// webhooks receives customer.isolated and customer.activated for every
+	// customer whose status changes, whichever path changed it.
+	webhooks *webhook.Dispatcher
 }
 
-func NewMikroTikService(client *MikroTikClient, customerRepo repositories.CustomerRepository, packageRepo repositories.PackageRepository, routerRepo repositories.RouterRepository) *MikroTikService {
+func NewMikroTikService(client *MikroTikClient, customerRepo repositories.CustomerRepository, packageRepo repositories.PackageRepository, routerRepo repositories.RouterRepository, isolationList string, webhooks *webhook.Dispatcher) *MikroTikService {
 	return &MikroTikService{
-		client:       client,
-		customerRepo: customerRepo,
-		packageRepo:  packageRepo,
-		routerRepo:   routerRepo,
+		client:        client,
+		customerRepo:  customerRepo,
+		packageRepo:   packageRepo,
+		routerRepo:    routerRepo,
+		isolationList: isolationList,
+		webhooks:      webhooks,
+	}
+}
+
+// publishStatus publishes event for customer unless their status was
+// already previous, so re-applying a status on sync stays quiet.
+func (s *MikroTikService) publishStatus(event, previous string, customer *entities.Customer) {
+	if s.webhooks != nil && customer.Status != previous {
+		s.webhooks.Publish(event, customer)
 	}
 }
 
@@ -30,79 +50,79 @@ func (s *MikroTikService) GetActiveRouter() (*entities.Router, error) {
 	return s.routerRepo.FindActive()
 }
 
-func (s *MikroTikService) TestConnection(routerID uint) error {
-	return s.client.HealthCheck(routerID)
+func (s *MikroTikService) TestConnection(ctx context.Context, routerID uint) error {
+	return s.client.HealthCheck(ctx, routerID)
 }
 
-func (s *MikroTikService) GetAllRoutersStatus() ([]RouterStatus, error) {
-	return s.client.GetAllRoutersStatus()
+func (s *MikroTikService) GetAllRoutersStatus(ctx context.Context) ([]RouterStatus, error) {
+	return s.client.GetAllRoutersStatus(ctx)
 }
 
-func (s *MikroTikService) GetPPPUsers() ([]PPPoEUser, error) {
+func (s *MikroTikService) GetPPPUsers(ctx context.Context) ([]PPPoEUser, error) {
 	_, routerID, err := s.client.GetActiveRouter()
 	if err != nil {
 		return nil, err
 	}
 
-	return s.client.GetAllUsers(routerID)
+	return s.client.GetAllUsers(ctx, routerID)
 }
 
-func (s *MikroTikService) GetPPPUsersByRouter(routerID uint) ([]PPPoEUser, error) {
+func (s *MikroTikService) GetPPPUsersByRouter(ctx context.Context, routerID uint) ([]PPPoEUser, error) {
 	_, err := s.client.GetClient(routerID)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.client.GetAllUsers(routerID)
+	return s.client.GetAllUsers(ctx, routerID)
 }
 
-func (s *MikroTikService) AddPPPUser(username, password, profile string, routerID uint) error {
-	return s.client.AddUser(routerID, username, password, profile)
+func (s *MikroTikService) AddPPPUser(ctx context.Context, username, password, profile string, routerID uint) error {
+	return s.client.AddUser(ctx, routerID, username, password, profile)
 }
 
-func (s *MikroTikService) RemovePPPUser(username string, routerID uint) error {
-	return s.client.RemoveUser(routerID, username)
+func (s *MikroTikService) RemovePPPUser(ctx context.Context, username string, routerID uint) error {
+	return s.client.RemoveUser(ctx, routerID, username)
 }
 
-func (s *MikroTikService) UpdatePPPUser(username string, routerID uint, params map[string]interface{}) error {
+func (s *MikroTikService) UpdatePPPUser(ctx context.Context, username string, routerID uint, params map[string]interface{}) error {
 	args := make([]string, 0, len(params))
 	for key, val := range params {
 		args = append(args, fmt.Sprintf("=%s=%v", key, val))
 	}
-	return s.client.UpdateUser(routerID, username, args...)
+	return s.client.UpdateUser(ctx, routerID, username, args...)
 }
 
-func (s *MikroTikService) GetActiveSessions() ([]ActiveSession, error) {
+func (s *MikroTikService) GetActiveSessions(ctx context.Context) ([]ActiveSession, error) {
 	_, routerID, err := s.client.GetActiveRouter()
 	if err != nil {
 		return nil, err
 	}
 
-	return s.client.GetActiveSessions(routerID)
+	return s.client.GetActiveSessions(ctx, routerID)
 }
 
-func (s *MikroTikService) GetActiveSessionsByRouter(routerID uint) ([]ActiveSession, error) {
-	return s.client.GetActiveSessions(routerID)
+func (s *MikroTikService) GetActiveSessionsByRouter(ctx context.Context, routerID uint) ([]ActiveSession, error) {
+	return s.client.GetActiveSessions(ctx, routerID)
 }
 
-func (s *MikroTikService) DisconnectUser(username string, routerID uint) error {
-	return s.client.DisconnectUser(routerID, username)
+func (s *MikroTikService) DisconnectUser(ctx context.Context, username string, routerID uint) error {
+	return s.client.DisconnectUser(ctx, routerID, username)
 }
 
-func (s *MikroTikService) GetPPPProfiles() ([]Profile, error) {
+func (s *MikroTikService) GetPPPProfiles(ctx context.Context) ([]Profile, error) {
 	_, routerID, err := s.client.GetActiveRouter()
 	if err != nil {
 		return nil, err
 	}
 
-	return s.client.GetAllProfiles(routerID)
+	return s.client.GetAllProfiles(ctx, routerID)
 }
 
-func (s *MikroTikService) GetPPPProfilesByRouter(routerID uint) ([]Profile, error) {
-	return s.client.GetAllProfiles(routerID)
+func (s *MikroTikService) GetPPPProfilesByRouter(ctx context.Context, routerID uint) ([]Profile, error) {
+	return s.client.GetAllProfiles(ctx, routerID)
 }
 
-func (s *MikroTikService) CreateCustomerOnMikroTik(customer *entities.Customer) error {
+func (s *MikroTikService) CreateCustomerOnMikroTik(ctx context.Context, customer *entities.Customer) error {
 	if customer.RouterID == 0 {
 		router, err := s.routerRepo.FindActive()
 		if err != nil {
@@ -119,7 +139,9 @@ func (s *MikroTikService) CreateCustomerOnMikroTik(customer *entities.Customer)
 		return fmt.Errorf("PPPoE password is required")
 	}
 
-	err := s.client.AddUser(customer.RouterID, customer.PPPoEUsername, customer.PPPoEPassword, "default")
+	profile := s.customerProfile(customer)
+
+	err := s.client.AddUser(ctx, customer.RouterID, customer.PPPoEUsername, customer.PPPoEPassword, profile)
 	if err != nil {
 		return fmt.Errorf("failed to create PPPoE user on MikroTik: %w", err)
 	}
@@ -128,12 +150,13 @@ func (s *MikroTikService) CreateCustomerOnMikroTik(customer *entities.Customer)
 		zap.Uint("customer_id", customer.ID),
 		zap.Uint("router_id", customer.RouterID),
 		zap.String("username", customer.PPPoEUsername),
+		zap.String("profile", profile),
 	)
 
 	return nil
 }
 
-func (s *MikroTikService) DeleteCustomerFromMikroTik(customer *entities.Customer) error {
+func (s *MikroTikService) DeleteCustomerFromMikroTik(ctx context.Context, customer *entities.Customer) error {
 	if customer.RouterID == 0 {
 		return fmt.Errorf("customer has no router assigned")
 	}
@@ -142,7 +165,7 @@ func (s *MikroTikService) DeleteCustomerFromMikroTik(customer *entities.Customer
 		return fmt.Errorf("customer has no PPPoE username")
 	}
 
-	err := s.client.RemoveUser(customer.RouterID, customer.PPPoEUsername)
+	err := s.client.RemoveUser(ctx, customer.RouterID, customer.PPPoEUsername)
 	if err != nil {
 		return fmt.Errorf("failed to delete PPPoE user from MikroTik: %w", err)
 	}
@@ -156,11 +179,132 @@ func (s *MikroTikService) DeleteCustomerFromMikroTik(customer *entities.Customer
 	return nil
 }
 
-func (s *MikroTikService) IsolateCustomer(customer *entities.Customer) error {
+// ActiveProfile is the profile of a customer who is not isolated: the
+// package's throttle profile while over the fair-usage quota, otherwise the
+// normal one.
+func ActiveProfile(customer *entities.Customer, pkg *entities.Package) string {
+	if customer.QuotaThrottled && pkg.ProfileThrottle != "" {
+		return pkg.ProfileThrottle
+	}
+	return pkg.ProfileNormal
+}
+
+// customerProfile returns the PPP profile for a customer's package and
+// status, falling back to "default" when the package does not name one.
+func (s *MikroTikService) customerProfile(customer *entities.Customer) string {
+	if customer.PackageID == 0 {
+		return "default"
+	}
+	pkg, err := s.packageRepo.FindByID(customer.PackageID)
+	if err != nil {
+		return "default"
+	}
+	profile := ActiveProfile(customer, pkg)
+	if customer.Status == "isolated" && s.isolationMode(customer.RouterID) == entities.IsolationProfile {
+		profile = pkg.ProfileIsolir
+	}
+	if profile == "" {
+		return "default"
+	}
+	return profile
+}
+
+// isolationMode returns how customers on a router are isolated.
+func (s *MikroTikService) isolationMode(routerID uint) string {
+	router, err := s.routerRepo.FindByID(routerID)
+	if err != nil || router.IsolationMode == "" {
+		return entities.IsolationProfile
+	}
+	return router.IsolationMode
+}
+
+// IsolationCommentPrefix starts the comment of every customer isolation
+// address-list entry.
+const IsolationCommentPrefix = "gembok:customer:"
+
+// IsolationComment tags a customer's isolation address-list entries.
+func IsolationComment(customer *entities.Customer) string {
+	return fmt.Sprintf("%s%d", IsolationCommentPrefix, customer.ID)
+}
+
+// IsolationAddress returns the address a customer is isolated at: the
+// static IP when set, otherwise the address of their active session, or ""
+// when they are offline.
+func IsolationAddress(customer *entities.Customer, session *ActiveSession) string {
+	if address := strings.TrimSuffix(customer.StaticIP, "/32"); address != "" {
+		return address
+	}
+	if session != nil {
+		return session.Address
+	}
+	return ""
+}
+
+// isolateByAddressList adds the customer's address to the isolation
+// address-list, replacing earlier entries of the customer. A customer who
+// is offline is still marked isolated without an entry; the isolation
+// refresh adds their address once they connect and follows it when they
+// reconnect with another one.
+func (s *MikroTikService) isolateByAddressList(ctx context.Context, customer *entities.Customer) error {
+	var session *ActiveSession
+	if customer.StaticIP == "" && customer.PPPoEUsername != "" {
+		var err error
+		session, err = s.client.GetActiveSession(ctx, customer.RouterID, customer.PPPoEUsername)
+		if err != nil {
+			return fmt.Errorf("failed to look up customer session: %w", err)
+		}
+	}
+	address := IsolationAddress(customer, session)
+	if address == "" && customer.PPPoEUsername == "" {
+		return fmt.Errorf("customer has no static IP and no PPPoE username to isolate")
+	}
+
+	comment := IsolationComment(customer)
+	if _, err := s.client.RemoveFromAddressList(ctx, customer.RouterID, s.isolationList, comment); err != nil {
+		return fmt.Errorf("failed to isolate customer on MikroTik: %w", err)
+	}
+	if address != "" {
+		if err := s.client.AddToAddressList(ctx, customer.RouterID, s.isolationList, address, comment); err != nil {
+			return fmt.Errorf("failed to isolate customer on MikroTik: %w", err)
+		}
+	}
+
+	now := time.Now()
+	customer.Status = "isolated"
+	customer.IsolationDate = &now
+	customer.IsolatedIP = address
+
+	if err := s.customerRepo.Update(customer); err != nil {
+		logger.Error("Failed to update customer status",
+			zap.Uint("customer_id", customer.ID),
+			zap.Error(err),
+		)
+	}
+
+	logger.Info("Customer isolated on MikroTik",
+		zap.Uint("customer_id", customer.ID),
+		zap.Uint("router_id", customer.RouterID),
+		zap.String("address_list", s.isolationList),
+		zap.String("address", address),
+	)
+
+	return nil
+}
+
+func (s *MikroTikService) IsolateCustomer(ctx context.Context, customer *entities.Customer) error {
 	if customer.RouterID == 0 {
 		return fmt.Errorf("customer has no router assigned")
 	}
 
+	previous := customer.Status
+	if s.isolationMode(customer.RouterID) == entities.IsolationAddressList {
+		if err := s.isolateByAddressList(ctx, customer); err != nil {
+			return err
+		}
+		s.publishStatus(webhook.EventCustomerIsolated, previous, customer)
+		return nil
+	}
+
 	if customer.PPPoEUsername == "" {
 		return fmt.Errorf("customer has no PPPoE username")
 	}
@@ -178,7 +322,7 @@ func (s *MikroTikService) IsolateCustomer(customer *entities.Customer) error {
 		return fmt.Errorf("package has no isolation profile configured")
 	}
 
-	err = s.client.SetActiveProfile(customer.RouterID, customer.PPPoEUsername, pkg.ProfileIsolir)
+	err = s.client.SetActiveProfile(ctx, customer.RouterID, customer.PPPoEUsername, pkg.ProfileIsolir)
 	if err != nil {
 		return fmt.Errorf("failed to isolate customer on MikroTik: %w", err)
 	}
@@ -201,35 +345,55 @@ func (s *MikroTikService) IsolateCustomer(customer *entities.Customer) error {
 		zap.String("username", customer.PPPoEUsername),
 		zap.String("profile", pkg.ProfileIsolir),
 	)
+	s.publishStatus(webhook.EventCustomerIsolated, previous, customer)
 
 	return nil
 }
 
-func (s *MikroTikService) ActivateCustomer(customer *entities.Customer) error {
+func (s *MikroTikService) ActivateCustomer(ctx context.Context, customer *entities.Customer) error {
 	if customer.RouterID == 0 {
 		return fmt.Errorf("customer has no router assigned")
 	}
 
-	if customer.PPPoEUsername == "" {
-		return fmt.Errorf("customer has no PPPoE username")
+	previous := customer.Status
+	mode := s.isolationMode(customer.RouterID)
+	if mode == entities.IsolationAddressList || customer.IsolatedIP != "" {
+		if _, err := s.client.RemoveFromAddressList(ctx, customer.RouterID, s.isolationList, IsolationComment(customer)); err != nil {
+			return fmt.Errorf("failed to activate customer on MikroTik: %w", err)
+		}
+		customer.IsolatedIP = ""
 	}
 
-	if customer.PackageID == 0 {
-		return fmt.Errorf("customer has no package assigned")
-	}
+	// Address-list isolation leaves the secret on its active profile, but
+	// the quota state may have changed while isolated, so the profile is set
+	// in both modes. Customers isolated by address-list may have no secret or
+	// package profile at all; they are only taken off the list.
+	profile := ""
+	if mode == entities.IsolationProfile || (customer.PPPoEUsername != "" && customer.PackageID != 0) {
+		if customer.PPPoEUsername == "" {
+			return fmt.Errorf("customer has no PPPoE username")
+		}
 
-	pkg, err := s.packageRepo.FindByID(customer.PackageID)
-	if err != nil {
-		return fmt.Errorf("failed to get customer package: %w", err)
-	}
+		if customer.PackageID == 0 {
+			return fmt.Errorf("customer has no package assigned")
+		}
 
-	if pkg.ProfileNormal == "" {
-		return fmt.Errorf("package has no normal profile configured")
-	}
+		pkg, err := s.packageRepo.FindByID(customer.PackageID)
+		if err != nil {
+			return fmt.Errorf("failed to get customer package: %w", err)
+		}
 
-	err = s.client.SetActiveProfile(customer.RouterID, customer.PPPoEUsername, pkg.ProfileNormal)
-	if err != nil {
-		return fmt.Errorf("failed to activate customer on MikroTik: %w", err)
+		if pkg.ProfileNormal == "" && mode == entities.IsolationProfile {
+			return fmt.Errorf("package has no normal profile configured")
+		}
+		profile = ActiveProfile(customer, pkg)
+
+		if profile != "" {
+			err = s.client.SetActiveProfile(ctx, customer.RouterID, customer.PPPoEUsername, profile)
+			if err != nil {
+				return fmt.Errorf("failed to activate customer on MikroTik: %w", err)
+			}
+		}
 	}
 
 	now := time.Now()
@@ -237,8 +401,7 @@ func (s *MikroTikService) ActivateCustomer(customer *entities.Customer) error {
 	customer.ActivationDate = &now
 	customer.IsolationDate = nil
 
-	err = s.customerRepo.Update(customer)
-	if err != nil {
+	if err := s.customerRepo.Update(customer); err != nil {
 		logger.Error("Failed to update customer status",
 			zap.Uint("customer_id", customer.ID),
 			zap.Error(err),
@@ -249,45 +412,70 @@ func (s *MikroTikService) ActivateCustomer(customer *entities.Customer) error {
 		zap.Uint("customer_id", customer.ID),
 		zap.Uint("router_id", customer.RouterID),
 		zap.String("username", customer.PPPoEUsername),
-		zap.String("profile", pkg.ProfileNormal),
+		zap.String("profile", profile),
 	)
+	s.publishStatus(webhook.EventCustomerActivated, previous, customer)
 
 	return nil
 }
 
-func (s *MikroTikService) SyncCustomerToMikroTik(customer *entities.Customer) error {
+func (s *MikroTikService) SyncCustomerToMikroTik(ctx context.Context, customer *entities.Customer) error {
 	if customer.Status == "active" {
-		return s.ActivateCustomer(customer)
+		return s.ActivateCustomer(ctx, customer)
 	} else if customer.Status == "isolated" {
-		return s.IsolateCustomer(customer)
+		return s.IsolateCustomer(ctx, customer)
 	}
 	return nil
 }
 
-func (s *MikroTikService) BulkSyncCustomers(customerIDs []uint) error {
+func (s *MikroTikService) BulkSyncCustomers(ctx context.Context, customerIDs []uint) error {
+	customers := make([]*entities.Customer, 0, len(customerIDs))
 	for _, id := range customerIDs {
 		customer, err := s.customerRepo.FindByID(id)
 		if err != nil {
 			continue
 		}
-		_ = s.SyncCustomerToMikroTik(customer)
+		customers = append(customers, customer)
 	}
+	s.syncByRouter(ctx, customers)
 	return nil
 }
 
+// syncByRouter syncs customers one router at a time per goroutine, so a
+// slow or unreachable router only delays its own customers.
+func (s *MikroTikService) syncByRouter(ctx context.Context, customers []*entities.Customer) {
+	byRouter := make(map[uint][]*entities.Customer)
+	for _, c := range customers {
+		byRouter[c.RouterID] = append(byRouter[c.RouterID], c)
+	}
+
+	var wg sync.WaitGroup
+	for _, group := range byRouter {
+		wg.Add(1)
+		go func(group []*entities.Customer) {
+			defer wg.Done()
+			for _, c := range group {
+				if ctx.Err() != nil {
+					return
+				}
+				_ = s.SyncCustomerToMikroTik(ctx, c)
+			}
+		}(group)
+	}
+	wg.Wait()
+}
+
 // GetCustomerByID fetches a customer entity by ID.
 func (s *MikroTikService) GetCustomerByID(id uint) (*entities.Customer, error) {
 	return s.customerRepo.FindByID(id)
 }
 
 // SyncAllCustomers syncs every customer to MikroTik.
-func (s *MikroTikService) SyncAllCustomers() error {
+func (s *MikroTikService) SyncAllCustomers(ctx context.Context) error {
 	customers, _, err := s.customerRepo.FindAll(1, 1000, "")
 	if err != nil {
 		return err
 	}
-	for _, c := range customers {
-		_ = s.SyncCustomerToMikroTik(c)
-	}
+	s.syncByRouter(ctx, customers)
 	return nil
 }
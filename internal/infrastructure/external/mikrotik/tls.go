@@ -0,0 +1,111 @@
+package mikrotik
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CertificateError reports a router certificate that failed verification.
+type CertificateError struct {
+	Host   string
+	Reason string
+}
+
+func (e *CertificateError) Error() string {
+	return fmt.Sprintf("TLS certificate of %s rejected: %s", e.Host, e.Reason)
+}
+
+// NormalizeFingerprint returns a SHA-256 certificate fingerprint as lower
+// case hex. Colons and spaces, as printed by openssl or RouterOS, are
+// accepted.
+func NormalizeFingerprint(fingerprint string) (string, error) {
+	fp := strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(fingerprint))
+	if _, err := hex.DecodeString(fp); err != nil || len(fp) != sha256.Size*2 {
+		return "", fmt.Errorf("fingerprint must be a SHA-256 hash in hex")
+	}
+	return fp, nil
+}
+
+// ParseCACert parses PEM encoded CA certificates into a pool.
+func ParseCACert(pem string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(pem)) {
+		return nil, fmt.Errorf("CA certificate must be PEM encoded")
+	}
+	return pool, nil
+}
+
+// tlsConfig builds the TLS settings for a router. A pinned fingerprint
+// replaces chain and host name checks, which suits the self-signed
+// certificates RouterOS generates. Otherwise the certificate must chain to
+// CACert, or to the system roots when no CA is set, and be valid for Host.
+func tlsConfig(info *MikroTikConnectionInfo) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: info.Host,
+	}
+
+	if info.TLSFingerprint != "" {
+		want, err := NormalizeFingerprint(info.TLSFingerprint)
+		if err != nil {
+			return nil, err
+		}
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return &CertificateError{Host: info.Host, Reason: "no certificate presented"}
+			}
+			sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+			if got := hex.EncodeToString(sum[:]); got != want {
+				return &CertificateError{
+					Host:   info.Host,
+					Reason: fmt.Sprintf("fingerprint %s does not match the pinned %s", got, want),
+				}
+			}
+			return nil
+		}
+		return cfg, nil
+	}
+
+	if info.TLSCACert != "" {
+		pool, err := ParseCACert(info.TLSCACert)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// certificateError turns a failed certificate check anywhere in err into a
+// CertificateError that says what to configure. Other errors are returned
+// unchanged.
+func certificateError(host string, err error) error {
+	var (
+		pinErr    *CertificateError
+		authErr   x509.UnknownAuthorityError
+		hostErr   x509.HostnameError
+		invalid   x509.CertificateInvalidError
+		verifyErr *tls.CertificateVerificationError
+	)
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &pinErr):
+		return pinErr
+	case errors.As(err, &authErr):
+		return &CertificateError{Host: host, Reason: "signed by an unknown authority; set tls_ca_cert or tls_fingerprint"}
+	case errors.As(err, &hostErr):
+		return &CertificateError{Host: host, Reason: hostErr.Error() + "; set tls_fingerprint or reissue the certificate"}
+	case errors.As(err, &invalid):
+		return &CertificateError{Host: host, Reason: invalid.Error()}
+	case errors.As(err, &verifyErr):
+		return &CertificateError{Host: host, Reason: verifyErr.Err.Error()}
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package mikrotik
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// PPPoEInterfaceName is the dynamic interface RouterOS creates for an active
+// PPPoE server session.
+func PPPoEInterfaceName(username string) string {
+	return "<pppoe-" + username + ">"
+}
+
+// MonitorTraffic takes a single monitor-traffic reading of an interface.
+func (c *MikroTikClient) MonitorTraffic(ctx context.Context, routerID uint, iface string) (*TrafficSample, error) {
+	reply, err := c.run(ctx, routerID, "/interface/monitor-traffic", "=interface="+iface, "=once=")
+	if err != nil {
+		return nil, fmt.Errorf("MonitorTraffic failed: %w", err)
+	}
+	if len(reply.Re) == 0 {
+		return nil, fmt.Errorf("no traffic data for interface %s", iface)
+	}
+
+	m := reply.Re[0].Map
+	parse := func(key string) int64 {
+		v, _ := strconv.ParseInt(m[key], 10, 64)
+		return v
+	}
+	return &TrafficSample{
+		Interface: iface,
+		Time:      time.Now(),
+		RxBps:     parse("rx-bits-per-second"),
+		TxBps:     parse("tx-bits-per-second"),
+		RxPps:     parse("rx-packets-per-second"),
+		TxPps:     parse("tx-packets-per-second"),
+	}, nil
+}
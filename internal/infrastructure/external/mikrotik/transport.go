@@ -0,0 +1,95 @@
+package mikrotik
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	routeros "github.com/go-routeros/routeros/v3"
+)
+
+// Transport carries RouterOS API sentences to one router. Replies keep the
+// shape of the binary API whatever the wire protocol, so callers read
+// reply.Re[i].Map the same way for every router.
+type Transport interface {
+	// Run executes one command such as "/ppp/secret/print" followed by its
+	// "=attribute=value" and "?query" words. It returns once ctx is done,
+	// even when the router has not answered yet.
+	Run(ctx context.Context, sentence ...string) (*routeros.Reply, error)
+	// Failed delivers an error when the underlying session breaks on its
+	// own. It is nil for transports without a persistent session.
+	Failed() <-chan error
+	Close()
+}
+
+// dialTransport opens a transport of the kind configured for the router.
+func dialTransport(info *MikroTikConnectionInfo, timeout time.Duration) (Transport, error) {
+	switch info.Transport {
+	case entities.TransportREST:
+		return dialREST(info, timeout)
+	case "", entities.TransportAPI:
+		return dialBinary(info, timeout)
+	}
+	return nil, fmt.Errorf("unknown transport %q", info.Transport)
+}
+
+// binaryTransport speaks the binary API, plain on port 8728 or api-ssl on
+// port 8729, over one session that is switched to async mode so several
+// tagged commands can share it.
+type binaryTransport struct {
+	client *routeros.Client
+	failed <-chan error
+}
+
+func dialBinary(info *MikroTikConnectionInfo, timeout time.Duration) (Transport, error) {
+	addr := fmt.Sprintf("%s:%d", info.Host, info.Port)
+	var (
+		client *routeros.Client
+		err    error
+	)
+	if info.TLS {
+		cfg, cfgErr := tlsConfig(info)
+		if cfgErr != nil {
+			return nil, cfgErr
+		}
+		client, err = routeros.DialTLSTimeout(addr, info.Username, info.Password, cfg, timeout)
+	} else {
+		client, err = routeros.DialTimeout(addr, info.Username, info.Password, timeout)
+	}
+	if err != nil {
+		return nil, certificateError(info.Host, err)
+	}
+	// The async loop ends as soon as the socket fails, which is reported
+	// through Failed.
+	return &binaryTransport{client: client, failed: client.Async()}, nil
+}
+
+// Run cannot withdraw a command from the device, so on cancellation the
+// command is abandoned and its reply discarded.
+func (t *binaryTransport) Run(ctx context.Context, sentence ...string) (*routeros.Reply, error) {
+	type result struct {
+		reply *routeros.Reply
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		reply, err := t.client.RunArgs(sentence)
+		done <- result{reply, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.reply, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *binaryTransport) Failed() <-chan error {
+	return t.failed
+}
+
+func (t *binaryTransport) Close() {
+	t.client.Close()
+}
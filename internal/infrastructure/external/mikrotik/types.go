@@ -13,15 +13,26 @@ type RouterStatus struct {
 	Memory      int       `json:"memory"`
 	Uptime      string    `json:"uptime"`
 	Error       string    `json:"error,omitempty"`
+
+	ConnectionState   string     `json:"connection_state"`
+	LastError         string     `json:"last_error,omitempty"`
+	LastErrorAt       *time.Time `json:"last_error_at,omitempty"`
+	ConnectedAt       *time.Time `json:"connected_at,omitempty"`
+	ReconnectFailures int        `json:"reconnect_failures"`
+	NextRetry         *time.Time `json:"next_retry,omitempty"`
+	CertificateError  string     `json:"certificate_error,omitempty"`
 }
 
 type PPPoEUser struct {
-	Name      string `json:"name"`
-	Service   string `json:"service"`
-	Profile   string `json:"profile"`
-	CallerID  string `json:"caller_id"`
-	Disabled  bool   `json:"disabled"`
-	LastLogin string `json:"last_login,omitempty"`
+	Name          string `json:"name"`
+	Password      string `json:"-"`
+	Service       string `json:"service"`
+	Profile       string `json:"profile"`
+	CallerID      string `json:"caller_id"`
+	RemoteAddress string `json:"remote_address,omitempty"`
+	Disabled      bool   `json:"disabled"`
+	LastLogin     string `json:"last_login,omitempty"`
+	Comment       string `json:"comment,omitempty"`
 }
 
 type ActiveSession struct {
@@ -58,21 +69,85 @@ type HotspotSession struct {
 	BytesOut   string `json:"bytes_out"`
 }
 
+// HotspotLog is one /log entry. Topic holds the comma separated RouterOS
+// topics, e.g. "hotspot,info,debug".
 type HotspotLog struct {
-	Time    time.Time `json:"time"`
-	Topic   string    `json:"topic"`
-	Message string    `json:"message"`
+	ID       string    `json:"id,omitempty"`
+	RouterID uint      `json:"router_id"`
+	Time     time.Time `json:"time"`
+	Topic    string    `json:"topic"`
+	Message  string    `json:"message"`
 }
 
+// Voucher is a hotspot user as written to /ip/hotspot/user. LimitUptime
+// uses RouterOS durations ("1h", "1d"); LimitBytes is in bytes, 0 for none.
 type Voucher struct {
-	Username   string `json:"username"`
-	Password   string `json:"password"`
-	Profile    string `json:"profile"`
-	ValidUntil string `json:"valid_until"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	Profile     string `json:"profile"`
+	Server      string `json:"server,omitempty"`
+	LimitUptime string `json:"limit_uptime,omitempty"`
+	LimitBytes  int64  `json:"limit_bytes,omitempty"`
+	Comment     string `json:"comment,omitempty"`
 }
 
+// SimpleQueue is one /queue/simple entry. Limits use the RouterOS
+// "upload/download" form, e.g. "5M/20M".
 type SimpleQueue struct {
-	Number     int    `json:"number"`
-	Username   string `json:"username"`
-	Expiration string `json:"expiration"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Target   string `json:"target"`
+	Parent   string `json:"parent,omitempty"`
+	MaxLimit string `json:"max_limit,omitempty"`
+	LimitAt  string `json:"limit_at,omitempty"`
+	Priority string `json:"priority,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+	Disabled bool   `json:"disabled"`
+	Dynamic  bool   `json:"dynamic"`
+	Rate     string `json:"rate,omitempty"`
+	Bytes    string `json:"bytes,omitempty"`
+}
+
+type IPPool struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Ranges   string `json:"ranges"`
+	NextPool string `json:"next_pool,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// PoolUtilization counts active PPP session addresses inside a pool.
+type PoolUtilization struct {
+	RouterID uint    `json:"router_id"`
+	Name     string  `json:"name"`
+	Ranges   string  `json:"ranges"`
+	NextPool string  `json:"next_pool,omitempty"`
+	Size     int     `json:"size"`
+	Used     int     `json:"used"`
+	Free     int     `json:"free"`
+	Percent  float64 `json:"percent"`
+	Warning  bool    `json:"warning"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// TrafficSample is one /interface/monitor-traffic reading in bits and
+// packets per second.
+type TrafficSample struct {
+	Interface string    `json:"interface"`
+	Time      time.Time `json:"time"`
+	RxBps     int64     `json:"rx_bps"`
+	TxBps     int64     `json:"tx_bps"`
+	RxPps     int64     `json:"rx_pps"`
+	TxPps     int64     `json:"tx_pps"`
+}
+
+// SessionCounter holds the byte counters of one PPPoE session, read from its
+// dynamic pppoe-in interface. Rx is what the customer uploaded and Tx what
+// they downloaded. SessionID changes whenever the customer reconnects, and
+// the counters start again from zero.
+type SessionCounter struct {
+	Username  string `json:"username"`
+	SessionID string `json:"session_id"`
+	RxBytes   int64  `json:"rx_bytes"`
+	TxBytes   int64  `json:"tx_bytes"`
 }
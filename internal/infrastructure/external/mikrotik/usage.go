@@ -0,0 +1,38 @@
+package mikrotik
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GetSessionCounters reads the byte counters of every active PPPoE session.
+// /ppp/active does not carry traffic counters, so they come from the
+// session's pppoe-in interface instead.
+func (c *MikroTikClient) GetSessionCounters(ctx context.Context, routerID uint) ([]SessionCounter, error) {
+	reply, err := c.run(ctx, routerID, "/interface/print",
+		"=.proplist=.id,name,rx-byte,tx-byte",
+		"?type=pppoe-in",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetSessionCounters failed: %w", err)
+	}
+
+	counters := make([]SessionCounter, 0, len(reply.Re))
+	for _, re := range reply.Re {
+		name := re.Map["name"]
+		if !strings.HasPrefix(name, "<pppoe-") || !strings.HasSuffix(name, ">") {
+			continue
+		}
+		rx, _ := strconv.ParseInt(re.Map["rx-byte"], 10, 64)
+		tx, _ := strconv.ParseInt(re.Map["tx-byte"], 10, 64)
+		counters = append(counters, SessionCounter{
+			Username:  strings.TrimSuffix(strings.TrimPrefix(name, "<pppoe-"), ">"),
+			SessionID: re.Map[".id"],
+			RxBytes:   rx,
+			TxBytes:   tx,
+		})
+	}
+	return counters, nil
+}
@@ -47,6 +47,8 @@ type TripayTransactionData struct {
 	Reference   string `json:"reference"`
 	MerchantRef string `json:"merchant_ref"`
 	PaymentURL  string `json:"payment_url"`
+	CheckoutURL string `json:"checkout_url"`
+	PayCode     string `json:"pay_code,omitempty"`
 	QRString    string `json:"qr_string,omitempty"`
 	Status      string `json:"status"`
 	Amount      int64  `json:"amount"`
@@ -0,0 +1,278 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Events published to registered webhook endpoints.
+const (
+	EventInvoiceCreated    = "invoice.created"
+	EventInvoicePaid       = "invoice.paid"
+	EventCustomerIsolated  = "customer.isolated"
+	EventCustomerActivated = "customer.activated"
+	EventTicketCreated     = "ticket.created"
+
+	// EventAll subscribes an endpoint to every event.
+	EventAll = "*"
+)
+
+// Events lists every event that can be subscribed to.
+var Events = []string{
+	EventInvoiceCreated,
+	EventInvoicePaid,
+	EventCustomerIsolated,
+	EventCustomerActivated,
+	EventTicketCreated,
+}
+
+// Request headers sent with every delivery. The signature is the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the endpoint secret.
+const (
+	HeaderEvent     = "X-Gembok-Event"
+	HeaderDelivery  = "X-Gembok-Delivery"
+	HeaderTimestamp = "X-Gembok-Timestamp"
+	HeaderSignature = "X-Gembok-Signature"
+)
+
+// maxResponseSize caps how much of a receiver's response body is logged.
+const maxResponseSize = 64 * 1024
+
+// Envelope is the JSON body posted to webhook endpoints.
+type Envelope struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type Dispatcher struct {
+	endpointRepo repositories.WebhookEndpointRepository
+	logRepo      repositories.WebhookLogRepository
+	httpClient   *http.Client
+	maxAttempts  int
+	retryBackoff time.Duration
+}
+
+func NewDispatcher(
+	endpointRepo repositories.WebhookEndpointRepository,
+	logRepo repositories.WebhookLogRepository,
+	timeout time.Duration,
+	maxAttempts int,
+	retryBackoff time.Duration,
+) *Dispatcher {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &Dispatcher{
+		endpointRepo: endpointRepo,
+		logRepo:      logRepo,
+		httpClient:   &http.Client{Timeout: timeout},
+		maxAttempts:  maxAttempts,
+		retryBackoff: retryBackoff,
+	}
+}
+
+// IsValidEvent reports whether event can be subscribed to.
+func IsValidEvent(event string) bool {
+	if event == EventAll {
+		return true
+	}
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribed reports whether the endpoint wants to receive event.
+func Subscribed(endpoint *entities.WebhookEndpoint, event string) bool {
+	for _, e := range strings.Split(endpoint.Events, ",") {
+		e = strings.TrimSpace(e)
+		if e == EventAll || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the signature header value for a delivery body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publish serialises data immediately and delivers it to every active
+// endpoint subscribed to event in the background, retrying failed
+// deliveries with exponential backoff. Retries wait in memory, so those
+// still pending when the process exits are lost; every attempt made is in
+// the log and can be resent from there.
+func (d *Dispatcher) Publish(event string, data interface{}) {
+	payload, err := json.Marshal(Envelope{
+		ID:        newID(),
+		Event:     event,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		logger.Error("Failed to encode webhook payload",
+			zap.String("event", event),
+			zap.Error(err),
+		)
+		return
+	}
+
+	go func() {
+		endpoints, err := d.endpointRepo.FindActive()
+		if err != nil {
+			logger.Error("Failed to load webhook endpoints", zap.Error(err))
+			return
+		}
+		for _, endpoint := range endpoints {
+			if Subscribed(endpoint, event) {
+				go d.deliver(endpoint, event, newID(), payload)
+			}
+		}
+	}()
+}
+
+func (d *Dispatcher) deliver(endpoint *entities.WebhookEndpoint, event, deliveryID string, payload []byte) {
+	backoff := d.retryBackoff
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		if d.send(endpoint, event, deliveryID, payload, attempt).Success {
+			return
+		}
+		if attempt < d.maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	logger.Warn("Webhook delivery failed after all attempts",
+		zap.Uint("endpoint_id", endpoint.ID),
+		zap.String("event", event),
+		zap.String("delivery_id", deliveryID),
+		zap.Int("attempts", d.maxAttempts),
+	)
+}
+
+// Resend makes one new attempt at the delivery recorded in a log entry,
+// using the endpoint's current URL and secret, and returns the new log.
+func (d *Dispatcher) Resend(logID uint) (*entities.WebhookLog, error) {
+	original, err := d.logRepo.FindByID(logID)
+	if err != nil {
+		return nil, fmt.Errorf("webhook log not found")
+	}
+	if original.EndpointID == nil {
+		return nil, fmt.Errorf("webhook log is not linked to an endpoint")
+	}
+
+	endpoint, err := d.endpointRepo.FindByID(*original.EndpointID)
+	if err != nil {
+		return nil, fmt.Errorf("webhook endpoint no longer exists")
+	}
+
+	deliveryID := original.DeliveryID
+	if deliveryID == "" {
+		deliveryID = newID()
+	}
+
+	return d.send(endpoint, original.Event, deliveryID, []byte(original.Payload), original.Attempt+1), nil
+}
+
+// send performs a single delivery attempt and records it.
+func (d *Dispatcher) send(endpoint *entities.WebhookEndpoint, event, deliveryID string, payload []byte, attempt int) *entities.WebhookLog {
+	endpointID := endpoint.ID
+	entry := &entities.WebhookLog{
+		EndpointID: &endpointID,
+		DeliveryID: deliveryID,
+		Event:      event,
+		URL:        endpoint.URL,
+		Payload:    string(payload),
+		Attempt:    attempt,
+	}
+
+	start := time.Now()
+	statusCode, response, err := d.post(endpoint, event, deliveryID, payload)
+	entry.Duration = int(time.Since(start).Milliseconds())
+	entry.StatusCode = statusCode
+	entry.Response = response
+
+	if err != nil {
+		entry.Error = err.Error()
+	} else if statusCode < 200 || statusCode >= 300 {
+		entry.Error = fmt.Sprintf("unexpected status code %d", statusCode)
+	} else {
+		entry.Success = true
+	}
+
+	if err := d.logRepo.Create(entry); err != nil {
+		logger.Error("Failed to save webhook log",
+			zap.Uint("endpoint_id", endpoint.ID),
+			zap.String("event", event),
+			zap.Error(err),
+		)
+	}
+
+	if !entry.Success {
+		logger.Warn("Webhook delivery attempt failed",
+			zap.Uint("endpoint_id", endpoint.ID),
+			zap.String("event", event),
+			zap.Int("attempt", attempt),
+			zap.String("error", entry.Error),
+		)
+	}
+
+	return entry
+}
+
+func (d *Dispatcher) post(endpoint *entities.WebhookEndpoint, event, deliveryID string, payload []byte) (int, string, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Gembok-Webhook/1.0")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, timestamp, payload))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	return resp.StatusCode, string(body), nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
@@ -2,6 +2,7 @@ package whatsapp
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
@@ -79,6 +80,66 @@ Terima kasih atas pembayaran Anda! Koneksi Anda kini aktif.`,
 	return s.client.SendText(customer.Phone, message)
 }
 
+func (s *WhatsAppService) SendPaymentQRCode(invoice *entities.Invoice, trx *entities.PaymentTransaction, imageURL string) error {
+	customer, err := s.customerRepo.FindByID(invoice.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	caption := fmt.Sprintf(`*Pembayaran QRIS* 📲
+
+No Invoice: %s
+Pelanggan: %s
+Jumlah: Rp %.2f
+Berlaku Sampai: %s
+
+Scan QR ini dengan aplikasi e-wallet atau mobile banking Anda.`,
+		invoice.Number,
+		customer.Name,
+		trx.Amount,
+		trx.ExpiredAt.Format("2006-01-02 15:04"),
+	)
+
+	return s.client.SendImage(customer.Phone, imageURL, caption)
+}
+
+func (s *WhatsAppService) SendCashReceipt(payment *entities.CashPayment) error {
+	customer := payment.Customer
+	if customer == nil {
+		c, err := s.customerRepo.FindByID(payment.CustomerID)
+		if err != nil {
+			return err
+		}
+		customer = c
+	}
+
+	invoiceNumber, period := "", ""
+	if payment.Invoice != nil {
+		invoiceNumber = payment.Invoice.Number
+		period = payment.Invoice.Period
+	}
+
+	message := fmt.Sprintf(`*Kwitansi Pembayaran Tunai* 🧾
+
+No Kwitansi: %s
+Tanggal: %s
+Pelanggan: %s
+No Invoice: %s
+Periode: %s
+Jumlah: Rp %.2f
+
+Pembayaran tunai Anda telah kami terima. Terima kasih!`,
+		payment.ReceiptNumber,
+		payment.CollectedAt.Format("2006-01-02 15:04"),
+		customer.Name,
+		invoiceNumber,
+		period,
+		payment.Amount,
+	)
+
+	return s.client.SendText(customer.Phone, message)
+}
+
 func (s *WhatsAppService) SendIsolationNotification(customer *entities.Customer) error {
 	message := fmt.Sprintf(`*Akun Diisolir* ⚠️
 
@@ -109,6 +170,104 @@ Terima kasih telah melakukan pembayaran!`,
 	return s.client.SendText(customer.Phone, message)
 }
 
+// SendQuotaNotification tells a customer how much of their monthly
+// fair-usage quota is used; at 100% it says the speed has been reduced.
+func (s *WhatsAppService) SendQuotaNotification(customer *entities.Customer, pkg *entities.Package, usedGB float64, percent int) error {
+	var message string
+	if percent >= 100 {
+		message = fmt.Sprintf(`*Kuota FUP Habis* ⚠️
+
+Pelanggan: %s
+Paket: %s
+Pemakaian: %.1f GB dari %d GB
+
+Kuota pemakaian wajar bulan ini telah habis. Kecepatan internet Anda diturunkan menjadi %s hingga awal periode berikutnya.`,
+			customer.Name,
+			pkg.Name,
+			usedGB,
+			pkg.QuotaGB,
+			pkg.ThrottleSpeed,
+		)
+	} else {
+		message = fmt.Sprintf(`*Pemakaian Kuota %d%%* 📶
+
+Pelanggan: %s
+Paket: %s
+Pemakaian: %.1f GB dari %d GB
+
+Jika kuota habis, kecepatan internet akan diturunkan menjadi %s hingga awal periode berikutnya.`,
+			percent,
+			customer.Name,
+			pkg.Name,
+			usedGB,
+			pkg.QuotaGB,
+			pkg.ThrottleSpeed,
+		)
+	}
+
+	return s.client.SendText(customer.Phone, message)
+}
+
+// SendRouterAlert tells the admins that a router alert fired, or that it
+// recovered once ResolvedAt is set. Reboots are only ever announced.
+func (s *WhatsAppService) SendRouterAlert(router *entities.Router, alert *entities.RouterAlert) error {
+	if len(s.adminPhones) == 0 {
+		return nil
+	}
+
+	var title, detail string
+	switch alert.Rule {
+	case entities.AlertRuleCPU:
+		title = "CPU Tinggi"
+		detail = fmt.Sprintf("Beban CPU %.0f%% sejak %s.", alert.Value, alert.StartedAt.Format("15:04"))
+	case entities.AlertRuleMemory:
+		title = "Memori Tinggi"
+		detail = fmt.Sprintf("Pemakaian memori %.0f%% sejak %s.", alert.Value, alert.StartedAt.Format("15:04"))
+	case entities.AlertRuleUnreachable:
+		title = "Router Tidak Terhubung"
+		detail = fmt.Sprintf("Router tidak dapat dihubungi sejak %s.", alert.StartedAt.Format("2006-01-02 15:04"))
+	case entities.AlertRuleReboot:
+		title = "Router Restart"
+		detail = fmt.Sprintf("Router baru saja restart (uptime %s).", time.Duration(alert.Value)*time.Second)
+	default:
+		title = alert.Rule
+		detail = alert.Message
+	}
+
+	var message string
+	if alert.ResolvedAt != nil && alert.Rule != entities.AlertRuleReboot {
+		message = fmt.Sprintf(`*Pulih: %s* ✅
+
+Router: %s (%s)
+Berlangsung: %s
+
+Kondisi router sudah kembali normal.`,
+			title,
+			router.Name,
+			router.Host,
+			alert.ResolvedAt.Sub(alert.StartedAt).Round(time.Minute),
+		)
+	} else {
+		message = fmt.Sprintf(`*%s* 🚨
+
+Router: %s (%s)
+%s`,
+			title,
+			router.Name,
+			router.Host,
+			detail,
+		)
+	}
+
+	var lastErr error
+	for _, phone := range s.adminPhones {
+		if err := s.client.SendText(phone, message); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
 func (s *WhatsAppService) SendWelcomeMessage(customer *entities.Customer) error {
 	pkgName := ""
 	if customer.Package != nil {
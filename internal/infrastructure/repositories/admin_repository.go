@@ -48,3 +48,11 @@ func (r *AdminRepositoryImpl) FindAll() ([]*entities.AdminUser, error) {
 	}
 	return admins, nil
 }
+
+func (r *AdminRepositoryImpl) FindByRole(role string) ([]*entities.AdminUser, error) {
+	var admins []*entities.AdminUser
+	if err := r.db.Where("role = ?", role).Order("username ASC").Find(&admins).Error; err != nil {
+		return nil, err
+	}
+	return admins, nil
+}
@@ -0,0 +1,212 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type cashPaymentRepository struct {
+	db *gorm.DB
+}
+
+func NewCashPaymentRepository(db *gorm.DB) repositories.CashPaymentRepository {
+	return &cashPaymentRepository{db: db}
+}
+
+func (r *cashPaymentRepository) Create(payment *entities.CashPayment) error {
+	return r.db.Create(payment).Error
+}
+
+func (r *cashPaymentRepository) FindByID(id uint) (*entities.CashPayment, error) {
+	var payment entities.CashPayment
+	err := r.db.Preload("Invoice").Preload("Customer").Preload("Collector").First(&payment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *cashPaymentRepository) FindByCollectorID(collectorID uint, from, to time.Time) ([]*entities.CashPayment, error) {
+	var payments []*entities.CashPayment
+	err := r.db.Preload("Invoice").Preload("Customer").
+		Where("collector_id = ? AND collected_at >= ? AND collected_at < ?", collectorID, from, to).
+		Order("collected_at DESC").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *cashPaymentRepository) FindUnsettledByCollectorID(collectorID uint) ([]*entities.CashPayment, error) {
+	var payments []*entities.CashPayment
+	err := r.db.Where("collector_id = ? AND settlement_id IS NULL", collectorID).
+		Order("collected_at ASC").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *cashPaymentRepository) SetSettlement(ids []uint, settlementID uint) error {
+	return r.db.Model(&entities.CashPayment{}).
+		Where("id IN ? AND settlement_id IS NULL", ids).
+		Update("settlement_id", settlementID).Error
+}
+
+func (r *cashPaymentRepository) SumCollected(collectorID uint, from, to time.Time) (float64, int64, error) {
+	var result struct {
+		Total float64
+		Count int64
+	}
+	err := r.db.Model(&entities.CashPayment{}).
+		Select("COALESCE(SUM(amount), 0) AS total, COUNT(*) AS count").
+		Where("collector_id = ? AND collected_at >= ? AND collected_at < ?", collectorID, from, to).
+		Scan(&result).Error
+	return result.Total, result.Count, err
+}
+
+// SumOutstanding returns the cash the collector still holds: payments that
+// are not part of a confirmed settlement.
+func (r *cashPaymentRepository) SumOutstanding(collectorID uint) (float64, error) {
+	var total float64
+	err := r.db.Model(&entities.CashPayment{}).
+		Select("COALESCE(SUM(cash_payments.amount), 0)").
+		Joins("LEFT JOIN cash_settlements ON cash_settlements.id = cash_payments.settlement_id").
+		Where("cash_payments.collector_id = ? AND (cash_payments.settlement_id IS NULL OR cash_settlements.status <> ?)", collectorID, "confirmed").
+		Scan(&total).Error
+	return total, err
+}
+
+// cashReceiptAttempts bounds the retries of CreateForInvoice when another
+// writer took the receipt number first.
+const cashReceiptAttempts = 3
+
+// CreateForInvoice inserts a cash payment in a transaction that locks the
+// invoice and then the collector row. The invoice lock stops two collectors
+// from collecting the same invoice; the collector lock serializes the
+// collector's receipt sequence, which counts the receipts already issued
+// under receiptPrefix. A duplicate receipt number is retried.
+func (r *cashPaymentRepository) CreateForInvoice(payment *entities.CashPayment, receiptPrefix string) error {
+	var err error
+	for attempt := 0; attempt < cashReceiptAttempts; attempt++ {
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			var invoice entities.Invoice
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id", "status").
+				First(&invoice, payment.InvoiceID).Error; err != nil {
+				return err
+			}
+			if invoice.Status == "paid" {
+				return repositories.ErrInvoiceAlreadyPaid
+			}
+
+			var collected int64
+			if err := tx.Model(&entities.CashPayment{}).
+				Where("invoice_id = ?", payment.InvoiceID).
+				Count(&collected).Error; err != nil {
+				return err
+			}
+			if collected > 0 {
+				return repositories.ErrInvoiceAlreadyPaid
+			}
+
+			var collector entities.AdminUser
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id").
+				First(&collector, payment.CollectorID).Error; err != nil {
+				return err
+			}
+
+			var issued int64
+			if err := tx.Model(&entities.CashPayment{}).
+				Where("receipt_number LIKE ?", receiptPrefix+"%").
+				Count(&issued).Error; err != nil {
+				return err
+			}
+			payment.ReceiptNumber = fmt.Sprintf("%s%04d", receiptPrefix, issued+1)
+			return tx.Create(payment).Error
+		})
+		if !isDuplicateKey(err) {
+			return err
+		}
+		payment.ID = 0
+	}
+	return err
+}
+
+// isDuplicateKey reports whether err is a MySQL unique key violation.
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+func (r *cashPaymentRepository) Delete(id uint) error {
+	return r.db.Delete(&entities.CashPayment{}, id).Error
+}
+
+type cashSettlementRepository struct {
+	db *gorm.DB
+}
+
+func NewCashSettlementRepository(db *gorm.DB) repositories.CashSettlementRepository {
+	return &cashSettlementRepository{db: db}
+}
+
+func (r *cashSettlementRepository) Create(settlement *entities.CashSettlement) error {
+	return r.db.Create(settlement).Error
+}
+
+func (r *cashSettlementRepository) FindByID(id uint) (*entities.CashSettlement, error) {
+	var settlement entities.CashSettlement
+	err := r.db.Preload("Collector").First(&settlement, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+func (r *cashSettlementRepository) FindAll(page, perPage int, collectorID uint, status string) ([]*entities.CashSettlement, int64, error) {
+	var settlements []*entities.CashSettlement
+	var total int64
+
+	query := r.db.Model(&entities.CashSettlement{})
+	if collectorID > 0 {
+		query = query.Where("collector_id = ?", collectorID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	err := query.Preload("Collector").Order("created_at DESC").Offset(offset).Limit(perPage).Find(&settlements).Error
+	return settlements, total, err
+}
+
+func (r *cashSettlementRepository) Update(settlement *entities.CashSettlement) error {
+	return r.db.Save(settlement).Error
+}
+
+func (r *cashSettlementRepository) SumConfirmed(collectorID uint, from, to time.Time) (float64, error) {
+	var total float64
+	err := r.db.Model(&entities.CashSettlement{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("collector_id = ? AND status = ? AND confirmed_at >= ? AND confirmed_at < ?", collectorID, "confirmed", from, to).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *cashSettlementRepository) SumPending(collectorID uint) (float64, error) {
+	var total float64
+	err := r.db.Model(&entities.CashSettlement{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("collector_id = ? AND status = ?", collectorID, "pending").
+		Scan(&total).Error
+	return total, err
+}
@@ -49,6 +49,22 @@ func (r *customerRepository) Update(customer *entities.Customer) error {
 	return r.db.Save(customer).Error
 }
 
+// UpdateIsolatedIP writes only the isolated_ip column, so it cannot
+// overwrite changes made to the customer since it was loaded.
+func (r *customerRepository) UpdateIsolatedIP(id uint, ip string) error {
+	return r.db.Model(&entities.Customer{}).Where("id = ?", id).Update("isolated_ip", ip).Error
+}
+
+// UpdateQuota writes only the quota columns, leaving changes made to the
+// rest of the customer by others in place.
+func (r *customerRepository) UpdateQuota(customer *entities.Customer) error {
+	return r.db.Model(&entities.Customer{}).Where("id = ?", customer.ID).Updates(map[string]interface{}{
+		"quota_throttled": customer.QuotaThrottled,
+		"quota_notified":  customer.QuotaNotified,
+		"quota_period":    customer.QuotaPeriod,
+	}).Error
+}
+
 func (r *customerRepository) Delete(id uint) error {
 	return r.db.Delete(&entities.Customer{}, id).Error
 }
@@ -102,3 +118,32 @@ func (r *customerRepository) FindByPackageID(packageID uint, page, perPage int)
 	err := query.Order("created_at DESC").Limit(perPage).Offset(offset).Find(&customers).Error
 	return customers, total, err
 }
+
+func (r *customerRepository) FindByCollectorID(collectorID uint) ([]*entities.Customer, error) {
+	var customers []*entities.Customer
+	err := r.db.Preload("Package").Where("collector_id = ?", collectorID).Order("name ASC").Find(&customers).Error
+	return customers, err
+}
+
+func (r *customerRepository) FindByRouterID(routerID uint) ([]*entities.Customer, error) {
+	var customers []*entities.Customer
+	err := r.db.Preload("Package").Where("router_id = ?", routerID).Order("pppoe_username ASC").Find(&customers).Error
+	return customers, err
+}
+
+// FindIsolatedByIP finds the isolated customers recorded at ip, by their
+// isolation address or static IP. Recorded addresses can be stale, so the
+// caller has to confirm who is using ip now.
+func (r *customerRepository) FindIsolatedByIP(ip string) ([]*entities.Customer, error) {
+	var customers []*entities.Customer
+	err := r.db.Preload("Package").
+		Where("status = ? AND (isolated_ip = ? OR static_ip IN ?)", "isolated", ip, []string{ip, ip + "/32"}).
+		Find(&customers).Error
+	return customers, err
+}
+
+func (r *customerRepository) FindQuotaThrottled() ([]*entities.Customer, error) {
+	var customers []*entities.Customer
+	err := r.db.Preload("Package").Where("quota_throttled = ?", true).Find(&customers).Error
+	return customers, err
+}
@@ -97,3 +97,15 @@ func (r *invoiceRepository) FindLastInvoiceNumber() (*entities.Invoice, error) {
 	}
 	return &invoice, nil
 }
+
+func (r *invoiceRepository) FindUnpaidByCustomerIDs(customerIDs []uint) ([]*entities.Invoice, error) {
+	var invoices []*entities.Invoice
+	if len(customerIDs) == 0 {
+		return invoices, nil
+	}
+	err := r.db.Preload("Customer").
+		Where("customer_id IN ? AND status <> ?", customerIDs, "paid").
+		Order("due_date ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
@@ -0,0 +1,101 @@
+package impl
+
+import (
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type routerMetricRepository struct {
+	db *gorm.DB
+}
+
+func NewRouterMetricRepository(db *gorm.DB) repositories.RouterMetricRepository {
+	return &routerMetricRepository{db: db}
+}
+
+func (r *routerMetricRepository) Create(metric *entities.RouterMetric) error {
+	return r.db.Create(metric).Error
+}
+
+func (r *routerMetricRepository) FindLatest(routerID uint) (*entities.RouterMetric, error) {
+	var metric entities.RouterMetric
+	err := r.db.Where("router_id = ? AND reachable = ?", routerID, true).
+		Order("sampled_at DESC, id DESC").
+		First(&metric).Error
+	if err != nil {
+		return nil, err
+	}
+	return &metric, nil
+}
+
+func (r *routerMetricRepository) Find(routerID uint, from, to time.Time) ([]*entities.RouterMetric, error) {
+	var metrics []*entities.RouterMetric
+	err := r.db.Where("router_id = ? AND sampled_at >= ? AND sampled_at <= ?", routerID, from, to).
+		Order("sampled_at ASC, id ASC").
+		Find(&metrics).Error
+	return metrics, err
+}
+
+func (r *routerMetricRepository) DeleteBefore(t time.Time) (int64, error) {
+	result := r.db.Where("sampled_at < ?", t).Delete(&entities.RouterMetric{})
+	return result.RowsAffected, result.Error
+}
+
+type routerAlertRepository struct {
+	db *gorm.DB
+}
+
+func NewRouterAlertRepository(db *gorm.DB) repositories.RouterAlertRepository {
+	return &routerAlertRepository{db: db}
+}
+
+func (r *routerAlertRepository) Create(alert *entities.RouterAlert) error {
+	return r.db.Create(alert).Error
+}
+
+func (r *routerAlertRepository) Update(alert *entities.RouterAlert) error {
+	return r.db.Save(alert).Error
+}
+
+func (r *routerAlertRepository) Delete(id uint) error {
+	return r.db.Delete(&entities.RouterAlert{}, id).Error
+}
+
+func (r *routerAlertRepository) FindOpen(routerID uint) (map[string]*entities.RouterAlert, error) {
+	var alerts []*entities.RouterAlert
+	err := r.db.Where("router_id = ? AND resolved_at IS NULL", routerID).
+		Order("id ASC").
+		Find(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+	open := make(map[string]*entities.RouterAlert, len(alerts))
+	for _, alert := range alerts {
+		open[alert.Rule] = alert
+	}
+	return open, nil
+}
+
+func (r *routerAlertRepository) Find(routerID uint, openOnly bool, page, perPage int) ([]*entities.RouterAlert, int64, error) {
+	var alerts []*entities.RouterAlert
+	var total int64
+
+	query := r.db.Model(&entities.RouterAlert{}).Where("fired_at IS NOT NULL")
+	if routerID != 0 {
+		query = query.Where("router_id = ?", routerID)
+	}
+	if openOnly {
+		query = query.Where("resolved_at IS NULL")
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * perPage
+	err := query.Order("fired_at DESC, id DESC").
+		Offset(offset).
+		Limit(perPage).
+		Find(&alerts).Error
+	return alerts, total, err
+}
@@ -20,7 +20,7 @@ func (r *packageRepository) Create(pkg *entities.Package) error {
 
 func (r *packageRepository) FindByID(id uint) (*entities.Package, error) {
 	var pkg entities.Package
-	err := r.db.First(&pkg, id).Error
+	err := r.db.Preload("Schedules").First(&pkg, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -37,7 +37,7 @@ func (r *packageRepository) FindByName(name string) (*entities.Package, error) {
 }
 
 func (r *packageRepository) Update(pkg *entities.Package) error {
-	return r.db.Save(pkg).Error
+	return r.db.Omit("Schedules").Save(pkg).Error
 }
 
 func (r *packageRepository) Delete(id uint) error {
@@ -46,6 +46,22 @@ func (r *packageRepository) Delete(id uint) error {
 
 func (r *packageRepository) FindAll() ([]*entities.Package, error) {
 	var packages []*entities.Package
-	err := r.db.Order("created_at DESC").Find(&packages).Error
+	err := r.db.Preload("Schedules").Order("created_at DESC").Find(&packages).Error
 	return packages, err
 }
+
+func (r *packageRepository) ReplaceSchedules(packageID uint, schedules []entities.PackageSchedule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("package_id = ?", packageID).Delete(&entities.PackageSchedule{}).Error; err != nil {
+			return err
+		}
+		for i := range schedules {
+			schedules[i].ID = 0
+			schedules[i].PackageID = packageID
+		}
+		if len(schedules) == 0 {
+			return nil
+		}
+		return tx.Create(&schedules).Error
+	})
+}
@@ -0,0 +1,56 @@
+package impl
+
+import (
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type paymentTransactionRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentTransactionRepository(db *gorm.DB) repositories.PaymentTransactionRepository {
+	return &paymentTransactionRepository{db: db}
+}
+
+func (r *paymentTransactionRepository) Create(trx *entities.PaymentTransaction) error {
+	return r.db.Create(trx).Error
+}
+
+func (r *paymentTransactionRepository) FindByID(id uint) (*entities.PaymentTransaction, error) {
+	var trx entities.PaymentTransaction
+	err := r.db.Preload("Invoice").First(&trx, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &trx, nil
+}
+
+func (r *paymentTransactionRepository) FindByReference(reference string) (*entities.PaymentTransaction, error) {
+	var trx entities.PaymentTransaction
+	err := r.db.Preload("Invoice").Where("reference = ?", reference).First(&trx).Error
+	if err != nil {
+		return nil, err
+	}
+	return &trx, nil
+}
+
+func (r *paymentTransactionRepository) FindByInvoiceID(invoiceID uint) ([]*entities.PaymentTransaction, error) {
+	var trxs []*entities.PaymentTransaction
+	err := r.db.Where("invoice_id = ?", invoiceID).Order("created_at DESC").Find(&trxs).Error
+	return trxs, err
+}
+
+func (r *paymentTransactionRepository) FindLatestByInvoiceAndMethod(invoiceID uint, method string) (*entities.PaymentTransaction, error) {
+	var trx entities.PaymentTransaction
+	err := r.db.Where("invoice_id = ? AND method = ?", invoiceID, method).Order("id DESC").First(&trx).Error
+	if err != nil {
+		return nil, err
+	}
+	return &trx, nil
+}
+
+func (r *paymentTransactionRepository) Update(trx *entities.PaymentTransaction) error {
+	return r.db.Save(trx).Error
+}
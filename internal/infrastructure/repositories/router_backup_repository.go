@@ -0,0 +1,93 @@
+package impl
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+// routerBackupSummary are the columns listed without the file contents.
+var routerBackupSummary = []string{"id", "router_id", "export_size", "backup_size", "export_hash", "changed", "error", "created_at"}
+
+type routerBackupRepository struct {
+	db *gorm.DB
+}
+
+func NewRouterBackupRepository(db *gorm.DB) repositories.RouterBackupRepository {
+	return &routerBackupRepository{db: db}
+}
+
+func (r *routerBackupRepository) Create(backup *entities.RouterBackup) error {
+	return r.db.Create(backup).Error
+}
+
+func (r *routerBackupRepository) FindByID(id uint) (*entities.RouterBackup, error) {
+	var backup entities.RouterBackup
+	if err := r.db.First(&backup, id).Error; err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+func (r *routerBackupRepository) FindByRouterID(routerID uint, page, perPage int) ([]*entities.RouterBackup, int64, error) {
+	var backups []*entities.RouterBackup
+	var total int64
+
+	query := r.db.Model(&entities.RouterBackup{}).Where("router_id = ?", routerID)
+	query.Count(&total)
+
+	offset := (page - 1) * perPage
+	err := query.Select(routerBackupSummary).
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(perPage).
+		Find(&backups).Error
+	return backups, total, err
+}
+
+func (r *routerBackupRepository) FindLatestExport(routerID uint) (*entities.RouterBackup, error) {
+	var backup entities.RouterBackup
+	err := r.db.Select(routerBackupSummary).
+		Where("router_id = ? AND export_size > 0", routerID).
+		Order("id DESC").
+		First(&backup).Error
+	if err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+func (r *routerBackupRepository) FindPrevious(backup *entities.RouterBackup) (*entities.RouterBackup, error) {
+	var previous entities.RouterBackup
+	err := r.db.Where("router_id = ? AND id < ? AND export_size > 0", backup.RouterID, backup.ID).
+		Order("id DESC").
+		First(&previous).Error
+	if err != nil {
+		return nil, err
+	}
+	return &previous, nil
+}
+
+func (r *routerBackupRepository) DeleteExpired(routerID uint, before time.Time, keep int) (int64, error) {
+	query := r.db.Where("router_id = ? AND created_at < ?", routerID, before)
+	if keep > 0 {
+		var oldestKept entities.RouterBackup
+		err := r.db.Select("id").
+			Where("router_id = ?", routerID).
+			Order("id DESC").
+			Offset(keep - 1).
+			Take(&oldestKept).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		query = query.Where("id < ?", oldestKept.ID)
+	}
+	result := query.Delete(&entities.RouterBackup{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,83 @@
+package impl
+
+import (
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type routerLogRepository struct {
+	db *gorm.DB
+}
+
+func NewRouterLogRepository(db *gorm.DB) repositories.RouterLogRepository {
+	return &routerLogRepository{db: db}
+}
+
+// CreateMany inserts logs, skipping entries already stored, and returns how
+// many were new.
+func (r *routerLogRepository) CreateMany(logs []*entities.RouterLog) (int64, error) {
+	if len(logs) == 0 {
+		return 0, nil
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(logs, 200)
+	return result.RowsAffected, result.Error
+}
+
+// Find returns matching logs, newest first.
+func (r *routerLogRepository) Find(filter repositories.RouterLogFilter) ([]*entities.RouterLog, error) {
+	var logs []*entities.RouterLog
+
+	query := r.db.Model(&entities.RouterLog{})
+	if filter.RouterID > 0 {
+		query = query.Where("router_id = ?", filter.RouterID)
+	}
+	if len(filter.Topics) > 0 {
+		cond := r.db
+		for i, topic := range filter.Topics {
+			if i == 0 {
+				cond = cond.Where("FIND_IN_SET(?, topics) > 0", topic)
+			} else {
+				cond = cond.Or("FIND_IN_SET(?, topics) > 0", topic)
+			}
+		}
+		query = query.Where(cond)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("logged_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("logged_at <= ?", filter.Until)
+	}
+	if filter.Search != "" {
+		query = query.Where("message LIKE ?", "%"+filter.Search+"%")
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	err := query.Order("logged_at DESC, id DESC").Find(&logs).Error
+	return logs, err
+}
+
+func (r *routerLogRepository) LatestTime(routerID uint) (time.Time, error) {
+	var latest struct {
+		LoggedAt *time.Time
+	}
+	err := r.db.Model(&entities.RouterLog{}).
+		Select("MAX(logged_at) AS logged_at").
+		Where("router_id = ?", routerID).
+		Scan(&latest).Error
+	if err != nil || latest.LoggedAt == nil {
+		return time.Time{}, err
+	}
+	return *latest.LoggedAt, nil
+}
+
+func (r *routerLogRepository) DeleteBefore(t time.Time) (int64, error) {
+	result := r.db.Where("logged_at < ?", t).Delete(&entities.RouterLog{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,151 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/alijayanet/gembok-backend/pkg/secrets"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// SecretSerializer is the GORM serializer name for encrypted columns, used
+// as `gorm:"serializer:secret"` on entity fields.
+const SecretSerializer = "secret"
+
+// secretSerializer encrypts a string field on write and decrypts it on
+// read, so repositories and preloads only ever see plain values.
+type secretSerializer struct {
+	keyring *secrets.Keyring
+}
+
+// RegisterSecretSerializer installs the serializer for encrypted columns.
+// It must run before the first query touching such a column.
+func RegisterSecretSerializer(keyring *secrets.Keyring) {
+	schema.RegisterSerializer(SecretSerializer, secretSerializer{keyring: keyring})
+}
+
+func (s secretSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("%s: unsupported column type %T", field.Name, dbValue)
+	}
+
+	plaintext, err := s.keyring.Decrypt(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", field.Name, err)
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (s secretSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s: secret fields must be strings", field.Name)
+	}
+	ciphertext, err := s.keyring.Encrypt(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", field.Name, err)
+	}
+	return ciphertext, nil
+}
+
+// SecretRotation counts the values RotateSecrets re-encrypted per column.
+type SecretRotation struct {
+	RouterPasswords   int `json:"router_passwords"`
+	CustomerPasswords int `json:"customer_passwords"`
+}
+
+const secretRotationBatch = 500
+
+// RotateSecrets re-encrypts every encrypted column under the keyring's
+// active key. Plain text rows from before encryption was enabled are
+// encrypted and rows under an older key are moved to the active one; the
+// old key must stay configured until this has run. Each row is updated only
+// if it still holds the value that was read, so concurrent writes win.
+func RotateSecrets(db *gorm.DB, keyring *secrets.Keyring) (*SecretRotation, error) {
+	return rewriteSecrets(db, keyring, keyring.IsCurrent)
+}
+
+// EncryptPlaintextSecrets encrypts only the rows still holding plain text,
+// leaving rows under older keys to RotateSecrets. The server runs it on
+// startup so no plain text outlives enabling encryption.
+func EncryptPlaintextSecrets(db *gorm.DB, keyring *secrets.Keyring) (*SecretRotation, error) {
+	return rewriteSecrets(db, keyring, func(value string) bool {
+		return value == "" || secrets.IsEncrypted(value)
+	})
+}
+
+// rewriteSecrets re-encrypts the values of every encrypted column that
+// keep does not accept.
+func rewriteSecrets(db *gorm.DB, keyring *secrets.Keyring, keep func(value string) bool) (*SecretRotation, error) {
+	if !keyring.Enabled() {
+		return nil, fmt.Errorf("no encryption key configured")
+	}
+
+	result := &SecretRotation{}
+	var err error
+	if result.RouterPasswords, err = rotateColumn(db, keyring, "routers", "password", keep); err != nil {
+		return result, err
+	}
+	if result.CustomerPasswords, err = rotateColumn(db, keyring, "customers", "pppoe_password", keep); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// rotateColumn works on the raw table so the serializer stays out of the
+// way, including soft-deleted rows.
+func rotateColumn(db *gorm.DB, keyring *secrets.Keyring, table, column string, keep func(value string) bool) (int, error) {
+	type row struct {
+		ID    uint
+		Value string
+	}
+
+	rotated := 0
+	var lastID uint
+	for {
+		var rows []row
+		err := db.Table(table).
+			Select("id, "+column+" AS value").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(secretRotationBatch).
+			Scan(&rows).Error
+		if err != nil {
+			return rotated, err
+		}
+		if len(rows) == 0 {
+			return rotated, nil
+		}
+
+		for _, r := range rows {
+			lastID = r.ID
+			if keep(r.Value) {
+				continue
+			}
+			plaintext, err := keyring.Decrypt(r.Value)
+			if err != nil {
+				return rotated, fmt.Errorf("%s.%s id %d: %w", table, column, r.ID, err)
+			}
+			ciphertext, err := keyring.Encrypt(plaintext)
+			if err != nil {
+				return rotated, err
+			}
+			res := db.Table(table).
+				Where("id = ? AND "+column+" = ?", r.ID, r.Value).
+				Update(column, ciphertext)
+			if res.Error != nil {
+				return rotated, fmt.Errorf("%s.%s id %d: %w", table, column, r.ID, res.Error)
+			}
+			rotated += int(res.RowsAffected)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package impl
+
+import (
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type usageRepository struct {
+	db *gorm.DB
+}
+
+func NewUsageRepository(db *gorm.DB) repositories.UsageRepository {
+	return &usageRepository{db: db}
+}
+
+func (r *usageRepository) FindCounters(routerID uint) ([]*entities.UsageCounter, error) {
+	var counters []*entities.UsageCounter
+	err := r.db.Where("router_id = ?", routerID).Find(&counters).Error
+	return counters, err
+}
+
+// SaveCounters inserts counters or overwrites the stored ones for the same
+// router and username.
+func (r *usageRepository) SaveCounters(counters []*entities.UsageCounter) error {
+	if len(counters) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "router_id"}, {Name: "username"}},
+		DoUpdates: clause.AssignmentColumns([]string{"session_id", "rx_bytes", "tx_bytes", "sampled_at"}),
+	}).CreateInBatches(counters, 200).Error
+}
+
+// AddUsage adds bytes to a customer's record for the period starting at
+// start, creating it when needed.
+func (r *usageRepository) AddUsage(customerID uint, period string, start time.Time, upload, download int64) error {
+	record := &entities.UsageRecord{
+		CustomerID:    customerID,
+		Period:        period,
+		PeriodStart:   start,
+		UploadBytes:   upload,
+		DownloadBytes: download,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "customer_id"}, {Name: "period"}, {Name: "period_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"upload_bytes":   gorm.Expr("upload_bytes + ?", upload),
+			"download_bytes": gorm.Expr("download_bytes + ?", download),
+			"updated_at":     time.Now(),
+		}),
+	}).Create(record).Error
+}
+
+// FindUsage returns a customer's records whose period starts within
+// [from, to], oldest first.
+func (r *usageRepository) FindUsage(customerID uint, period string, from, to time.Time) ([]*entities.UsageRecord, error) {
+	var records []*entities.UsageRecord
+	err := r.db.Where("customer_id = ? AND period = ? AND period_start BETWEEN ? AND ?", customerID, period, from, to).
+		Order("period_start ASC").
+		Find(&records).Error
+	return records, err
+}
+
+// FindTop returns every customer's record for one period, heaviest users
+// first.
+func (r *usageRepository) FindTop(period string, start time.Time, limit int) ([]*entities.UsageRecord, error) {
+	var records []*entities.UsageRecord
+	query := r.db.Where("period = ? AND period_start = ?", period, start).
+		Order("upload_bytes + download_bytes DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&records).Error
+	return records, err
+}
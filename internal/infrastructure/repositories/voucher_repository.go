@@ -0,0 +1,112 @@
+package impl
+
+import (
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type voucherRepository struct {
+	db *gorm.DB
+}
+
+func NewVoucherRepository(db *gorm.DB) repositories.VoucherRepository {
+	return &voucherRepository{db: db}
+}
+
+func (r *voucherRepository) CreateBatch(batch *entities.VoucherBatch) error {
+	return r.db.Create(batch).Error
+}
+
+func (r *voucherRepository) CreateVouchers(vouchers []*entities.HotspotVoucher) error {
+	if len(vouchers) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(vouchers, 100).Error
+}
+
+func (r *voucherRepository) UpdateBatch(batch *entities.VoucherBatch) error {
+	return r.db.Omit("Vouchers", "Router").Save(batch).Error
+}
+
+func (r *voucherRepository) FindBatchByID(id uint) (*entities.VoucherBatch, error) {
+	var batch entities.VoucherBatch
+	err := r.db.Preload("Router").
+		Preload("Vouchers", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&batch, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *voucherRepository) FindBatches(page, perPage int, routerID uint) ([]*entities.VoucherBatch, int64, error) {
+	var batches []*entities.VoucherBatch
+	var total int64
+
+	query := r.db.Model(&entities.VoucherBatch{})
+	if routerID > 0 {
+		query = query.Where("router_id = ?", routerID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	err := query.Preload("Router").Order("created_at DESC").Offset(offset).Limit(perPage).Find(&batches).Error
+	return batches, total, err
+}
+
+func (r *voucherRepository) DeleteBatch(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("batch_id = ?", id).Delete(&entities.HotspotVoucher{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entities.VoucherBatch{}, id).Error
+	})
+}
+
+func (r *voucherRepository) FindByID(id uint) (*entities.HotspotVoucher, error) {
+	var voucher entities.HotspotVoucher
+	if err := r.db.First(&voucher, id).Error; err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+func (r *voucherRepository) FindAll(page, perPage int, batchID uint, status string) ([]*entities.HotspotVoucher, int64, error) {
+	var vouchers []*entities.HotspotVoucher
+	var total int64
+
+	query := r.db.Model(&entities.HotspotVoucher{})
+	if batchID > 0 {
+		query = query.Where("batch_id = ?", batchID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	err := query.Order("id ASC").Offset(offset).Limit(perPage).Find(&vouchers).Error
+	return vouchers, total, err
+}
+
+func (r *voucherRepository) FindExistingUsernames(routerID uint, usernames []string) ([]string, error) {
+	var existing []string
+	if len(usernames) == 0 {
+		return existing, nil
+	}
+	err := r.db.Model(&entities.HotspotVoucher{}).
+		Where("router_id = ? AND username IN ?", routerID, usernames).
+		Pluck("username", &existing).Error
+	return existing, err
+}
+
+func (r *voucherRepository) Update(voucher *entities.HotspotVoucher) error {
+	return r.db.Save(voucher).Error
+}
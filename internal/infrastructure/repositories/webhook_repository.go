@@ -0,0 +1,92 @@
+package impl
+
+import (
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type webhookEndpointRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookEndpointRepository(db *gorm.DB) repositories.WebhookEndpointRepository {
+	return &webhookEndpointRepository{db: db}
+}
+
+func (r *webhookEndpointRepository) Create(endpoint *entities.WebhookEndpoint) error {
+	return r.db.Create(endpoint).Error
+}
+
+func (r *webhookEndpointRepository) FindByID(id uint) (*entities.WebhookEndpoint, error) {
+	var endpoint entities.WebhookEndpoint
+	err := r.db.First(&endpoint, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *webhookEndpointRepository) FindAll() ([]*entities.WebhookEndpoint, error) {
+	var endpoints []*entities.WebhookEndpoint
+	err := r.db.Order("id ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *webhookEndpointRepository) FindActive() ([]*entities.WebhookEndpoint, error) {
+	var endpoints []*entities.WebhookEndpoint
+	err := r.db.Where("is_active = ?", true).Order("id ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *webhookEndpointRepository) Update(endpoint *entities.WebhookEndpoint) error {
+	return r.db.Save(endpoint).Error
+}
+
+func (r *webhookEndpointRepository) Delete(id uint) error {
+	return r.db.Delete(&entities.WebhookEndpoint{}, id).Error
+}
+
+type webhookLogRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookLogRepository(db *gorm.DB) repositories.WebhookLogRepository {
+	return &webhookLogRepository{db: db}
+}
+
+func (r *webhookLogRepository) Create(log *entities.WebhookLog) error {
+	return r.db.Create(log).Error
+}
+
+func (r *webhookLogRepository) FindByID(id uint) (*entities.WebhookLog, error) {
+	var log entities.WebhookLog
+	err := r.db.First(&log, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+func (r *webhookLogRepository) FindAll(page, perPage int, event string, endpointID uint) ([]*entities.WebhookLog, int64, error) {
+	var logs []*entities.WebhookLog
+	var total int64
+
+	query := r.db.Model(&entities.WebhookLog{})
+	if event != "" {
+		query = query.Where("event = ?", event)
+	}
+	if endpointID > 0 {
+		query = query.Where("endpoint_id = ?", endpointID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Order("id DESC").Offset(offset).Limit(perPage).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
@@ -62,6 +62,7 @@ type CustomerDetail struct {
 	ONUSerial      string  `json:"onu_serial"`
 	ONUMacAddress  string  `json:"onu_mac_address"`
 	ONUIPAddress   string  `json:"onu_ip_address"`
+	StaticIP       string  `json:"static_ip"`
 	Latitude       float64 `json:"latitude"`
 	Longitude      float64 `json:"longitude"`
 	IsolationDate  *string `json:"isolation_date,omitempty"`
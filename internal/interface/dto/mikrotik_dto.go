@@ -2,21 +2,31 @@ package dto
 
 // Router DTOs
 type RouterCreate struct {
-	Name     string `json:"name" binding:"required"`
-	Host     string `json:"host" binding:"required"`
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	Port     int    `json:"port"`
-	IsActive bool   `json:"is_active"`
+	Name           string `json:"name" binding:"required"`
+	Host           string `json:"host" binding:"required"`
+	Username       string `json:"username" binding:"required"`
+	Password       string `json:"password" binding:"required"`
+	Port           int    `json:"port"`
+	IsActive       bool   `json:"is_active"`
+	IsolationMode  string `json:"isolation_mode"`
+	Transport      string `json:"transport"`
+	UseTLS         bool   `json:"use_tls"`
+	TLSCACert      string `json:"tls_ca_cert"`
+	TLSFingerprint string `json:"tls_fingerprint"`
 }
 
 type RouterUpdate struct {
-	Name     string `json:"name"`
-	Host     string `json:"host"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Port     *int   `json:"port"`
-	IsActive *bool  `json:"is_active"`
+	Name           string  `json:"name"`
+	Host           string  `json:"host"`
+	Username       string  `json:"username"`
+	Password       string  `json:"password"`
+	Port           *int    `json:"port"`
+	IsActive       *bool   `json:"is_active"`
+	IsolationMode  string  `json:"isolation_mode"`
+	Transport      string  `json:"transport"`
+	UseTLS         *bool   `json:"use_tls"`
+	TLSCACert      *string `json:"tls_ca_cert"`
+	TLSFingerprint *string `json:"tls_fingerprint"`
 }
 
 type RouterStatus struct {
@@ -30,17 +40,30 @@ type RouterStatus struct {
 	Memory      int     `json:"memory"`
 	Uptime      string  `json:"uptime"`
 	Error       string  `json:"error,omitempty"`
+
+	ConnectionState   string `json:"connection_state"`
+	LastError         string `json:"last_error,omitempty"`
+	LastErrorAt       string `json:"last_error_at,omitempty"`
+	ConnectedAt       string `json:"connected_at,omitempty"`
+	ReconnectFailures int    `json:"reconnect_failures"`
+	CertificateError  string `json:"certificate_error,omitempty"`
+	NextRetry         string `json:"next_retry,omitempty"`
 }
 
 type RouterDetail struct {
-	ID        uint   `json:"id"`
-	Name      string `json:"name"`
-	Host      string `json:"host"`
-	Username  string `json:"username"`
-	Port      int    `json:"port"`
-	IsActive  bool   `json:"is_active"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID             uint   `json:"id"`
+	Name           string `json:"name"`
+	Host           string `json:"host"`
+	Username       string `json:"username"`
+	Port           int    `json:"port"`
+	IsActive       bool   `json:"is_active"`
+	IsolationMode  string `json:"isolation_mode"`
+	Transport      string `json:"transport"`
+	UseTLS         bool   `json:"use_tls"`
+	TLSCACert      string `json:"tls_ca_cert,omitempty"`
+	TLSFingerprint string `json:"tls_fingerprint,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
 }
 
 type ConnectionTestResult struct {
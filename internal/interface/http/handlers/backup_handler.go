@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type BackupHandler struct {
+	backupUsecase *usecase.BackupUsecase
+}
+
+func NewBackupHandler(backupUsecase *usecase.BackupUsecase) *BackupHandler {
+	return &BackupHandler{backupUsecase: backupUsecase}
+}
+
+// backupIDs parses the router and backup IDs of a backup route.
+func backupIDs(c *gin.Context) (uint, uint, bool) {
+	routerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return 0, 0, false
+	}
+	backupID, err := strconv.ParseUint(c.Param("backup_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid backup ID")
+		return 0, 0, false
+	}
+	return uint(routerID), uint(backupID), true
+}
+
+// GET /api/routers/:id/backups?page=1&per_page=20
+func (h *BackupHandler) List(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	backups, total, err := h.backupUsecase.List(uint(id), page, perPage)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendPaginatedSuccess(c, backups, total, page, perPage)
+}
+
+// POST /api/routers/:id/backups
+func (h *BackupHandler) Create(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	backup, err := h.backupUsecase.BackupRouter(c.Request.Context(), uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Backup created",
+		"data":    backup,
+	})
+}
+
+// GET /api/routers/:id/backups/:backup_id/download?type=export|backup
+func (h *BackupHandler) Download(c *gin.Context) {
+	routerID, backupID, ok := backupIDs(c)
+	if !ok {
+		return
+	}
+
+	backup, err := h.backupUsecase.Get(routerID, backupID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	name := fmt.Sprintf("router-%d-%s", routerID, backup.CreatedAt.Format("20060102-150405"))
+	switch c.DefaultQuery("type", "export") {
+	case "export":
+		if backup.ExportSize == 0 {
+			utils.ErrorResponse(c, http.StatusNotFound, "Backup has no export")
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+name+`.rsc"`)
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(backup.Export))
+	case "backup":
+		if backup.BackupSize == 0 {
+			utils.ErrorResponse(c, http.StatusNotFound, "Backup has no backup file")
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+name+`.backup"`)
+		c.Data(http.StatusOK, "application/octet-stream", backup.Backup)
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Type must be export or backup")
+	}
+}
+
+// GET /api/routers/:id/backups/:backup_id/diff?from=<backup_id>
+//
+// Without from the backup is compared with the previous one.
+func (h *BackupHandler) Diff(c *gin.Context) {
+	routerID, backupID, ok := backupIDs(c)
+	if !ok {
+		return
+	}
+	var fromID uint64
+	if from := c.Query("from"); from != "" {
+		var err error
+		if fromID, err = strconv.ParseUint(from, 10, 32); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid from")
+			return
+		}
+	}
+
+	result, err := h.backupUsecase.Diff(routerID, backupID, uint(fromID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, result)
+}
@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type CashHandler struct {
+	cashUsecase *usecase.CashUsecase
+}
+
+func NewCashHandler(cashUsecase *usecase.CashUsecase) *CashHandler {
+	return &CashHandler{cashUsecase: cashUsecase}
+}
+
+// ── Admin ────────────────────────────────────────────────────
+
+// GET /api/collectors
+func (h *CashHandler) GetCollectors(c *gin.Context) {
+	collectors, err := h.cashUsecase.GetCollectors()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, collectors)
+}
+
+// POST /api/collectors
+func (h *CashHandler) CreateCollector(c *gin.Context) {
+	var req usecase.CreateCollectorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	collector, err := h.cashUsecase.CreateCollector(req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Collector created",
+		"data":    collector,
+	})
+}
+
+// GET /api/collectors/:id/customers
+func (h *CashHandler) GetCollectorCustomers(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	customers, err := h.cashUsecase.GetAssignedCustomers(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, customers)
+}
+
+// POST /api/collectors/:id/customers
+func (h *CashHandler) AssignCustomers(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req struct {
+		CustomerIDs []uint `json:"customer_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	if err := h.cashUsecase.AssignCustomers(uint(id), req.CustomerIDs); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Customers assigned to collector",
+	})
+}
+
+// DELETE /api/collectors/:id/customers/:customerId
+func (h *CashHandler) UnassignCustomer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	customerID, err := strconv.ParseUint(c.Param("customerId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	if err := h.cashUsecase.UnassignCustomer(uint(id), uint(customerID)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Customer unassigned from collector",
+	})
+}
+
+// GET /api/collectors/report?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *CashHandler) GetReport(c *gin.Context) {
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	reports, err := h.cashUsecase.GetReport(from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, reports)
+}
+
+// GET /api/collectors/:id/report?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *CashHandler) GetCollectorReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.cashUsecase.GetCollectorReport(uint(id), from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, report)
+}
+
+// GET /api/cash-settlements?collector_id=&status=
+func (h *CashHandler) GetSettlements(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	collectorID, _ := strconv.ParseUint(c.Query("collector_id"), 10, 32)
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	settlements, total, err := h.cashUsecase.GetSettlements(page, perPage, uint(collectorID), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendPaginatedSuccess(c, settlements, total, page, perPage)
+}
+
+// POST /api/cash-settlements/:id/confirm
+func (h *CashHandler) ConfirmSettlement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	settlement, err := h.cashUsecase.ConfirmSettlement(uint(id), getUserID(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Settlement confirmed",
+		"data":    settlement,
+	})
+}
+
+// GET /api/cash-payments/:id/receipt
+func (h *CashHandler) GetReceipt(c *gin.Context) {
+	h.renderReceipt(c, 0)
+}
+
+// ── Collector ────────────────────────────────────────────────
+
+// GET /api/collector/customers
+func (h *CashHandler) GetMyCustomers(c *gin.Context) {
+	customers, err := h.cashUsecase.GetAssignedCustomers(getUserID(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, customers)
+}
+
+// GET /api/collector/invoices
+func (h *CashHandler) GetMyInvoices(c *gin.Context) {
+	invoices, err := h.cashUsecase.GetUnpaidInvoices(getUserID(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, invoices)
+}
+
+// POST /api/collector/payments
+func (h *CashHandler) RecordPayment(c *gin.Context) {
+	var req usecase.RecordCashPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	payment, err := h.cashUsecase.RecordPayment(c.Request.Context(), getUserID(c), req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Cash payment recorded",
+		"data":    payment,
+	})
+}
+
+// GET /api/collector/payments?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *CashHandler) GetMyPayments(c *gin.Context) {
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	payments, err := h.cashUsecase.GetPayments(getUserID(c), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, payments)
+}
+
+// GET /api/collector/payments/:id/receipt
+func (h *CashHandler) GetMyReceipt(c *gin.Context) {
+	h.renderReceipt(c, getUserID(c))
+}
+
+// POST /api/collector/payments/:id/receipt/send
+func (h *CashHandler) SendMyReceipt(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := h.cashUsecase.SendReceipt(getUserID(c), uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Receipt sent via WhatsApp",
+	})
+}
+
+// POST /api/collector/settlements
+func (h *CashHandler) CreateSettlement(c *gin.Context) {
+	var req struct {
+		Notes string `json:"notes"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	settlement, err := h.cashUsecase.CreateSettlement(getUserID(c), req.Notes)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Settlement submitted, waiting for admin confirmation",
+		"data":    settlement,
+	})
+}
+
+// GET /api/collector/settlements
+func (h *CashHandler) GetMySettlements(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	settlements, total, err := h.cashUsecase.GetSettlements(page, perPage, getUserID(c), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendPaginatedSuccess(c, settlements, total, page, perPage)
+}
+
+// GET /api/collector/report?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *CashHandler) GetMyReport(c *gin.Context) {
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.cashUsecase.GetCollectorReport(getUserID(c), from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, report)
+}
+
+func (h *CashHandler) renderReceipt(c *gin.Context, collectorID uint) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	payment, err := h.cashUsecase.GetPayment(collectorID, uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	html, err := h.cashUsecase.RenderReceipt(payment)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", html)
+}
+
+// parseDateRange reads the from/to query dates (YYYY-MM-DD), defaulting
+// both to today. It writes the error response itself when they are invalid.
+func parseDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from, to := today, today
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid from date, use YYYY-MM-DD")
+			return from, to, false
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid to date, use YYYY-MM-DD")
+			return from, to, false
+		}
+		to = t
+	}
+	if to.Before(from) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "to date must not be before from date")
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// getUserID returns the authenticated user's ID from the JWT claims.
+func getUserID(c *gin.Context) uint {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return 0
+	}
+	if id, ok := userID.(uint); ok {
+		return id
+	}
+	return 0
+}
@@ -57,7 +57,7 @@ func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 		return
 	}
 
-	if err := h.customerUsecase.CreateCustomer(&req); err != nil {
+	if err := h.customerUsecase.CreateCustomer(c.Request.Context(), &req); err != nil {
 		utils.SendError(c, 500, "Failed to create customer: "+err.Error())
 		return
 	}
@@ -111,7 +111,7 @@ func (h *CustomerHandler) IsolateCustomer(c *gin.Context) {
 		return
 	}
 
-	if err := h.customerUsecase.IsolateCustomer(uint(id)); err != nil {
+	if err := h.customerUsecase.IsolateCustomer(c.Request.Context(), uint(id)); err != nil {
 		utils.SendError(c, 500, "Failed to isolate customer")
 		return
 	}
@@ -127,7 +127,7 @@ func (h *CustomerHandler) ActivateCustomer(c *gin.Context) {
 		return
 	}
 
-	if err := h.customerUsecase.ActivateCustomer(uint(id)); err != nil {
+	if err := h.customerUsecase.ActivateCustomer(c.Request.Context(), uint(id)); err != nil {
 		utils.SendError(c, 500, "Failed to activate customer")
 		return
 	}
@@ -143,7 +143,7 @@ func (h *CustomerHandler) SyncCustomer(c *gin.Context) {
 		return
 	}
 
-	if err := h.customerUsecase.SyncCustomer(uint(id)); err != nil {
+	if err := h.customerUsecase.SyncCustomer(c.Request.Context(), uint(id)); err != nil {
 		utils.SendError(c, 500, "Failed to sync customer")
 		return
 	}
@@ -158,7 +158,7 @@ func (h *CustomerHandler) BulkIsolate(c *gin.Context) {
 		return
 	}
 
-	if err := h.customerUsecase.BulkIsolate(req.CustomerIDs); err != nil {
+	if err := h.customerUsecase.BulkIsolate(c.Request.Context(), req.CustomerIDs); err != nil {
 		utils.SendError(c, 500, "Failed to bulk isolate customers")
 		return
 	}
@@ -173,7 +173,7 @@ func (h *CustomerHandler) BulkActivate(c *gin.Context) {
 		return
 	}
 
-	if err := h.customerUsecase.BulkActivate(req.CustomerIDs); err != nil {
+	if err := h.customerUsecase.BulkActivate(c.Request.Context(), req.CustomerIDs); err != nil {
 		utils.SendError(c, 500, "Failed to bulk activate customers")
 		return
 	}
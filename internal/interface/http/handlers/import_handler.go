@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+	importUsecase *usecase.ImportUsecase
+}
+
+func NewImportHandler(importUsecase *usecase.ImportUsecase) *ImportHandler {
+	return &ImportHandler{importUsecase: importUsecase}
+}
+
+// POST /api/routers/:id/import/preview
+func (h *ImportHandler) Preview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	var req usecase.PPPoEImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	preview, err := h.importUsecase.Preview(c.Request.Context(), uint(id), req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, preview)
+}
+
+// POST /api/routers/:id/import
+func (h *ImportHandler) Commit(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	var req usecase.PPPoEImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	result, err := h.importUsecase.Commit(c.Request.Context(), uint(id), req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "PPPoE secrets imported",
+		"data":    result,
+	})
+}
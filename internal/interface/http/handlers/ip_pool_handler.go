@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type IPPoolHandler struct {
+	poolUsecase *usecase.IPPoolUsecase
+}
+
+func NewIPPoolHandler(poolUsecase *usecase.IPPoolUsecase) *IPPoolHandler {
+	return &IPPoolHandler{poolUsecase: poolUsecase}
+}
+
+// GET /api/routers/:id/pools
+func (h *IPPoolHandler) List(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	pools, err := h.poolUsecase.List(c.Request.Context(), uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, pools)
+}
+
+// POST /api/routers/:id/pools
+func (h *IPPoolHandler) Create(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	var req usecase.IPPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	pool, err := h.poolUsecase.Create(c.Request.Context(), uint(id), req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": pool, "message": "Pool created successfully"})
+}
+
+// PUT /api/routers/:id/pools/:name
+func (h *IPPoolHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	var req usecase.IPPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	pool, err := h.poolUsecase.Update(c.Request.Context(), uint(id), c.Param("name"), req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": pool, "message": "Pool updated successfully"})
+}
+
+// DELETE /api/routers/:id/pools/:name
+func (h *IPPoolHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	if err := h.poolUsecase.Delete(c.Request.Context(), uint(id), c.Param("name")); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Pool deleted successfully"})
+}
+
+// POST /api/routers/:id/pools/:name/assign
+func (h *IPPoolHandler) AssignToProfile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	var req struct {
+		Profile string `json:"profile" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	if err := h.poolUsecase.AssignToProfile(c.Request.Context(), uint(id), c.Param("name"), req.Profile); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Pool assigned to profile " + req.Profile})
+}
+
+// GET /api/routers/:id/pools/utilization
+func (h *IPPoolHandler) Utilization(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	pools, err := h.poolUsecase.Utilization(c.Request.Context(), uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": pools, "threshold": h.poolUsecase.Threshold()})
+}
+
+// GET /api/pools/warnings
+func (h *IPPoolHandler) Warnings(c *gin.Context) {
+	warnings, err := h.poolUsecase.Warnings(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": warnings, "threshold": h.poolUsecase.Threshold()})
+}
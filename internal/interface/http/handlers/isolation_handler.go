@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type IsolationHandler struct {
+	isolationUsecase *usecase.IsolationUsecase
+}
+
+func NewIsolationHandler(isolationUsecase *usecase.IsolationUsecase) *IsolationHandler {
+	return &IsolationHandler{isolationUsecase: isolationUsecase}
+}
+
+// POST /api/routers/:id/isolation/setup
+func (h *IsolationHandler) SetupRouter(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	router, err := h.isolationUsecase.SetupRouter(c.Request.Context(), uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Isolation firewall rules installed",
+		"data":    gin.H{"router_id": router.ID, "isolation_mode": router.IsolationMode},
+	})
+}
+
+// POST /api/isolation/refresh
+func (h *IsolationHandler) Refresh(c *gin.Context) {
+	result, err := h.isolationUsecase.Refresh(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Isolation address-lists refreshed",
+		"data":    result,
+	})
+}
+
+// GET /isolir  (public, captive page)
+//
+// Customers are identified by the source address. ClientIP only takes it
+// from forwarding headers sent by server.trusted_proxies, so clients cannot
+// forge it.
+func (h *IsolationHandler) CaptivePage(c *gin.Context) {
+	h.render(c, "")
+}
+
+// POST /isolir/pay  (public, form: invoice_id, method)
+func (h *IsolationHandler) Pay(c *gin.Context) {
+	invoiceID, err := strconv.ParseUint(c.PostForm("invoice_id"), 10, 32)
+	if err != nil {
+		h.render(c, "Tagihan tidak valid")
+		return
+	}
+
+	resp, err := h.isolationUsecase.Pay(c.Request.Context(), c.ClientIP(), uint(invoiceID), c.PostForm("method"))
+	if err != nil {
+		h.render(c, "Pembayaran gagal dibuat: "+err.Error())
+		return
+	}
+
+	switch {
+	case resp.CheckoutURL != "":
+		c.Redirect(http.StatusSeeOther, resp.CheckoutURL)
+	case resp.QRImageURL != "":
+		c.Redirect(http.StatusSeeOther, resp.QRImageURL)
+	default:
+		h.render(c, "Kode pembayaran: "+resp.PayCode)
+	}
+}
+
+// NoRoute sends web traffic the router redirected from isolated customers,
+// which asks for other sites, to the captive page, and answers requests
+// for this server with a plain 404. Nothing is looked up here; the
+// captive page decides who the visitor is.
+func (h *IsolationHandler) NoRoute(c *gin.Context) {
+	captiveURL := h.isolationUsecase.CaptiveURL()
+	if captiveURL != "" && c.Request.Method == http.MethodGet &&
+		!strings.HasPrefix(c.Request.URL.Path, "/api/") && !h.isolationUsecase.IsCaptiveHost(c.Request.Host) {
+		c.Header("Cache-Control", "no-store")
+		c.Redirect(http.StatusFound, captiveURL)
+		return
+	}
+	utils.ErrorResponse(c, http.StatusNotFound, "Not found")
+}
+
+func (h *IsolationHandler) render(c *gin.Context, message string) {
+	html, err := h.isolationUsecase.RenderCaptivePage(c.Request.Context(), c.ClientIP(), message)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", html)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type MigrationHandler struct {
+	migrationUsecase *usecase.MigrationUsecase
+}
+
+func NewMigrationHandler(migrationUsecase *usecase.MigrationUsecase) *MigrationHandler {
+	return &MigrationHandler{migrationUsecase: migrationUsecase}
+}
+
+// POST /api/routers/:id/migrate
+func (h *MigrationHandler) Migrate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	var req usecase.MigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if len(req.CustomerIDs) == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Select customers to migrate")
+		return
+	}
+
+	result, err := h.migrationUsecase.Migrate(c.Request.Context(), uint(id), req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	message := "Customers migrated"
+	if req.DryRun {
+		message = "Dry run: no changes made"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    result,
+	})
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"fmt"
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/alijayanet/gembok-backend/internal/interface/dto"
 	"github.com/alijayanet/gembok-backend/internal/usecase"
@@ -11,11 +14,13 @@ import (
 
 type MikroTikHandler struct {
 	mikrotikUC usecase.MikroTikUsecase
+	logUC      *usecase.LogUsecase
 }
 
-func NewMikroTikHandler(mikrotikUC usecase.MikroTikUsecase) *MikroTikHandler {
+func NewMikroTikHandler(mikrotikUC usecase.MikroTikUsecase, logUC *usecase.LogUsecase) *MikroTikHandler {
 	return &MikroTikHandler{
 		mikrotikUC: mikrotikUC,
+		logUC:      logUC,
 	}
 }
 
@@ -23,7 +28,7 @@ func (h *MikroTikHandler) GetPPPUsers(c *gin.Context) {
 	routerIDStr := c.DefaultQuery("router_id", "0")
 	routerID, _ := strconv.ParseUint(routerIDStr, 10, 32)
 
-	result, err := h.mikrotikUC.GetPPPUsers(uint(routerID))
+	result, err := h.mikrotikUC.GetPPPUsers(c.Request.Context(), uint(routerID))
 	if err != nil {
 		utils.SendError(c, 500, "Failed to get PPP users")
 		return
@@ -36,7 +41,7 @@ func (h *MikroTikHandler) GetActiveSessions(c *gin.Context) {
 	routerIDStr := c.DefaultQuery("router_id", "0")
 	routerID, _ := strconv.ParseUint(routerIDStr, 10, 32)
 
-	result, err := h.mikrotikUC.GetActiveSessions(uint(routerID))
+	result, err := h.mikrotikUC.GetActiveSessions(c.Request.Context(), uint(routerID))
 	if err != nil {
 		utils.SendError(c, 500, "Failed to get active sessions")
 		return
@@ -49,7 +54,7 @@ func (h *MikroTikHandler) GetPPPProfiles(c *gin.Context) {
 	routerIDStr := c.DefaultQuery("router_id", "0")
 	routerID, _ := strconv.ParseUint(routerIDStr, 10, 32)
 
-	result, err := h.mikrotikUC.GetPPPProfiles(uint(routerID))
+	result, err := h.mikrotikUC.GetPPPProfiles(c.Request.Context(), uint(routerID))
 	if err != nil {
 		utils.SendError(c, 500, "Failed to get PPP profiles")
 		return
@@ -65,7 +70,7 @@ func (h *MikroTikHandler) AddPPPUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.mikrotikUC.AddPPPUser(&req); err != nil {
+	if err := h.mikrotikUC.AddPPPUser(c.Request.Context(), &req); err != nil {
 		utils.SendError(c, 500, "Failed to add PPP user")
 		return
 	}
@@ -84,7 +89,7 @@ func (h *MikroTikHandler) UpdatePPPUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.mikrotikUC.UpdatePPPUser(username, uint(routerID), params); err != nil {
+	if err := h.mikrotikUC.UpdatePPPUser(c.Request.Context(), username, uint(routerID), params); err != nil {
 		utils.SendError(c, 500, "Failed to update PPP user")
 		return
 	}
@@ -97,7 +102,7 @@ func (h *MikroTikHandler) RemovePPPUser(c *gin.Context) {
 	routerIDStr := c.DefaultQuery("router_id", "0")
 	routerID, _ := strconv.ParseUint(routerIDStr, 10, 32)
 
-	if err := h.mikrotikUC.RemovePPPUser(username, uint(routerID)); err != nil {
+	if err := h.mikrotikUC.RemovePPPUser(c.Request.Context(), username, uint(routerID)); err != nil {
 		utils.SendError(c, 500, "Failed to remove PPP user")
 		return
 	}
@@ -110,7 +115,7 @@ func (h *MikroTikHandler) DisconnectUser(c *gin.Context) {
 	routerIDStr := c.DefaultQuery("router_id", "0")
 	routerID, _ := strconv.ParseUint(routerIDStr, 10, 32)
 
-	if err := h.mikrotikUC.DisconnectUser(username, uint(routerID)); err != nil {
+	if err := h.mikrotikUC.DisconnectUser(c.Request.Context(), username, uint(routerID)); err != nil {
 		utils.SendError(c, 500, "Failed to disconnect user")
 		return
 	}
@@ -126,7 +131,7 @@ func (h *MikroTikHandler) IsolateCustomer(c *gin.Context) {
 		return
 	}
 
-	if err := h.mikrotikUC.IsolateCustomer(uint(id)); err != nil {
+	if err := h.mikrotikUC.IsolateCustomer(c.Request.Context(), uint(id)); err != nil {
 		utils.SendError(c, 500, "Failed to isolate customer")
 		return
 	}
@@ -142,7 +147,7 @@ func (h *MikroTikHandler) ActivateCustomer(c *gin.Context) {
 		return
 	}
 
-	if err := h.mikrotikUC.ActivateCustomer(uint(id)); err != nil {
+	if err := h.mikrotikUC.ActivateCustomer(c.Request.Context(), uint(id)); err != nil {
 		utils.SendError(c, 500, "Failed to activate customer")
 		return
 	}
@@ -157,7 +162,7 @@ func (h *MikroTikHandler) BulkIsolate(c *gin.Context) {
 		return
 	}
 
-	if err := h.mikrotikUC.BulkIsolate(req.CustomerIDs); err != nil {
+	if err := h.mikrotikUC.BulkIsolate(c.Request.Context(), req.CustomerIDs); err != nil {
 		utils.SendError(c, 500, "Failed to bulk isolate customers")
 		return
 	}
@@ -172,7 +177,7 @@ func (h *MikroTikHandler) BulkActivate(c *gin.Context) {
 		return
 	}
 
-	if err := h.mikrotikUC.BulkActivate(req.CustomerIDs); err != nil {
+	if err := h.mikrotikUC.BulkActivate(c.Request.Context(), req.CustomerIDs); err != nil {
 		utils.SendError(c, 500, "Failed to bulk activate customers")
 		return
 	}
@@ -188,7 +193,7 @@ func (h *MikroTikHandler) SyncCustomer(c *gin.Context) {
 		return
 	}
 
-	if err := h.mikrotikUC.SyncCustomer(uint(id)); err != nil {
+	if err := h.mikrotikUC.SyncCustomer(c.Request.Context(), uint(id)); err != nil {
 		utils.SendError(c, 500, "Failed to sync customer")
 		return
 	}
@@ -197,7 +202,7 @@ func (h *MikroTikHandler) SyncCustomer(c *gin.Context) {
 }
 
 func (h *MikroTikHandler) SyncAllCustomers(c *gin.Context) {
-	if err := h.mikrotikUC.SyncAllCustomers(); err != nil {
+	if err := h.mikrotikUC.SyncAllCustomers(c.Request.Context()); err != nil {
 		utils.SendError(c, 500, "Failed to sync all customers")
 		return
 	}
@@ -205,24 +210,81 @@ func (h *MikroTikHandler) SyncAllCustomers(c *gin.Context) {
 	utils.SendSuccessWithMessage(c, "All customers synced successfully", nil)
 }
 
-// GET /api/mikrotik/hotspot/logs - stub (MikroTik hotspot log)
+// GET /api/mikrotik/hotspot/logs
 func (h *MikroTikHandler) GetHotspotLog(c *gin.Context) {
-	limit := 20
-	// Return stub data — real implementation requires routeros v2 integration
-	c.JSON(200, gin.H{
+	h.getLogs(c, "hotspot")
+}
+
+// GET /api/mikrotik/logs?router_id=&topic=&since=&until=&search=&limit=&stored=
+func (h *MikroTikHandler) GetLogs(c *gin.Context) {
+	h.getLogs(c, "")
+}
+
+// POST /api/mikrotik/logs/ship
+func (h *MikroTikHandler) ShipLogs(c *gin.Context) {
+	stored, err := h.logUC.Ship(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    []interface{}{},
-		"message": "Hotspot log endpoint ready. MikroTik routeros v2 integration pending.",
-		"limit":   limit,
+		"message": "Router logs shipped",
+		"data":    gin.H{"stored": stored},
 	})
 }
 
-// GET /api/mikrotik/traffic - stub (Real-time traffic monitor)
-func (h *MikroTikHandler) GetTraffic(c *gin.Context) {
-	iface := c.DefaultQuery("interface", "ether1")
-	// Return stub data — real implementation requires routeros v2 integration
-	c.JSON(200, []gin.H{
-		{"data": 0, "interface": iface, "direction": "tx"},
-		{"data": 0, "interface": iface, "direction": "rx"},
+func (h *MikroTikHandler) getLogs(c *gin.Context, defaultTopic string) {
+	routerID, _ := strconv.ParseUint(c.DefaultQuery("router_id", "0"), 10, 32)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	stored, _ := strconv.ParseBool(c.DefaultQuery("stored", "false"))
+
+	now := time.Now()
+	since, err := parseLogTimeParam(c.Query("since"), now)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	until, err := parseLogTimeParam(c.Query("until"), now)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logs, err := h.logUC.GetLogs(c.Request.Context(), usecase.LogQuery{
+		RouterID: uint(routerID),
+		Topic:    c.DefaultQuery("topic", defaultTopic),
+		Since:    since,
+		Until:    until,
+		Search:   c.Query("search"),
+		Limit:    limit,
+		Stored:   stored,
 	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, logs)
+}
+
+// parseLogTimeParam accepts a duration back from now ("30m", "24h"), an
+// RFC 3339 timestamp, "YYYY-MM-DD HH:MM" or "YYYY-MM-DD".
+func parseLogTimeParam(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
 }
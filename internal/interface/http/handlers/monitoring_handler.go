@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type MonitoringHandler struct {
+	monitoringUsecase *usecase.MonitoringUsecase
+}
+
+func NewMonitoringHandler(monitoringUsecase *usecase.MonitoringUsecase) *MonitoringHandler {
+	return &MonitoringHandler{monitoringUsecase: monitoringUsecase}
+}
+
+// GET /api/routers/:id/metrics?from=&to=&step=5m
+func (h *MonitoringHandler) GetMetrics(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+	now := time.Now()
+	from, err := parseLogTimeParam(c.Query("from"), now)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid from: "+err.Error())
+		return
+	}
+	to, err := parseLogTimeParam(c.Query("to"), now)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid to: "+err.Error())
+		return
+	}
+	var step time.Duration
+	if s := c.Query("step"); s != "" {
+		if step, err = time.ParseDuration(s); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid step: "+err.Error())
+			return
+		}
+	}
+
+	history, err := h.monitoringUsecase.Metrics(uint(id), from, to, step)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, history)
+}
+
+// GET /api/monitoring/alerts?router_id=&open=true&page=1&per_page=20
+func (h *MonitoringHandler) GetAlerts(c *gin.Context) {
+	var routerID uint64
+	if s := c.Query("router_id"); s != "" {
+		var err error
+		if routerID, err = strconv.ParseUint(s, 10, 32); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+			return
+		}
+	}
+	openOnly := c.Query("open") == "true"
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	alerts, total, err := h.monitoringUsecase.Alerts(uint(routerID), openOnly, page, perPage)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendPaginatedSuccess(c, alerts, total, page, perPage)
+}
+
+// POST /api/monitoring/poll
+func (h *MonitoringHandler) Poll(c *gin.Context) {
+	reached, err := h.monitoringUsecase.Poll(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Routers polled",
+		"data":    gin.H{"reachable": reached},
+	})
+}
@@ -49,12 +49,16 @@ func (h *PackageHandler) Create(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
 		return
 	}
-	pkg, err := h.packageUC.Create(req)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	pkg, results, err := h.packageUC.Create(c.Request.Context(), req)
+	if err != nil && pkg == nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"success": true, "data": pkg, "message": "Package created successfully"})
+	resp := gin.H{"success": true, "data": pkg, "provisioning": results, "message": "Package created successfully"}
+	if err != nil {
+		resp["provisioning_error"] = err.Error()
+	}
+	c.JSON(http.StatusCreated, resp)
 }
 
 // PUT /api/packages/:id
@@ -69,12 +73,16 @@ func (h *PackageHandler) Update(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
 		return
 	}
-	pkg, err := h.packageUC.Update(uint(id), req)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	pkg, results, err := h.packageUC.Update(c.Request.Context(), uint(id), req)
+	if err != nil && pkg == nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": pkg, "message": "Package updated successfully"})
+	resp := gin.H{"success": true, "data": pkg, "provisioning": results, "message": "Package updated successfully"}
+	if err != nil {
+		resp["provisioning_error"] = err.Error()
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // DELETE /api/packages/:id
@@ -90,3 +98,27 @@ func (h *PackageHandler) Delete(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Package deleted successfully"})
 }
+
+// POST /api/packages/:id/provision
+func (h *PackageHandler) Provision(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid package ID")
+		return
+	}
+	var req struct {
+		RouterIDs []uint `json:"router_ids"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+			return
+		}
+	}
+	results, err := h.packageUC.Provision(c.Request.Context(), uint(id), req.RouterIDs)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": results, "message": "Package profiles provisioned"})
+}
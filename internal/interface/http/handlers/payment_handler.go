@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/tripay"
 	"github.com/alijayanet/gembok-backend/internal/usecase"
@@ -53,6 +55,43 @@ func (h *PaymentHandler) GetGateways(c *gin.Context) {
 	})
 }
 
+// GET /api/payment/qr/:reference/:format  (public - fetched by WhatsApp gateway)
+func (h *PaymentHandler) GetQRCode(c *gin.Context) {
+	format := c.Param("format")
+	if format != usecase.QRFormatPNG && format != usecase.QRFormatSVG {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Format must be png or svg")
+		return
+	}
+
+	img, contentType, err := h.paymentUsecase.RenderQRCode(c.Param("reference"), format)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, contentType, img)
+}
+
+// POST /api/payment/transactions/:id/send-qr
+func (h *PaymentHandler) SendQRCode(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	if err := h.paymentUsecase.SendQRCode(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "QR code sent via WhatsApp",
+	})
+}
+
 // POST /api/payment/callback  (Tripay webhook - no auth required)
 func (h *PaymentHandler) TripayCallback(c *gin.Context) {
 	rawBody, err := io.ReadAll(c.Request.Body)
@@ -63,13 +102,14 @@ func (h *PaymentHandler) TripayCallback(c *gin.Context) {
 
 	signature := c.GetHeader("X-Callback-Signature")
 
+	// The body has already been consumed above, so decode from rawBody.
 	var payload tripay.TripayCallbackPayload
-	if err := c.ShouldBindJSON(&payload); err != nil {
+	if err := json.Unmarshal(rawBody, &payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false})
 		return
 	}
 
-	if err := h.paymentUsecase.HandleCallback(payload, string(rawBody), signature); err != nil {
+	if err := h.paymentUsecase.HandleCallback(c.Request.Context(), payload, string(rawBody), signature); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 		return
 	}
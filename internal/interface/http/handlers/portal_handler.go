@@ -102,6 +102,77 @@ func (h *PortalHandler) GetInvoices(c *gin.Context) {
 	utils.SendPaginatedSuccess(c, invoices, total, page, perPage)
 }
 
+// GET /api/portal/payment/channels  (customer auth required)
+func (h *PortalHandler) GetPaymentChannels(c *gin.Context) {
+	channels, err := h.portalUsecase.GetPaymentChannels()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, channels)
+}
+
+// POST /api/portal/invoices/:id/pay  (customer auth required)
+func (h *PortalHandler) PayInvoice(c *gin.Context) {
+	customerID := getCustomerID(c)
+	if customerID == 0 {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid invoice ID")
+		return
+	}
+
+	var req struct {
+		PaymentMethod string `json:"payment_method" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Payment method is required")
+		return
+	}
+
+	resp, err := h.portalUsecase.PayInvoice(customerID, uint(invoiceID), req.PaymentMethod)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Payment transaction created",
+		"data":    resp,
+	})
+}
+
+// POST /api/portal/payment/transactions/:id/send-qr  (customer auth required)
+func (h *PortalHandler) SendPaymentQRCode(c *gin.Context) {
+	customerID := getCustomerID(c)
+	if customerID == 0 {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	trxID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	if err := h.portalUsecase.SendPaymentQRCode(customerID, uint(trxID)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "QR code sent via WhatsApp",
+	})
+}
+
 // GET /api/portal/tickets  (customer auth required)
 func (h *PortalHandler) GetTickets(c *gin.Context) {
 	customerID := getCustomerID(c)
@@ -158,12 +229,5 @@ func (h *PortalHandler) CreateTicket(c *gin.Context) {
 
 // Helper to get authenticated customer ID from context
 func getCustomerID(c *gin.Context) uint {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		return 0
-	}
-	if id, ok := userID.(uint); ok {
-		return id
-	}
-	return 0
+	return getUserID(c)
 }
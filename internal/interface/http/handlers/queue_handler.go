@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type QueueHandler struct {
+	queueUsecase *usecase.QueueUsecase
+}
+
+func NewQueueHandler(queueUsecase *usecase.QueueUsecase) *QueueHandler {
+	return &QueueHandler{queueUsecase: queueUsecase}
+}
+
+// GET /api/routers/:id/queues
+func (h *QueueHandler) List(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	queues, err := h.queueUsecase.List(c.Request.Context(), uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, queues)
+}
+
+// POST /api/routers/:id/queues
+func (h *QueueHandler) Create(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	var req usecase.QueueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	queue, err := h.queueUsecase.Create(c.Request.Context(), uint(id), req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": queue, "message": "Queue created successfully"})
+}
+
+// PUT /api/routers/:id/queues/:name
+func (h *QueueHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	var req usecase.QueueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	queue, err := h.queueUsecase.Update(c.Request.Context(), uint(id), c.Param("name"), req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": queue, "message": "Queue updated successfully"})
+}
+
+// POST /api/routers/:id/queues/:name/enable
+func (h *QueueHandler) Enable(c *gin.Context) {
+	h.setDisabled(c, false, "Queue enabled")
+}
+
+// POST /api/routers/:id/queues/:name/disable
+func (h *QueueHandler) Disable(c *gin.Context) {
+	h.setDisabled(c, true, "Queue disabled")
+}
+
+func (h *QueueHandler) setDisabled(c *gin.Context, disabled bool, message string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	if err := h.queueUsecase.SetDisabled(c.Request.Context(), uint(id), c.Param("name"), disabled); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": message})
+}
+
+// DELETE /api/routers/:id/queues/:name
+func (h *QueueHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	if err := h.queueUsecase.Delete(c.Request.Context(), uint(id), c.Param("name")); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Queue deleted successfully"})
+}
+
+// POST /api/customers/:id/queue
+func (h *QueueHandler) SyncCustomer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	queue, err := h.queueUsecase.SyncCustomer(c.Request.Context(), uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": queue, "message": "Customer queue synced"})
+}
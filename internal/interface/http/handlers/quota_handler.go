@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type QuotaHandler struct {
+	quotaUsecase *usecase.QuotaUsecase
+}
+
+func NewQuotaHandler(quotaUsecase *usecase.QuotaUsecase) *QuotaHandler {
+	return &QuotaHandler{quotaUsecase: quotaUsecase}
+}
+
+// GET /api/customers/:id/quota
+func (h *QuotaHandler) GetCustomerQuota(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	status, err := h.quotaUsecase.Status(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, status)
+}
+
+// GET /api/portal/quota  (customer auth required)
+func (h *QuotaHandler) GetMyQuota(c *gin.Context) {
+	customerID := getCustomerID(c)
+	if customerID == 0 {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	status, err := h.quotaUsecase.Status(customerID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, status)
+}
+
+// POST /api/quota/check
+func (h *QuotaHandler) Check(c *gin.Context) {
+	result, err := h.quotaUsecase.Check(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Quota check finished",
+		"data":    result,
+	})
+}
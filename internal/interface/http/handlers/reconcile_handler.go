@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ReconcileHandler struct {
+	reconcileUsecase *usecase.ReconcileUsecase
+}
+
+func NewReconcileHandler(reconcileUsecase *usecase.ReconcileUsecase) *ReconcileHandler {
+	return &ReconcileHandler{reconcileUsecase: reconcileUsecase}
+}
+
+// GET /api/routers/:id/reconcile
+func (h *ReconcileHandler) GetReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	report, err := h.reconcileUsecase.GetReport(c.Request.Context(), uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, report)
+}
+
+// POST /api/routers/:id/reconcile/fix
+func (h *ReconcileHandler) Fix(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid router ID")
+		return
+	}
+
+	var req usecase.ReconcileFixRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if !req.All && len(req.Items) == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Select items to fix or set all")
+		return
+	}
+
+	result, err := h.reconcileUsecase.Fix(c.Request.Context(), uint(id), req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	message := "Reconciliation applied"
+	if req.DryRun {
+		message = "Dry run: no changes made"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    result,
+	})
+}
@@ -107,7 +107,7 @@ func (h *RouterHandler) TestConnection(c *gin.Context) {
 		return
 	}
 
-	result, err := h.routerUC.TestConnection(uint(id))
+	result, err := h.routerUC.TestConnection(c.Request.Context(), uint(id))
 	if err != nil {
 		utils.SendError(c, 500, "Failed to test connection")
 		return
@@ -150,7 +150,7 @@ func (h *RouterHandler) GetStatus(c *gin.Context) {
 		return
 	}
 
-	status, err := h.routerUC.GetStatus(uint(id))
+	status, err := h.routerUC.GetStatus(c.Request.Context(), uint(id))
 	if err != nil {
 		utils.SendError(c, 500, "Failed to get router status")
 		return
@@ -160,7 +160,7 @@ func (h *RouterHandler) GetStatus(c *gin.Context) {
 }
 
 func (h *RouterHandler) GetAllStatus(c *gin.Context) {
-	statuses, err := h.routerUC.GetAllStatus()
+	statuses, err := h.routerUC.GetAllStatus(c.Request.Context())
 	if err != nil {
 		utils.SendError(c, 500, "Failed to get all router statuses")
 		return
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduleHandler struct {
+	scheduleUsecase *usecase.ScheduleUsecase
+}
+
+func NewScheduleHandler(scheduleUsecase *usecase.ScheduleUsecase) *ScheduleHandler {
+	return &ScheduleHandler{scheduleUsecase: scheduleUsecase}
+}
+
+// POST /api/packages/schedules/apply
+func (h *ScheduleHandler) Apply(c *gin.Context) {
+	result, err := h.scheduleUsecase.Apply(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Speed schedules applied",
+		"data":    result,
+	})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type TrafficHandler struct {
+	trafficUsecase *usecase.TrafficUsecase
+}
+
+func NewTrafficHandler(trafficUsecase *usecase.TrafficUsecase) *TrafficHandler {
+	return &TrafficHandler{trafficUsecase: trafficUsecase}
+}
+
+// GET /api/mikrotik/traffic?router_id=&interface=
+func (h *TrafficHandler) GetTraffic(c *gin.Context) {
+	routerID, _ := strconv.ParseUint(c.DefaultQuery("router_id", "0"), 10, 32)
+
+	target, err := h.trafficUsecase.InterfaceTarget(uint(routerID), c.DefaultQuery("interface", "ether1"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sample, err := h.trafficUsecase.Sample(c.Request.Context(), target)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, []gin.H{
+		{"data": sample.TxBps, "interface": sample.Interface, "direction": "tx"},
+		{"data": sample.RxBps, "interface": sample.Interface, "direction": "rx"},
+	})
+}
+
+// GET /api/mikrotik/traffic/stream?router_id=&interface=
+func (h *TrafficHandler) StreamInterface(c *gin.Context) {
+	routerID, _ := strconv.ParseUint(c.DefaultQuery("router_id", "0"), 10, 32)
+
+	target, err := h.trafficUsecase.InterfaceTarget(uint(routerID), c.Query("interface"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.stream(c, target)
+}
+
+// GET /api/customers/:id/traffic
+func (h *TrafficHandler) GetCustomerTraffic(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	target, err := h.trafficUsecase.CustomerTarget(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sample, err := h.trafficUsecase.Sample(c.Request.Context(), target)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, sample)
+}
+
+// GET /api/customers/:id/traffic/stream
+func (h *TrafficHandler) StreamCustomer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	target, err := h.trafficUsecase.CustomerTarget(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.stream(c, target)
+}
+
+// stream sends Server-Sent Events until the client goes away: a "target"
+// event first, then a "traffic" event per sample or an "error" event when a
+// reading fails. Closing the request context stops the router polling.
+func (h *TrafficHandler) stream(c *gin.Context, target *usecase.TrafficTarget) {
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	events := h.trafficUsecase.Watch(c.Request.Context(), target)
+
+	c.SSEvent("target", target)
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		event, ok := <-events
+		if !ok {
+			return false
+		}
+		if event.Err != nil {
+			c.SSEvent("error", gin.H{"message": event.Err.Error()})
+		} else {
+			c.SSEvent("traffic", event.Sample)
+		}
+		return true
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type UsageHandler struct {
+	usageUsecase *usecase.UsageUsecase
+}
+
+func NewUsageHandler(usageUsecase *usecase.UsageUsecase) *UsageHandler {
+	return &UsageHandler{usageUsecase: usageUsecase}
+}
+
+// GET /api/customers/:id/usage?period=day&from=&to=
+func (h *UsageHandler) GetCustomerUsage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+	h.history(c, uint(id))
+}
+
+// GET /api/portal/usage?period=day&from=&to=  (customer auth required)
+func (h *UsageHandler) GetMyUsage(c *gin.Context) {
+	customerID := getCustomerID(c)
+	if customerID == 0 {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	h.history(c, customerID)
+}
+
+func (h *UsageHandler) history(c *gin.Context, customerID uint) {
+	now := time.Now()
+	from, err := parseLogTimeParam(c.Query("from"), now)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid from: "+err.Error())
+		return
+	}
+	to, err := parseLogTimeParam(c.Query("to"), now)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid to: "+err.Error())
+		return
+	}
+
+	history, err := h.usageUsecase.History(customerID, c.Query("period"), from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, history)
+}
+
+// GET /api/usage?period=month&date=&limit=50
+func (h *UsageHandler) GetTop(c *gin.Context) {
+	at, err := parseLogTimeParam(c.Query("date"), time.Now())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date: "+err.Error())
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	usage, err := h.usageUsecase.Top(c.Query("period"), at, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, usage)
+}
+
+// POST /api/usage/collect
+func (h *UsageHandler) Collect(c *gin.Context) {
+	updated, err := h.usageUsecase.Collect(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Usage collected",
+		"data":    gin.H{"customers": updated},
+	})
+}
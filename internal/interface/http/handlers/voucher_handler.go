@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type VoucherHandler struct {
+	voucherUsecase *usecase.VoucherUsecase
+}
+
+func NewVoucherHandler(voucherUsecase *usecase.VoucherUsecase) *VoucherHandler {
+	return &VoucherHandler{voucherUsecase: voucherUsecase}
+}
+
+// POST /api/hotspot/batches
+func (h *VoucherHandler) Generate(c *gin.Context) {
+	var req usecase.VoucherBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	var createdBy *uint
+	if id := getUserID(c); id != 0 {
+		createdBy = &id
+	}
+
+	result, err := h.voucherUsecase.Generate(c.Request.Context(), req, createdBy)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Vouchers generated",
+		"data":    result,
+	})
+}
+
+// GET /api/hotspot/batches?router_id=
+func (h *VoucherHandler) GetBatches(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	routerID, _ := strconv.ParseUint(c.Query("router_id"), 10, 32)
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	batches, total, err := h.voucherUsecase.GetBatches(page, perPage, uint(routerID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendPaginatedSuccess(c, batches, total, page, perPage)
+}
+
+// GET /api/hotspot/batches/:id
+func (h *VoucherHandler) GetBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	batch, err := h.voucherUsecase.GetBatch(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, batch)
+}
+
+// GET /api/hotspot/batches/:id/print?status=available
+func (h *VoucherHandler) PrintBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	html, err := h.voucherUsecase.RenderBatchSheet(uint(id), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", html)
+}
+
+// DELETE /api/hotspot/batches/:id
+func (h *VoucherHandler) DeleteBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := h.voucherUsecase.DeleteBatch(c.Request.Context(), uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Voucher batch deleted"})
+}
+
+// GET /api/hotspot/vouchers?batch_id=&status=
+func (h *VoucherHandler) GetVouchers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	batchID, _ := strconv.ParseUint(c.Query("batch_id"), 10, 32)
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > usecase.MaxVoucherBatch {
+		perPage = 50
+	}
+
+	vouchers, total, err := h.voucherUsecase.GetVouchers(page, perPage, uint(batchID), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendPaginatedSuccess(c, vouchers, total, page, perPage)
+}
+
+// POST /api/hotspot/vouchers/:id/sell
+func (h *VoucherHandler) Sell(c *gin.Context) {
+	h.setSold(c, true)
+}
+
+// POST /api/hotspot/vouchers/:id/unsell
+func (h *VoucherHandler) Unsell(c *gin.Context) {
+	h.setSold(c, false)
+}
+
+func (h *VoucherHandler) setSold(c *gin.Context, sold bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	voucher, err := h.voucherUsecase.SetSold(uint(id), sold)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, voucher)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alijayanet/gembok-backend/internal/usecase"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookUsecase *usecase.WebhookUsecase
+}
+
+func NewWebhookHandler(webhookUsecase *usecase.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{webhookUsecase: webhookUsecase}
+}
+
+// GET /api/webhook-events
+func (h *WebhookHandler) GetEvents(c *gin.Context) {
+	utils.SendSuccess(c, h.webhookUsecase.GetEvents())
+}
+
+// GET /api/webhook-endpoints
+func (h *WebhookHandler) GetEndpoints(c *gin.Context) {
+	endpoints, err := h.webhookUsecase.GetEndpoints()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, endpoints)
+}
+
+// GET /api/webhook-endpoints/:id
+func (h *WebhookHandler) GetEndpoint(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	endpoint, err := h.webhookUsecase.GetEndpoint(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, endpoint)
+}
+
+// POST /api/webhook-endpoints
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	var req usecase.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	endpoint, err := h.webhookUsecase.CreateEndpoint(req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Webhook endpoint created",
+		"data":    endpoint,
+	})
+}
+
+// PUT /api/webhook-endpoints/:id
+func (h *WebhookHandler) UpdateEndpoint(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req usecase.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	endpoint, err := h.webhookUsecase.UpdateEndpoint(uint(id), req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook endpoint updated",
+		"data":    endpoint,
+	})
+}
+
+// DELETE /api/webhook-endpoints/:id
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := h.webhookUsecase.DeleteEndpoint(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook endpoint deleted",
+	})
+}
+
+// GET /api/webhook-logs?event=&endpoint_id=
+func (h *WebhookHandler) GetLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	endpointID, _ := strconv.ParseUint(c.Query("endpoint_id"), 10, 32)
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	logs, total, err := h.webhookUsecase.GetLogs(page, perPage, c.Query("event"), uint(endpointID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SendPaginatedSuccess(c, logs, total, page, perPage)
+}
+
+// POST /api/webhook-logs/:id/resend
+func (h *WebhookHandler) ResendLog(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	entry, err := h.webhookUsecase.ResendLog(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	message := "Webhook delivered"
+	if !entry.Success {
+		message = "Webhook delivery failed"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    entry,
+	})
+}
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/whatsapp"
+	"github.com/alijayanet/gembok-backend/internal/usecase"
 	"github.com/alijayanet/gembok-backend/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -11,17 +15,20 @@ import (
 
 type WhatsAppHandler struct {
 	whatsappService *whatsapp.WhatsAppService
+	voucherUsecase  *usecase.VoucherUsecase
 	webhookSecret   string
 	adminPhones     []string
 }
 
 func NewWhatsAppHandler(
 	whatsappService *whatsapp.WhatsAppService,
+	voucherUsecase *usecase.VoucherUsecase,
 	webhookSecret string,
 	adminPhones []string,
 ) *WhatsAppHandler {
 	return &WhatsAppHandler{
 		whatsappService: whatsappService,
+		voucherUsecase:  voucherUsecase,
 		webhookSecret:   webhookSecret,
 		adminPhones:     adminPhones,
 	}
@@ -52,7 +59,7 @@ func (h *WhatsAppHandler) HandleWebhook(c *gin.Context) {
 
 	isAdmin := h.whatsappService.IsAdmin(phone)
 
-	response := h.handleCommand(text, phone, isAdmin, payload)
+	response := h.handleCommand(c.Request.Context(), text, phone, isAdmin, payload)
 
 	if response != "" {
 		if err := h.whatsappService.SendBulkNotification(response, []string{phone}); err != nil {
@@ -77,7 +84,7 @@ func (h *WhatsAppHandler) TestConnection(c *gin.Context) {
 	})
 }
 
-func (h *WhatsAppHandler) handleCommand(text, phone string, isAdmin bool, payload map[string]interface{}) string {
+func (h *WhatsAppHandler) handleCommand(ctx context.Context, text, phone string, isAdmin bool, payload map[string]interface{}) string {
 	parts := strings.Split(text, " ")
 	command := parts[0]
 	args := parts[1:]
@@ -111,7 +118,7 @@ func (h *WhatsAppHandler) handleCommand(text, phone string, isAdmin bool, payloa
 			if !isAdmin {
 				return "Perintah ini hanya untuk admin."
 			}
-			return h.handleHotspotCommands(command, args)
+			return h.handleHotspotCommands(ctx, command, args)
 		}
 
 		return "Perintah tidak dikenali. Ketik /help untuk bantuan."
@@ -177,12 +184,12 @@ func (h *WhatsAppHandler) handlePPPoECommands(command string, args []string) str
 	}
 }
 
-func (h *WhatsAppHandler) handleHotspotCommands(command string, args []string) string {
+func (h *WhatsAppHandler) handleHotspotCommands(ctx context.Context, command string, args []string) string {
 	switch command {
 	case "/hotspot_list":
 		return h.handleHotspotList()
 	case "/hotspot_add":
-		return h.handleHotspotAdd(args)
+		return h.handleHotspotAdd(ctx, args)
 	case "/hotspot_del":
 		return h.handleHotspotDel(args)
 	default:
@@ -352,8 +359,56 @@ func (h *WhatsAppHandler) handleHotspotList() string {
 `
 }
 
-func (h *WhatsAppHandler) handleHotspotAdd(args []string) string {
-	return "Fitur ini memerlukan akses ke database. Silakan gunakan dashboard web."
+// whatsappVoucherLimit keeps /hotspot_add replies short enough for a chat.
+const whatsappVoucherLimit = 20
+
+func (h *WhatsAppHandler) handleHotspotAdd(ctx context.Context, args []string) string {
+	if len(args) < 1 {
+		return "Format: /hotspot_add <profile> [jumlah] [harga]"
+	}
+	if h.voucherUsecase == nil {
+		return "Fitur voucher belum dikonfigurasi."
+	}
+
+	req := usecase.VoucherBatchRequest{Profile: args[0], Count: 1}
+	if len(args) > 1 {
+		count, err := strconv.Atoi(args[1])
+		if err != nil || count < 1 || count > whatsappVoucherLimit {
+			return fmt.Sprintf("Jumlah harus antara 1 dan %d.", whatsappVoucherLimit)
+		}
+		req.Count = count
+	}
+	if len(args) > 2 {
+		price, err := strconv.ParseFloat(args[2], 64)
+		if err != nil || price < 0 {
+			return "Harga tidak valid."
+		}
+		req.Price = price
+	}
+
+	result, err := h.voucherUsecase.Generate(ctx, req, nil)
+	if err != nil {
+		logger.Error("WhatsApp hotspot_add failed", zap.Error(err))
+		return "Gagal membuat voucher: " + err.Error()
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*Voucher Hotspot* 🎫\n\nProfile: %s\nBatch: #%d\n", result.Batch.Profile, result.Batch.ID)
+	if result.Batch.Price > 0 {
+		fmt.Fprintf(&sb, "Harga: Rp %.0f\n", result.Batch.Price)
+	}
+	sb.WriteString("\n")
+	for i, v := range result.Batch.Vouchers {
+		if v.Username == v.Password {
+			fmt.Fprintf(&sb, "%d. %s\n", i+1, v.Username)
+		} else {
+			fmt.Fprintf(&sb, "%d. %s / %s\n", i+1, v.Username, v.Password)
+		}
+	}
+	if len(result.Failed) > 0 {
+		fmt.Fprintf(&sb, "\n%d voucher gagal dibuat di router.", len(result.Failed))
+	}
+	return sb.String()
 }
 
 func (h *WhatsAppHandler) handleHotspotDel(args []string) string {
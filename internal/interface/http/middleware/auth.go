@@ -43,3 +43,36 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireRole only lets through tokens whose role is one of roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "Access denied for this role",
+		})
+	}
+}
+
+// StreamAuthMiddleware is AuthMiddleware for Server-Sent Events endpoints.
+// Browsers' EventSource cannot set headers, so the token may also be passed
+// as the access_token query parameter.
+func StreamAuthMiddleware(jwtSecret string) gin.HandlerFunc {
+	auth := AuthMiddleware(jwtSecret)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		auth(c)
+	}
+}
@@ -21,17 +21,41 @@ func SetupRouter(
 	ticketHandler *handlers.TroubleTicketHandler,
 	portalHandler *handlers.PortalHandler,
 	whatsappHandler *handlers.WhatsAppHandler,
+	webhookHandler *handlers.WebhookHandler,
+	cashHandler *handlers.CashHandler,
+	reconcileHandler *handlers.ReconcileHandler,
+	importHandler *handlers.ImportHandler,
+	packageHandler *handlers.PackageHandler,
+	queueHandler *handlers.QueueHandler,
+	poolHandler *handlers.IPPoolHandler,
+	voucherHandler *handlers.VoucherHandler,
+	trafficHandler *handlers.TrafficHandler,
+	usageHandler *handlers.UsageHandler,
+	quotaHandler *handlers.QuotaHandler,
+	isolationHandler *handlers.IsolationHandler,
+	backupHandler *handlers.BackupHandler,
+	monitoringHandler *handlers.MonitoringHandler,
+	migrationHandler *handlers.MigrationHandler,
+	scheduleHandler *handlers.ScheduleHandler,
 ) *gin.Engine {
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.New()
+	// Only the configured proxies may set the client address through
+	// forwarding headers; the entries are checked when the config loads.
+	_ = router.SetTrustedProxies(cfg.Server.TrustedProxies)
 
 	router.Use(middleware.LoggingMiddleware())
 	router.Use(gin.Recovery())
 	router.Use(middleware.CORSMiddleware())
 
+	// ----- Captive page for address-list isolation (no auth) -----
+	router.GET("/isolir", isolationHandler.CaptivePage)
+	router.POST("/isolir/pay", isolationHandler.Pay)
+	router.NoRoute(isolationHandler.NoRoute)
+
 	// ----- Public routes (no auth) -----
 	public := router.Group("/api")
 	{
@@ -44,18 +68,26 @@ func SetupRouter(
 		// Tripay callback (webhook from Tripay, verified by signature)
 		public.POST("/payment/callback", paymentHandler.TripayCallback)
 
+		// QRIS QR images (public so the WhatsApp gateway can fetch them)
+		public.GET("/payment/qr/:reference/:format", paymentHandler.GetQRCode)
+
 		// WhatsApp webhook (no auth required, verified by signature)
 		public.POST("/whatsapp/webhook", whatsappHandler.HandleWebhook)
 		public.GET("/whatsapp/test", whatsappHandler.TestConnection)
 	}
 
+	// ----- Any signed-in user -----
+	auth := router.Group("/api/auth")
+	auth.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+	{
+		auth.POST("/logout", authHandler.Logout)
+		auth.GET("/me", authHandler.Me)
+	}
+
 	// ----- Admin protected routes -----
 	api := router.Group("/api")
-	api.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+	api.Use(middleware.AuthMiddleware(cfg.JWT.Secret), middleware.RequireRole("admin"))
 	{
-		// Auth
-		api.POST("/auth/logout", authHandler.Logout)
-		api.GET("/auth/me", authHandler.Me)
 
 		// Dashboard
 		api.GET("/dashboard", dashboardHandler.GetDashboardStats)
@@ -71,6 +103,10 @@ func SetupRouter(
 		api.POST("/customers/bulk-isolate", customerHandler.BulkIsolate)
 		api.POST("/customers/bulk-activate", customerHandler.BulkActivate)
 		api.POST("/customers/:id/sync", customerHandler.SyncCustomer)
+		api.POST("/customers/:id/queue", queueHandler.SyncCustomer)
+		api.GET("/customers/:id/traffic", trafficHandler.GetCustomerTraffic)
+		api.GET("/customers/:id/usage", usageHandler.GetCustomerUsage)
+		api.GET("/customers/:id/quota", quotaHandler.GetCustomerQuota)
 
 		// Invoices
 		api.GET("/invoices", invoiceHandler.GetInvoices)
@@ -79,6 +115,15 @@ func SetupRouter(
 		api.PUT("/invoices/:id", invoiceHandler.UpdateInvoice)
 		api.DELETE("/invoices/:id", invoiceHandler.DeleteInvoice)
 
+		// Packages
+		api.GET("/packages", packageHandler.GetAll)
+		api.GET("/packages/:id", packageHandler.GetByID)
+		api.POST("/packages", packageHandler.Create)
+		api.PUT("/packages/:id", packageHandler.Update)
+		api.DELETE("/packages/:id", packageHandler.Delete)
+		api.POST("/packages/:id/provision", packageHandler.Provision)
+		api.POST("/packages/schedules/apply", scheduleHandler.Apply)
+
 		// Routers
 		api.GET("/routers", routerHandler.GetRouters)
 		api.GET("/routers/active", routerHandler.GetActive)
@@ -90,6 +135,31 @@ func SetupRouter(
 		api.PUT("/routers/:id/activate", routerHandler.SetActive)
 		api.GET("/routers/:id/status", routerHandler.GetStatus)
 		api.GET("/routers/status/all", routerHandler.GetAllStatus)
+		api.GET("/routers/:id/reconcile", reconcileHandler.GetReport)
+		api.POST("/routers/:id/reconcile/fix", reconcileHandler.Fix)
+		api.POST("/routers/:id/migrate", migrationHandler.Migrate)
+		api.POST("/routers/:id/import/preview", importHandler.Preview)
+		api.POST("/routers/:id/import", importHandler.Commit)
+		api.GET("/routers/:id/queues", queueHandler.List)
+		api.POST("/routers/:id/queues", queueHandler.Create)
+		api.PUT("/routers/:id/queues/:name", queueHandler.Update)
+		api.POST("/routers/:id/queues/:name/enable", queueHandler.Enable)
+		api.POST("/routers/:id/queues/:name/disable", queueHandler.Disable)
+		api.DELETE("/routers/:id/queues/:name", queueHandler.Delete)
+		api.GET("/routers/:id/pools", poolHandler.List)
+		api.GET("/routers/:id/pools/utilization", poolHandler.Utilization)
+		api.POST("/routers/:id/pools", poolHandler.Create)
+		api.PUT("/routers/:id/pools/:name", poolHandler.Update)
+		api.DELETE("/routers/:id/pools/:name", poolHandler.Delete)
+		api.POST("/routers/:id/pools/:name/assign", poolHandler.AssignToProfile)
+		api.GET("/pools/warnings", poolHandler.Warnings)
+		api.POST("/routers/:id/isolation/setup", isolationHandler.SetupRouter)
+		api.POST("/isolation/refresh", isolationHandler.Refresh)
+		api.GET("/routers/:id/backups", backupHandler.List)
+		api.POST("/routers/:id/backups", backupHandler.Create)
+		api.GET("/routers/:id/backups/:backup_id/download", backupHandler.Download)
+		api.GET("/routers/:id/backups/:backup_id/diff", backupHandler.Diff)
+		api.GET("/routers/:id/metrics", monitoringHandler.GetMetrics)
 
 		// MikroTik PPPoE
 		api.GET("/mikrotik/ppp/users", mikrotikHandler.GetPPPUsers)
@@ -100,9 +170,30 @@ func SetupRouter(
 		api.DELETE("/mikrotik/ppp/users/:username", mikrotikHandler.RemovePPPUser)
 		api.POST("/mikrotik/ppp/users/:username/disconnect", mikrotikHandler.DisconnectUser)
 
+		// Hotspot vouchers
+		api.POST("/hotspot/batches", voucherHandler.Generate)
+		api.GET("/hotspot/batches", voucherHandler.GetBatches)
+		api.GET("/hotspot/batches/:id", voucherHandler.GetBatch)
+		api.GET("/hotspot/batches/:id/print", voucherHandler.PrintBatch)
+		api.DELETE("/hotspot/batches/:id", voucherHandler.DeleteBatch)
+		api.GET("/hotspot/vouchers", voucherHandler.GetVouchers)
+		api.POST("/hotspot/vouchers/:id/sell", voucherHandler.Sell)
+		api.POST("/hotspot/vouchers/:id/unsell", voucherHandler.Unsell)
+
 		// MikroTik Hotspot & Traffic
 		api.GET("/mikrotik/hotspot/logs", mikrotikHandler.GetHotspotLog)
-		api.GET("/mikrotik/traffic", mikrotikHandler.GetTraffic)
+		api.GET("/mikrotik/logs", mikrotikHandler.GetLogs)
+		api.POST("/mikrotik/logs/ship", mikrotikHandler.ShipLogs)
+		api.GET("/mikrotik/traffic", trafficHandler.GetTraffic)
+
+		// Data usage
+		api.GET("/usage", usageHandler.GetTop)
+		api.POST("/usage/collect", usageHandler.Collect)
+		api.POST("/quota/check", quotaHandler.Check)
+
+		// Router monitoring
+		api.GET("/monitoring/alerts", monitoringHandler.GetAlerts)
+		api.POST("/monitoring/poll", monitoringHandler.Poll)
 
 		// GenieACS
 		api.GET("/genieacs/devices", genieacsHandler.GetDevices)
@@ -114,6 +205,7 @@ func SetupRouter(
 		// Payment
 		api.GET("/payment/gateways", paymentHandler.GetGateways)
 		api.POST("/payment/create", paymentHandler.CreateTransaction)
+		api.POST("/payment/transactions/:id/send-qr", paymentHandler.SendQRCode)
 
 		// ONU Locations & WiFi
 		api.GET("/onu-locations", onuHandler.GetLocations)
@@ -125,17 +217,67 @@ func SetupRouter(
 		api.GET("/tickets/:id", ticketHandler.GetByID)
 		api.POST("/tickets", ticketHandler.Create)
 		api.PUT("/tickets/:id", ticketHandler.Update)
+
+		// Outbound webhooks
+		api.GET("/webhook-events", webhookHandler.GetEvents)
+		api.GET("/webhook-endpoints", webhookHandler.GetEndpoints)
+		api.GET("/webhook-endpoints/:id", webhookHandler.GetEndpoint)
+		api.POST("/webhook-endpoints", webhookHandler.CreateEndpoint)
+		api.PUT("/webhook-endpoints/:id", webhookHandler.UpdateEndpoint)
+		api.DELETE("/webhook-endpoints/:id", webhookHandler.DeleteEndpoint)
+		api.GET("/webhook-logs", webhookHandler.GetLogs)
+		api.POST("/webhook-logs/:id/resend", webhookHandler.ResendLog)
+
+		// Cash collection
+		api.GET("/collectors", cashHandler.GetCollectors)
+		api.POST("/collectors", cashHandler.CreateCollector)
+		api.GET("/collectors/report", cashHandler.GetReport)
+		api.GET("/collectors/:id/report", cashHandler.GetCollectorReport)
+		api.GET("/collectors/:id/customers", cashHandler.GetCollectorCustomers)
+		api.POST("/collectors/:id/customers", cashHandler.AssignCustomers)
+		api.DELETE("/collectors/:id/customers/:customerId", cashHandler.UnassignCustomer)
+		api.GET("/cash-settlements", cashHandler.GetSettlements)
+		api.POST("/cash-settlements/:id/confirm", cashHandler.ConfirmSettlement)
+		api.GET("/cash-payments/:id/receipt", cashHandler.GetReceipt)
+	}
+
+	// ----- Admin event streams (token may be sent as ?access_token=) -----
+	stream := router.Group("/api")
+	stream.Use(middleware.StreamAuthMiddleware(cfg.JWT.Secret), middleware.RequireRole("admin"))
+	{
+		stream.GET("/mikrotik/traffic/stream", trafficHandler.StreamInterface)
+		stream.GET("/customers/:id/traffic/stream", trafficHandler.StreamCustomer)
 	}
 
 	// ----- Customer portal protected routes -----
 	portal := router.Group("/api/portal")
-	portal.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+	portal.Use(middleware.AuthMiddleware(cfg.JWT.Secret), middleware.RequireRole("customer"))
 	{
 		portal.GET("/profile", portalHandler.GetProfile)
 		portal.PUT("/password", portalHandler.ChangePassword)
 		portal.GET("/invoices", portalHandler.GetInvoices)
+		portal.POST("/invoices/:id/pay", portalHandler.PayInvoice)
+		portal.GET("/payment/channels", portalHandler.GetPaymentChannels)
+		portal.POST("/payment/transactions/:id/send-qr", portalHandler.SendPaymentQRCode)
 		portal.GET("/tickets", portalHandler.GetTickets)
 		portal.POST("/tickets", portalHandler.CreateTicket)
+		portal.GET("/usage", usageHandler.GetMyUsage)
+		portal.GET("/quota", quotaHandler.GetMyQuota)
+	}
+
+	// ----- Field collector routes -----
+	collector := router.Group("/api/collector")
+	collector.Use(middleware.AuthMiddleware(cfg.JWT.Secret), middleware.RequireRole("collector"))
+	{
+		collector.GET("/customers", cashHandler.GetMyCustomers)
+		collector.GET("/invoices", cashHandler.GetMyInvoices)
+		collector.POST("/payments", cashHandler.RecordPayment)
+		collector.GET("/payments", cashHandler.GetMyPayments)
+		collector.GET("/payments/:id/receipt", cashHandler.GetMyReceipt)
+		collector.POST("/payments/:id/receipt/send", cashHandler.SendMyReceipt)
+		collector.POST("/settlements", cashHandler.CreateSettlement)
+		collector.GET("/settlements", cashHandler.GetMySettlements)
+		collector.GET("/report", cashHandler.GetMyReport)
 	}
 
 	return router
@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/pkg/diff"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const backupDiffContext = 3
+
+// BackupRunResult summarizes one scheduled backup of every router.
+type BackupRunResult struct {
+	Routers   int   `json:"routers"`
+	Succeeded int   `json:"succeeded"`
+	Failed    int   `json:"failed"`
+	Pruned    int64 `json:"pruned"`
+}
+
+// BackupDiff is the unified diff between the exports of two backups.
+type BackupDiff struct {
+	RouterID uint      `json:"router_id"`
+	FromID   uint      `json:"from_id"`
+	ToID     uint      `json:"to_id"`
+	FromTime time.Time `json:"from_time"`
+	ToTime   time.Time `json:"to_time"`
+	Changed  bool      `json:"changed"`
+	Diff     string    `json:"diff"`
+	// TooLarge is set instead of Diff when the exports differ in more
+	// than diff.MaxEdits lines.
+	TooLarge bool `json:"too_large,omitempty"`
+}
+
+type BackupUsecase struct {
+	routerRepo     repositories.RouterRepository
+	backupRepo     repositories.RouterBackupRepository
+	mikrotikClient *mikrotik.MikroTikClient
+	retention      time.Duration
+	keep           int
+}
+
+// NewBackupUsecase keeps backups for retention, but never fewer than the
+// newest keep per router. A zero retention keeps everything.
+func NewBackupUsecase(routerRepo repositories.RouterRepository, backupRepo repositories.RouterBackupRepository, mikrotikClient *mikrotik.MikroTikClient, retention time.Duration, keep int) *BackupUsecase {
+	return &BackupUsecase{
+		routerRepo:     routerRepo,
+		backupRepo:     backupRepo,
+		mikrotikClient: mikrotikClient,
+		retention:      retention,
+		keep:           keep,
+	}
+}
+
+// exportHash hashes an export without the "# <time> by RouterOS <version>"
+// line RouterOS puts at the top, which differs on every export.
+func exportHash(export string) string {
+	if first, rest, ok := strings.Cut(export, "\n"); ok && strings.HasPrefix(first, "# ") && strings.Contains(first, " by RouterOS") {
+		export = rest
+	}
+	sum := sha256.Sum256([]byte(export))
+	return hex.EncodeToString(sum[:])
+}
+
+// BackupRouter takes an /export and a /system/backup of a router and
+// stores them. A backup is stored when at least one of the two succeeded.
+func (u *BackupUsecase) BackupRouter(ctx context.Context, routerID uint) (*entities.RouterBackup, error) {
+	if _, err := u.routerRepo.FindByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+
+	export, exportErr := u.mikrotikClient.ExportConfig(ctx, routerID)
+	data, backupErr := u.mikrotikClient.SaveBackup(ctx, routerID)
+	if exportErr != nil && backupErr != nil {
+		return nil, exportErr
+	}
+
+	backup := &entities.RouterBackup{
+		RouterID:   routerID,
+		Export:     export,
+		Backup:     data,
+		ExportSize: len(export),
+		BackupSize: len(data),
+	}
+	var errs []string
+	for _, err := range []error{exportErr, backupErr} {
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	backup.Error = strings.Join(errs, "; ")
+
+	if exportErr == nil {
+		backup.ExportHash = exportHash(export)
+		latest, err := u.backupRepo.FindLatestExport(routerID)
+		backup.Changed = err != nil || latest.ExportHash != backup.ExportHash
+	}
+
+	if err := u.backupRepo.Create(backup); err != nil {
+		return nil, err
+	}
+	logger.Info("Router backup stored",
+		zap.Uint("router_id", routerID),
+		zap.Uint("backup_id", backup.ID),
+		zap.Bool("changed", backup.Changed),
+	)
+	return backup, nil
+}
+
+// Run backs up every router concurrently, then prunes expired backups.
+// Routers that cannot be reached are counted as failed.
+func (u *BackupUsecase) Run(ctx context.Context) (*BackupRunResult, error) {
+	routers, err := u.routerRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BackupRunResult{Routers: len(routers)}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, router := range routers {
+		wg.Add(1)
+		go func(router *entities.Router) {
+			defer wg.Done()
+			_, err := u.BackupRouter(ctx, router.ID)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				logger.Warn("Router backup failed",
+					zap.Uint("router_id", router.ID),
+					zap.Error(err),
+				)
+				result.Failed++
+				return
+			}
+			result.Succeeded++
+		}(router)
+	}
+	wg.Wait()
+
+	if u.retention > 0 {
+		before := time.Now().Add(-u.retention)
+		for _, router := range routers {
+			n, err := u.backupRepo.DeleteExpired(router.ID, before, u.keep)
+			if err != nil {
+				logger.Error("Failed to prune router backups",
+					zap.Uint("router_id", router.ID),
+					zap.Error(err),
+				)
+				continue
+			}
+			result.Pruned += n
+		}
+	}
+
+	return result, nil
+}
+
+// StartScheduling runs Run every interval. It returns a function that stops
+// the loop.
+func (u *BackupUsecase) StartScheduling(interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			result, err := u.Run(ctx)
+			if err != nil {
+				logger.Error("Router backups failed", zap.Error(err))
+				continue
+			}
+			logger.Info("Router backups finished",
+				zap.Int("succeeded", result.Succeeded),
+				zap.Int("failed", result.Failed),
+				zap.Int64("pruned", result.Pruned),
+			)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// List returns a router's backups, newest first.
+func (u *BackupUsecase) List(routerID uint, page, perPage int) ([]*entities.RouterBackup, int64, error) {
+	return u.backupRepo.FindByRouterID(routerID, page, perPage)
+}
+
+// Get returns a backup of a router with its export and backup file.
+func (u *BackupUsecase) Get(routerID, backupID uint) (*entities.RouterBackup, error) {
+	backup, err := u.backupRepo.FindByID(backupID)
+	if err != nil || backup.RouterID != routerID {
+		return nil, fmt.Errorf("backup not found")
+	}
+	return backup, nil
+}
+
+// Diff compares the export of backup fromID with that of backupID. A zero
+// fromID compares with the router's previous export.
+func (u *BackupUsecase) Diff(routerID, backupID, fromID uint) (*BackupDiff, error) {
+	to, err := u.Get(routerID, backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var from *entities.RouterBackup
+	if fromID == 0 {
+		if from, err = u.backupRepo.FindPrevious(to); err != nil {
+			return nil, fmt.Errorf("no earlier backup to compare with")
+		}
+	} else if from, err = u.Get(routerID, fromID); err != nil {
+		return nil, err
+	}
+	if from.ExportSize == 0 || to.ExportSize == 0 {
+		return nil, fmt.Errorf("both backups need an export to compare")
+	}
+
+	text, err := diff.Unified(
+		fmt.Sprintf("backup-%d.rsc\t%s", from.ID, from.CreatedAt.Format(time.RFC3339)),
+		fmt.Sprintf("backup-%d.rsc\t%s", to.ID, to.CreatedAt.Format(time.RFC3339)),
+		from.Export, to.Export, backupDiffContext,
+	)
+	return &BackupDiff{
+		RouterID: routerID,
+		FromID:   from.ID,
+		ToID:     to.ID,
+		FromTime: from.CreatedAt,
+		ToTime:   to.CreatedAt,
+		Changed:  from.ExportHash != to.ExportHash,
+		Diff:     text,
+		TooLarge: errors.Is(err, diff.ErrTooManyChanges),
+	}, nil
+}
@@ -0,0 +1,483 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/whatsapp"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"github.com/alijayanet/gembok-backend/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// Cash settlement statuses. A settlement is pending until an admin confirms
+// the cash was handed over.
+const (
+	SettlementStatusPending   = "pending"
+	SettlementStatusConfirmed = "confirmed"
+)
+
+// CashPaymentMethod is stored as the invoice payment method for cash.
+const CashPaymentMethod = "CASH"
+
+// CashUsecase handles door-to-door cash collection by field collectors.
+type CashUsecase struct {
+	cashRepo       repositories.CashPaymentRepository
+	settlementRepo repositories.CashSettlementRepository
+	customerRepo   repositories.CustomerRepository
+	invoiceRepo    repositories.InvoiceRepository
+	adminRepo      repositories.AdminRepository
+	paymentUsecase *PaymentUsecase
+	whatsappSvc    *whatsapp.WhatsAppService
+	appName        string
+}
+
+func NewCashUsecase(
+	cashRepo repositories.CashPaymentRepository,
+	settlementRepo repositories.CashSettlementRepository,
+	customerRepo repositories.CustomerRepository,
+	invoiceRepo repositories.InvoiceRepository,
+	adminRepo repositories.AdminRepository,
+	paymentUsecase *PaymentUsecase,
+	whatsappSvc *whatsapp.WhatsAppService,
+	appName string,
+) *CashUsecase {
+	return &CashUsecase{
+		cashRepo:       cashRepo,
+		settlementRepo: settlementRepo,
+		customerRepo:   customerRepo,
+		invoiceRepo:    invoiceRepo,
+		adminRepo:      adminRepo,
+		paymentUsecase: paymentUsecase,
+		whatsappSvc:    whatsappSvc,
+		appName:        appName,
+	}
+}
+
+// ── Collector management (admin) ─────────────────────────────
+
+type CreateCollectorRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+	Email    string `json:"email"`
+}
+
+func (u *CashUsecase) GetCollectors() ([]*entities.AdminUser, error) {
+	return u.adminRepo.FindByRole("collector")
+}
+
+func (u *CashUsecase) CreateCollector(req CreateCollectorRequest) (*entities.AdminUser, error) {
+	if _, err := u.adminRepo.FindByUsername(req.Username); err == nil {
+		return nil, fmt.Errorf("username already exists")
+	}
+
+	hashed, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	collector := &entities.AdminUser{
+		Username: req.Username,
+		Password: hashed,
+		Email:    req.Email,
+		Role:     "collector",
+		Status:   "active",
+	}
+	if err := u.adminRepo.Create(collector); err != nil {
+		return nil, fmt.Errorf("failed to create collector: %w", err)
+	}
+	return collector, nil
+}
+
+func (u *CashUsecase) findCollector(id uint) (*entities.AdminUser, error) {
+	collector, err := u.adminRepo.FindByID(id)
+	if err != nil || collector.Role != "collector" {
+		return nil, fmt.Errorf("collector not found")
+	}
+	return collector, nil
+}
+
+func (u *CashUsecase) AssignCustomers(collectorID uint, customerIDs []uint) error {
+	if _, err := u.findCollector(collectorID); err != nil {
+		return err
+	}
+
+	for _, id := range customerIDs {
+		customer, err := u.customerRepo.FindByID(id)
+		if err != nil {
+			return fmt.Errorf("customer %d not found", id)
+		}
+		customer.CollectorID = &collectorID
+		if err := u.customerRepo.Update(customer); err != nil {
+			return fmt.Errorf("failed to assign customer %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (u *CashUsecase) UnassignCustomer(collectorID, customerID uint) error {
+	customer, err := u.customerRepo.FindByID(customerID)
+	if err != nil || customer.CollectorID == nil || *customer.CollectorID != collectorID {
+		return fmt.Errorf("customer is not assigned to this collector")
+	}
+	customer.CollectorID = nil
+	return u.customerRepo.Update(customer)
+}
+
+func (u *CashUsecase) GetSettlements(page, perPage int, collectorID uint, status string) ([]*entities.CashSettlement, int64, error) {
+	return u.settlementRepo.FindAll(page, perPage, collectorID, status)
+}
+
+// ConfirmSettlement records that an admin received the cash of a settlement.
+func (u *CashUsecase) ConfirmSettlement(id, adminID uint) (*entities.CashSettlement, error) {
+	settlement, err := u.settlementRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("settlement not found")
+	}
+	if settlement.Status == SettlementStatusConfirmed {
+		return nil, fmt.Errorf("settlement is already confirmed")
+	}
+
+	now := time.Now()
+	settlement.Status = SettlementStatusConfirmed
+	settlement.ConfirmedBy = &adminID
+	settlement.ConfirmedAt = &now
+	if err := u.settlementRepo.Update(settlement); err != nil {
+		return nil, fmt.Errorf("failed to confirm settlement: %w", err)
+	}
+	return settlement, nil
+}
+
+// ── Collector operations ─────────────────────────────────────
+
+// CollectorCustomer is an assigned customer with their unpaid invoices.
+type CollectorCustomer struct {
+	Customer       *entities.Customer  `json:"customer"`
+	UnpaidInvoices []*entities.Invoice `json:"unpaid_invoices"`
+	Outstanding    float64             `json:"outstanding"`
+}
+
+func (u *CashUsecase) GetAssignedCustomers(collectorID uint) ([]CollectorCustomer, error) {
+	customers, err := u.customerRepo.FindByCollectorID(collectorID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(customers))
+	for _, c := range customers {
+		ids = append(ids, c.ID)
+	}
+	invoices, err := u.invoiceRepo.FindUnpaidByCustomerIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byCustomer := make(map[uint][]*entities.Invoice)
+	for _, inv := range invoices {
+		inv.Customer = nil
+		byCustomer[inv.CustomerID] = append(byCustomer[inv.CustomerID], inv)
+	}
+
+	result := make([]CollectorCustomer, 0, len(customers))
+	for _, c := range customers {
+		item := CollectorCustomer{Customer: c, UnpaidInvoices: byCustomer[c.ID]}
+		if item.UnpaidInvoices == nil {
+			item.UnpaidInvoices = []*entities.Invoice{}
+		}
+		for _, inv := range item.UnpaidInvoices {
+			item.Outstanding += inv.Amount
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (u *CashUsecase) GetUnpaidInvoices(collectorID uint) ([]*entities.Invoice, error) {
+	customers, err := u.customerRepo.FindByCollectorID(collectorID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(customers))
+	for _, c := range customers {
+		ids = append(ids, c.ID)
+	}
+	return u.invoiceRepo.FindUnpaidByCustomerIDs(ids)
+}
+
+type RecordCashPaymentRequest struct {
+	InvoiceID uint     `json:"invoice_id" binding:"required"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Notes     string   `json:"notes"`
+}
+
+// RecordPayment records the full cash payment of an invoice belonging to
+// one of the collector's assigned customers and marks the invoice paid.
+func (u *CashUsecase) RecordPayment(ctx context.Context, collectorID uint, req RecordCashPaymentRequest) (*entities.CashPayment, error) {
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return nil, fmt.Errorf("latitude and longitude must be given together")
+	}
+	if req.Latitude != nil && (*req.Latitude < -90 || *req.Latitude > 90 || *req.Longitude < -180 || *req.Longitude > 180) {
+		return nil, fmt.Errorf("invalid GPS coordinates")
+	}
+
+	invoice, err := u.invoiceRepo.FindByID(req.InvoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found")
+	}
+
+	customer, err := u.customerRepo.FindByID(invoice.CustomerID)
+	if err != nil || customer.CollectorID == nil || *customer.CollectorID != collectorID {
+		return nil, fmt.Errorf("invoice not found")
+	}
+
+	if invoice.Status == "paid" {
+		return nil, fmt.Errorf("invoice is already paid")
+	}
+
+	now := time.Now()
+	payment := &entities.CashPayment{
+		InvoiceID:   invoice.ID,
+		CustomerID:  customer.ID,
+		CollectorID: collectorID,
+		Amount:      invoice.Amount,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Notes:       req.Notes,
+		CollectedAt: now,
+	}
+	if err := u.cashRepo.CreateForInvoice(payment, receiptPrefix(collectorID, now)); err != nil {
+		if errors.Is(err, repositories.ErrInvoiceAlreadyPaid) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to record cash payment: %w", err)
+	}
+
+	if err := u.paymentUsecase.MarkInvoicePaid(ctx, invoice, CashPaymentMethod, payment.ReceiptNumber, now); err != nil {
+		if delErr := u.cashRepo.Delete(payment.ID); delErr != nil {
+			logger.Error("Failed to roll back cash payment",
+				zap.Uint("payment_id", payment.ID),
+				zap.Error(delErr),
+			)
+		}
+		return nil, err
+	}
+
+	return u.cashRepo.FindByID(payment.ID)
+}
+
+// receiptPrefix returns RCP-<date>-<collector>-; the repository appends the
+// collector's sequence number for the day.
+func receiptPrefix(collectorID uint, now time.Time) string {
+	return fmt.Sprintf("RCP-%s-%d-", now.Format("20060102"), collectorID)
+}
+
+func (u *CashUsecase) GetPayments(collectorID uint, from, to time.Time) ([]*entities.CashPayment, error) {
+	return u.cashRepo.FindByCollectorID(collectorID, from, to)
+}
+
+// GetPayment returns a cash payment. A non-zero collectorID restricts the
+// lookup to that collector's own payments.
+func (u *CashUsecase) GetPayment(collectorID, id uint) (*entities.CashPayment, error) {
+	payment, err := u.cashRepo.FindByID(id)
+	if err != nil || (collectorID != 0 && payment.CollectorID != collectorID) {
+		return nil, fmt.Errorf("cash payment not found")
+	}
+	return payment, nil
+}
+
+var receiptTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Kwitansi {{.Payment.ReceiptNumber}}</title>
+<style>
+  body { font-family: monospace; width: 58mm; margin: 0 auto; font-size: 12px; }
+  h1 { font-size: 14px; text-align: center; margin: 8px 0; }
+  table { width: 100%; border-collapse: collapse; }
+  td { padding: 2px 0; vertical-align: top; }
+  td.r { text-align: right; }
+  .total { border-top: 1px dashed #000; font-weight: bold; }
+  .center { text-align: center; margin-top: 10px; }
+  @media print { .no-print { display: none; } }
+</style>
+</head>
+<body>
+<h1>{{.AppName}}</h1>
+<div class="center">KWITANSI PEMBAYARAN</div>
+<table>
+  <tr><td>No</td><td class="r">{{.Payment.ReceiptNumber}}</td></tr>
+  <tr><td>Tanggal</td><td class="r">{{.CollectedAt}}</td></tr>
+  <tr><td>Pelanggan</td><td class="r">{{.CustomerName}}</td></tr>
+  <tr><td>Invoice</td><td class="r">{{.InvoiceNumber}}</td></tr>
+  <tr><td>Periode</td><td class="r">{{.Period}}</td></tr>
+  <tr><td>Petugas</td><td class="r">{{.CollectorName}}</td></tr>
+  <tr class="total"><td>Jumlah</td><td class="r">Rp {{printf "%.2f" .Payment.Amount}}</td></tr>
+</table>
+<div class="center">Pembayaran tunai telah diterima.<br>Terima kasih!</div>
+<div class="center no-print"><button onclick="window.print()">Cetak</button></div>
+</body>
+</html>
+`))
+
+// RenderReceipt renders a printable HTML receipt sized for 58mm printers.
+func (u *CashUsecase) RenderReceipt(payment *entities.CashPayment) ([]byte, error) {
+	data := struct {
+		AppName       string
+		Payment       *entities.CashPayment
+		CollectedAt   string
+		CustomerName  string
+		InvoiceNumber string
+		Period        string
+		CollectorName string
+	}{
+		AppName:     u.appName,
+		Payment:     payment,
+		CollectedAt: payment.CollectedAt.Format("2006-01-02 15:04"),
+	}
+	if payment.Customer != nil {
+		data.CustomerName = payment.Customer.Name
+	}
+	if payment.Invoice != nil {
+		data.InvoiceNumber = payment.Invoice.Number
+		data.Period = payment.Invoice.Period
+	}
+	if payment.Collector != nil {
+		data.CollectorName = payment.Collector.Username
+	}
+
+	var buf bytes.Buffer
+	if err := receiptTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render receipt: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SendReceipt sends the receipt of a cash payment to the customer over
+// WhatsApp.
+func (u *CashUsecase) SendReceipt(collectorID, id uint) error {
+	if u.whatsappSvc == nil {
+		return fmt.Errorf("whatsapp service not configured")
+	}
+	payment, err := u.GetPayment(collectorID, id)
+	if err != nil {
+		return err
+	}
+	return u.whatsappSvc.SendCashReceipt(payment)
+}
+
+// CreateSettlement bundles every unsettled cash payment of the collector
+// into a settlement awaiting admin confirmation.
+func (u *CashUsecase) CreateSettlement(collectorID uint, notes string) (*entities.CashSettlement, error) {
+	payments, err := u.cashRepo.FindUnsettledByCollectorID(collectorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(payments) == 0 {
+		return nil, fmt.Errorf("no unsettled cash payments")
+	}
+
+	settlement := &entities.CashSettlement{
+		CollectorID:  collectorID,
+		PaymentCount: len(payments),
+		Status:       SettlementStatusPending,
+		Notes:        notes,
+	}
+	ids := make([]uint, 0, len(payments))
+	for _, p := range payments {
+		settlement.Amount += p.Amount
+		ids = append(ids, p.ID)
+	}
+
+	if err := u.settlementRepo.Create(settlement); err != nil {
+		return nil, fmt.Errorf("failed to create settlement: %w", err)
+	}
+	if err := u.cashRepo.SetSettlement(ids, settlement.ID); err != nil {
+		return nil, fmt.Errorf("failed to attach payments to settlement: %w", err)
+	}
+	return settlement, nil
+}
+
+// ── Reconciliation ───────────────────────────────────────────
+
+// CollectorReport reconciles a collector's cash for a period. Collected and
+// Deposited cover the period; Outstanding is the cash currently held that
+// has not been confirmed by an admin, of which PendingSettlement has already
+// been handed over for confirmation.
+type CollectorReport struct {
+	CollectorID       uint    `json:"collector_id"`
+	Username          string  `json:"username"`
+	From              string  `json:"from"`
+	To                string  `json:"to"`
+	PaymentCount      int64   `json:"payment_count"`
+	Collected         float64 `json:"collected"`
+	Deposited         float64 `json:"deposited"`
+	PendingSettlement float64 `json:"pending_settlement"`
+	Outstanding       float64 `json:"outstanding"`
+}
+
+// GetCollectorReport reports on one collector between from and to (both
+// dates inclusive).
+func (u *CashUsecase) GetCollectorReport(collectorID uint, from, to time.Time) (*CollectorReport, error) {
+	collector, err := u.findCollector(collectorID)
+	if err != nil {
+		return nil, err
+	}
+	return u.buildReport(collector, from, to)
+}
+
+func (u *CashUsecase) GetReport(from, to time.Time) ([]*CollectorReport, error) {
+	collectors, err := u.adminRepo.FindByRole("collector")
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*CollectorReport, 0, len(collectors))
+	for _, collector := range collectors {
+		report, err := u.buildReport(collector, from, to)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (u *CashUsecase) buildReport(collector *entities.AdminUser, from, to time.Time) (*CollectorReport, error) {
+	end := to.AddDate(0, 0, 1)
+
+	collected, count, err := u.cashRepo.SumCollected(collector.ID, from, end)
+	if err != nil {
+		return nil, err
+	}
+	deposited, err := u.settlementRepo.SumConfirmed(collector.ID, from, end)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := u.settlementRepo.SumPending(collector.ID)
+	if err != nil {
+		return nil, err
+	}
+	outstanding, err := u.cashRepo.SumOutstanding(collector.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CollectorReport{
+		CollectorID:       collector.ID,
+		Username:          collector.Username,
+		From:              from.Format("2006-01-02"),
+		To:                to.Format("2006-01-02"),
+		PaymentCount:      count,
+		Collected:         collected,
+		Deposited:         deposited,
+		PendingSettlement: pending,
+		Outstanding:       outstanding,
+	}, nil
+}
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/whatsapp"
@@ -12,14 +14,14 @@ import (
 type CustomerUsecase interface {
 	GetCustomers(page, perPage int, search string) (*dto.CustomerListResponse, error)
 	GetCustomerByID(id uint) (*dto.CustomerDetail, error)
-	CreateCustomer(customer *dto.CustomerDetail) error
+	CreateCustomer(ctx context.Context, customer *dto.CustomerDetail) error
 	UpdateCustomer(id uint, customer *dto.CustomerDetail) error
 	DeleteCustomer(id uint) error
-	IsolateCustomer(id uint) error
-	ActivateCustomer(id uint) error
-	SyncCustomer(id uint) error
-	BulkIsolate(ids []uint) error
-	BulkActivate(ids []uint) error
+	IsolateCustomer(ctx context.Context, id uint) error
+	ActivateCustomer(ctx context.Context, id uint) error
+	SyncCustomer(ctx context.Context, id uint) error
+	BulkIsolate(ctx context.Context, ids []uint) error
+	BulkActivate(ctx context.Context, ids []uint) error
 }
 
 type customerUsecase struct {
@@ -77,7 +79,7 @@ func (u *customerUsecase) GetCustomerByID(id uint) (*dto.CustomerDetail, error)
 	return u.entityToDTO(customer), nil
 }
 
-func (u *customerUsecase) CreateCustomer(customerDTO *dto.CustomerDetail) error {
+func (u *customerUsecase) CreateCustomer(ctx context.Context, customerDTO *dto.CustomerDetail) error {
 	customer := &entities.Customer{
 		Name:          customerDTO.Name,
 		Phone:         customerDTO.Phone,
@@ -92,6 +94,7 @@ func (u *customerUsecase) CreateCustomer(customerDTO *dto.CustomerDetail) error
 		ONUSerial:     customerDTO.ONUSerial,
 		ONUMacAddress: customerDTO.ONUMacAddress,
 		ONUIPAddress:  customerDTO.ONUIPAddress,
+		StaticIP:      customerDTO.StaticIP,
 		Latitude:      customerDTO.Latitude,
 		Longitude:     customerDTO.Longitude,
 	}
@@ -101,7 +104,7 @@ func (u *customerUsecase) CreateCustomer(customerDTO *dto.CustomerDetail) error
 	}
 
 	if customer.PPPoEUsername != "" && customer.PPPoEPassword != "" {
-		if err := u.mikrotikService.CreateCustomerOnMikroTik(customer); err != nil {
+		if err := u.mikrotikService.CreateCustomerOnMikroTik(ctx, customer); err != nil {
 			return err
 		}
 	}
@@ -129,6 +132,7 @@ func (u *customerUsecase) UpdateCustomer(id uint, customerDTO *dto.CustomerDetai
 	customer.ONUSerial = customerDTO.ONUSerial
 	customer.ONUMacAddress = customerDTO.ONUMacAddress
 	customer.ONUIPAddress = customerDTO.ONUIPAddress
+	customer.StaticIP = customerDTO.StaticIP
 	customer.Latitude = customerDTO.Latitude
 	customer.Longitude = customerDTO.Longitude
 
@@ -147,13 +151,13 @@ func (u *customerUsecase) DeleteCustomer(id uint) error {
 	return u.customerRepo.Delete(id)
 }
 
-func (u *customerUsecase) IsolateCustomer(id uint) error {
+func (u *customerUsecase) IsolateCustomer(ctx context.Context, id uint) error {
 	customer, err := u.customerRepo.FindByID(id)
 	if err != nil {
 		return err
 	}
 
-	if err := u.mikrotikService.IsolateCustomer(customer); err != nil {
+	if err := u.mikrotikService.IsolateCustomer(ctx, customer); err != nil {
 		return err
 	}
 
@@ -164,13 +168,13 @@ func (u *customerUsecase) IsolateCustomer(id uint) error {
 	return nil
 }
 
-func (u *customerUsecase) ActivateCustomer(id uint) error {
+func (u *customerUsecase) ActivateCustomer(ctx context.Context, id uint) error {
 	customer, err := u.customerRepo.FindByID(id)
 	if err != nil {
 		return err
 	}
 
-	if err := u.mikrotikService.ActivateCustomer(customer); err != nil {
+	if err := u.mikrotikService.ActivateCustomer(ctx, customer); err != nil {
 		return err
 	}
 
@@ -181,25 +185,31 @@ func (u *customerUsecase) ActivateCustomer(id uint) error {
 	return nil
 }
 
-func (u *customerUsecase) SyncCustomer(id uint) error {
+func (u *customerUsecase) SyncCustomer(ctx context.Context, id uint) error {
 	customer, err := u.customerRepo.FindByID(id)
 	if err != nil {
 		return err
 	}
 
-	if err := u.mikrotikService.SyncCustomerToMikroTik(customer); err != nil {
+	if err := u.mikrotikService.SyncCustomerToMikroTik(ctx, customer); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (u *customerUsecase) BulkIsolate(ids []uint) error {
-	return u.mikrotikService.BulkSyncCustomers(ids)
+func (u *customerUsecase) BulkIsolate(ctx context.Context, ids []uint) error {
+	for _, id := range ids {
+		_ = u.IsolateCustomer(ctx, id)
+	}
+	return nil
 }
 
-func (u *customerUsecase) BulkActivate(ids []uint) error {
-	return u.mikrotikService.BulkSyncCustomers(ids)
+func (u *customerUsecase) BulkActivate(ctx context.Context, ids []uint) error {
+	for _, id := range ids {
+		_ = u.ActivateCustomer(ctx, id)
+	}
+	return nil
 }
 
 func (u *customerUsecase) entityToDTO(customer *entities.Customer) *dto.CustomerDetail {
@@ -237,6 +247,7 @@ func (u *customerUsecase) entityToDTO(customer *entities.Customer) *dto.Customer
 		ONUSerial:      customer.ONUSerial,
 		ONUMacAddress:  customer.ONUMacAddress,
 		ONUIPAddress:   customer.ONUIPAddress,
+		StaticIP:       customer.StaticIP,
 		Latitude:       customer.Latitude,
 		Longitude:      customer.Longitude,
 		IsolationDate:  isolationDate,
@@ -0,0 +1,388 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// How secrets that match an existing customer are handled on import.
+const (
+	ImportDuplicateSkip  = "skip"
+	ImportDuplicateMerge = "merge"
+)
+
+// Import actions proposed per secret.
+const (
+	ImportActionCreate = "create"
+	ImportActionMerge  = "merge"
+	ImportActionSkip   = "skip"
+)
+
+// PPPoEImportRequest drives both the preview and the commit of an import.
+// ProfileMap maps a secret profile to a package ID and overrides the
+// automatic match on Package.ProfileNormal/ProfileIsolir. Usernames limits
+// the commit to the rows picked from the preview.
+type PPPoEImportRequest struct {
+	ProfileMap       map[string]uint `json:"profile_map"`
+	DefaultPackageID uint            `json:"default_package_id"`
+	DuplicateMode    string          `json:"duplicate_mode"`
+	Usernames        []string        `json:"usernames"`
+}
+
+// ImportFieldChange is one field an import merge would overwrite.
+type ImportFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// PPPoEImportItem is the proposed change for one /ppp/secret entry.
+type PPPoEImportItem struct {
+	Username    string              `json:"username"`
+	Profile     string              `json:"profile"`
+	Action      string              `json:"action"`
+	Reason      string              `json:"reason,omitempty"`
+	CustomerID  *uint               `json:"customer_id,omitempty"`
+	Name        string              `json:"name"`
+	Phone       string              `json:"phone"`
+	PackageID   uint                `json:"package_id,omitempty"`
+	PackageName string              `json:"package_name,omitempty"`
+	Status      string              `json:"status"`
+	Changes     []ImportFieldChange `json:"changes,omitempty"`
+	Warnings    []string            `json:"warnings,omitempty"`
+	Error       string              `json:"error,omitempty"`
+
+	password string
+	existing *entities.Customer
+}
+
+type PPPoEImportPreview struct {
+	RouterID      uint              `json:"router_id"`
+	DuplicateMode string            `json:"duplicate_mode"`
+	Secrets       int               `json:"secrets"`
+	Create        int               `json:"create"`
+	Merge         int               `json:"merge"`
+	Skip          int               `json:"skip"`
+	Items         []PPPoEImportItem `json:"items"`
+}
+
+type PPPoEImportResult struct {
+	RouterID uint              `json:"router_id"`
+	Created  int               `json:"created"`
+	Merged   int               `json:"merged"`
+	Skipped  int               `json:"skipped"`
+	Failed   int               `json:"failed"`
+	Items    []PPPoEImportItem `json:"items"`
+}
+
+// ImportUsecase turns existing /ppp/secret entries into customers.
+type ImportUsecase struct {
+	routerRepo     repositories.RouterRepository
+	customerRepo   repositories.CustomerRepository
+	packageRepo    repositories.PackageRepository
+	mikrotikClient *mikrotik.MikroTikClient
+}
+
+func NewImportUsecase(routerRepo repositories.RouterRepository, customerRepo repositories.CustomerRepository, packageRepo repositories.PackageRepository, mikrotikClient *mikrotik.MikroTikClient) *ImportUsecase {
+	return &ImportUsecase{
+		routerRepo:     routerRepo,
+		customerRepo:   customerRepo,
+		packageRepo:    packageRepo,
+		mikrotikClient: mikrotikClient,
+	}
+}
+
+var commentPhonePattern = regexp.MustCompile(`(?:\+?62|0)8[0-9\-\s]{7,14}[0-9]`)
+
+// parseSecretComment pulls a phone number and a display name out of a
+// secret comment such as "Budi Santoso 0812-3456-7890".
+func parseSecretComment(comment string) (name, phone string) {
+	comment = strings.TrimSpace(comment)
+	if match := commentPhonePattern.FindString(comment); match != "" {
+		phone = strings.NewReplacer("-", "", " ", "", "+", "").Replace(match)
+		if strings.HasPrefix(phone, "0") {
+			phone = "62" + phone[1:]
+		}
+		comment = strings.Replace(comment, match, "", 1)
+	}
+	name = strings.Trim(strings.TrimSpace(comment), "-,;|/")
+	return strings.TrimSpace(name), phone
+}
+
+// Preview builds the import plan without writing anything.
+func (u *ImportUsecase) Preview(ctx context.Context, routerID uint, req PPPoEImportRequest) (*PPPoEImportPreview, error) {
+	if req.DuplicateMode == "" {
+		req.DuplicateMode = ImportDuplicateSkip
+	}
+	if req.DuplicateMode != ImportDuplicateSkip && req.DuplicateMode != ImportDuplicateMerge {
+		return nil, fmt.Errorf("duplicate_mode must be %q or %q", ImportDuplicateSkip, ImportDuplicateMerge)
+	}
+
+	if _, err := u.routerRepo.FindByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+
+	packages, err := u.packageRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	packageByID := make(map[uint]*entities.Package, len(packages))
+	for _, pkg := range packages {
+		packageByID[pkg.ID] = pkg
+	}
+	for profile, id := range req.ProfileMap {
+		if _, ok := packageByID[id]; !ok {
+			return nil, fmt.Errorf("package %d mapped from profile %q not found", id, profile)
+		}
+	}
+	if req.DefaultPackageID != 0 {
+		if _, ok := packageByID[req.DefaultPackageID]; !ok {
+			return nil, fmt.Errorf("default package %d not found", req.DefaultPackageID)
+		}
+	}
+
+	secrets, err := u.mikrotikClient.GetAllUsers(ctx, routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(req.Usernames))
+	for _, name := range req.Usernames {
+		selected[name] = true
+	}
+
+	preview := &PPPoEImportPreview{
+		RouterID:      routerID,
+		DuplicateMode: req.DuplicateMode,
+		Items:         []PPPoEImportItem{},
+	}
+
+	phonesTaken := make(map[string]bool)
+	for _, secret := range secrets {
+		if len(selected) > 0 && !selected[secret.Name] {
+			continue
+		}
+		preview.Secrets++
+
+		item := u.planItem(routerID, secret, req, packages, packageByID, phonesTaken)
+		switch item.Action {
+		case ImportActionCreate:
+			preview.Create++
+		case ImportActionMerge:
+			preview.Merge++
+		default:
+			preview.Skip++
+		}
+		preview.Items = append(preview.Items, item)
+	}
+
+	return preview, nil
+}
+
+// resolvePackage picks the package for a secret profile and the customer
+// status it implies.
+func resolvePackage(profile string, req PPPoEImportRequest, packages []*entities.Package, packageByID map[uint]*entities.Package) (*entities.Package, string) {
+	if id, ok := req.ProfileMap[profile]; ok {
+		pkg := packageByID[id]
+		if profile != "" && profile == pkg.ProfileIsolir {
+			return pkg, "isolated"
+		}
+		return pkg, "active"
+	}
+	for _, pkg := range packages {
+		if profile != "" && profile == pkg.ProfileNormal {
+			return pkg, "active"
+		}
+	}
+	for _, pkg := range packages {
+		if profile != "" && profile == pkg.ProfileIsolir {
+			return pkg, "isolated"
+		}
+	}
+	if req.DefaultPackageID != 0 {
+		return packageByID[req.DefaultPackageID], "active"
+	}
+	return nil, ""
+}
+
+func (u *ImportUsecase) planItem(routerID uint, secret mikrotik.PPPoEUser, req PPPoEImportRequest, packages []*entities.Package, packageByID map[uint]*entities.Package, phonesTaken map[string]bool) PPPoEImportItem {
+	item := PPPoEImportItem{
+		Username: secret.Name,
+		Profile:  secret.Profile,
+		password: secret.Password,
+	}
+
+	pkg, status := resolvePackage(secret.Profile, req, packages, packageByID)
+	if secret.Disabled {
+		status = "inactive"
+	}
+	item.Status = status
+	if pkg != nil {
+		item.PackageID = pkg.ID
+		item.PackageName = pkg.Name
+	}
+
+	name, phone := parseSecretComment(secret.Comment)
+
+	existing, err := u.customerRepo.FindByPPPoEUsername(secret.Name)
+	if err != nil {
+		existing = nil
+	}
+	if existing == nil && phone != "" {
+		if c, err := u.customerRepo.FindByPhone(phone); err == nil {
+			if c.PPPoEUsername == "" {
+				existing = c
+			} else {
+				// Same phone but a different PPPoE account: not a duplicate.
+				item.Warnings = append(item.Warnings, "phone "+phone+" already used by customer "+c.PPPoEUsername)
+				phone = ""
+			}
+		}
+	}
+
+	if existing != nil {
+		id := existing.ID
+		item.CustomerID = &id
+		item.Name = existing.Name
+		item.Phone = existing.Phone
+		if req.DuplicateMode == ImportDuplicateSkip {
+			item.Action = ImportActionSkip
+			item.Reason = "customer already exists"
+			return item
+		}
+		item.Action = ImportActionMerge
+		item.existing = existing
+		item.Changes = mergeChanges(existing, routerID, secret, pkg, status)
+		if len(item.Changes) == 0 {
+			item.Action = ImportActionSkip
+			item.Reason = "customer already up to date"
+		}
+		return item
+	}
+
+	if pkg == nil {
+		item.Action = ImportActionSkip
+		item.Reason = fmt.Sprintf("no package mapped for profile %q", secret.Profile)
+		return item
+	}
+
+	if name == "" {
+		name = secret.Name
+	}
+	if phone == "" || phonesTaken[phone] {
+		if phone != "" {
+			item.Warnings = append(item.Warnings, "phone "+phone+" used by another secret in this import")
+		}
+		phone = fmt.Sprintf("import-%d-%s", routerID, secret.Name)
+		item.Warnings = append(item.Warnings, "no phone found in secret comment; placeholder used")
+	}
+	phonesTaken[phone] = true
+
+	item.Action = ImportActionCreate
+	item.Name = name
+	item.Phone = phone
+	return item
+}
+
+// mergeChanges lists the fields a merge would overwrite on an existing
+// customer. Name and phone are never touched.
+func mergeChanges(existing *entities.Customer, routerID uint, secret mikrotik.PPPoEUser, pkg *entities.Package, status string) []ImportFieldChange {
+	var changes []ImportFieldChange
+	if existing.PPPoEUsername != secret.Name {
+		changes = append(changes, ImportFieldChange{Field: "pppoe_username", From: existing.PPPoEUsername, To: secret.Name})
+	}
+	if secret.Password != "" && existing.PPPoEPassword != secret.Password {
+		changes = append(changes, ImportFieldChange{Field: "pppoe_password", From: "***", To: "***"})
+	}
+	if existing.RouterID != routerID {
+		changes = append(changes, ImportFieldChange{Field: "router_id", From: fmt.Sprint(existing.RouterID), To: fmt.Sprint(routerID)})
+	}
+	if pkg != nil && existing.PackageID != pkg.ID {
+		changes = append(changes, ImportFieldChange{Field: "package_id", From: fmt.Sprint(existing.PackageID), To: fmt.Sprint(pkg.ID)})
+	}
+	if status != "" && existing.Status != status {
+		changes = append(changes, ImportFieldChange{Field: "status", From: existing.Status, To: status})
+	}
+	return changes
+}
+
+// Commit rebuilds the plan from the same request and applies it, so what is
+// written always matches a preview of the current router state.
+func (u *ImportUsecase) Commit(ctx context.Context, routerID uint, req PPPoEImportRequest) (*PPPoEImportResult, error) {
+	preview, err := u.Preview(ctx, routerID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PPPoEImportResult{
+		RouterID: routerID,
+		Items:    make([]PPPoEImportItem, 0, len(preview.Items)),
+	}
+
+	for _, item := range preview.Items {
+		switch item.Action {
+		case ImportActionCreate:
+			customer := &entities.Customer{
+				Name:          item.Name,
+				Phone:         item.Phone,
+				PackageID:     item.PackageID,
+				PPPoEUsername: item.Username,
+				PPPoEPassword: item.password,
+				Status:        item.Status,
+				RouterID:      routerID,
+			}
+			if err := u.customerRepo.Create(customer); err != nil {
+				item.Error = err.Error()
+				result.Failed++
+			} else {
+				id := customer.ID
+				item.CustomerID = &id
+				result.Created++
+			}
+		case ImportActionMerge:
+			customer := item.existing
+			for _, change := range item.Changes {
+				switch change.Field {
+				case "pppoe_username":
+					customer.PPPoEUsername = item.Username
+				case "pppoe_password":
+					customer.PPPoEPassword = item.password
+				case "router_id":
+					customer.RouterID = routerID
+				case "package_id":
+					customer.PackageID = item.PackageID
+				case "status":
+					customer.Status = item.Status
+				}
+			}
+			customer.Package = nil
+			if err := u.customerRepo.Update(customer); err != nil {
+				item.Error = err.Error()
+				result.Failed++
+			} else {
+				result.Merged++
+			}
+		default:
+			result.Skipped++
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	logger.Info("PPPoE secrets imported",
+		zap.Uint("router_id", routerID),
+		zap.Int("created", result.Created),
+		zap.Int("merged", result.Merged),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
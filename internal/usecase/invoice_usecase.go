@@ -8,6 +8,7 @@ import (
 
 	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/webhook"
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/whatsapp"
 	"github.com/alijayanet/gembok-backend/internal/interface/dto"
 )
@@ -24,13 +25,15 @@ type invoiceUsecase struct {
 	invoiceRepo     repositories.InvoiceRepository
 	settingRepo     repositories.SettingRepository
 	whatsappService *whatsapp.WhatsAppService
+	webhooks        *webhook.Dispatcher
 }
 
-func NewInvoiceUsecase(invoiceRepo repositories.InvoiceRepository, settingRepo repositories.SettingRepository, whatsappService *whatsapp.WhatsAppService) InvoiceUsecase {
+func NewInvoiceUsecase(invoiceRepo repositories.InvoiceRepository, settingRepo repositories.SettingRepository, whatsappService *whatsapp.WhatsAppService, webhooks *webhook.Dispatcher) InvoiceUsecase {
 	return &invoiceUsecase{
 		invoiceRepo:     invoiceRepo,
 		settingRepo:     settingRepo,
 		whatsappService: whatsappService,
+		webhooks:        webhooks,
 	}
 }
 
@@ -123,6 +126,10 @@ func (u *invoiceUsecase) CreateInvoice(invoiceDTO *dto.InvoiceDetail) error {
 		go u.whatsappService.SendInvoiceNotification(invoice)
 	}
 
+	if u.webhooks != nil {
+		u.webhooks.Publish(webhook.EventInvoiceCreated, invoice)
+	}
+
 	return nil
 }
 
@@ -131,6 +138,7 @@ func (u *invoiceUsecase) UpdateInvoice(id uint, invoiceDTO *dto.InvoiceDetail) e
 	if err != nil {
 		return fmt.Errorf("invoice not found")
 	}
+	wasPaid := invoice.Status == "paid"
 
 	if invoiceDTO.Amount > 0 {
 		invoice.Amount = invoiceDTO.Amount
@@ -167,6 +175,10 @@ func (u *invoiceUsecase) UpdateInvoice(id uint, invoiceDTO *dto.InvoiceDetail) e
 		go u.whatsappService.SendPaymentConfirmation(invoice)
 	}
 
+	if u.webhooks != nil && invoice.Status == "paid" && !wasPaid {
+		u.webhooks.Publish(webhook.EventInvoicePaid, invoice)
+	}
+
 	return nil
 }
 
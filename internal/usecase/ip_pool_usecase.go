@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+)
+
+type IPPoolRequest struct {
+	Name     string `json:"name"`
+	Ranges   string `json:"ranges" binding:"required"`
+	NextPool string `json:"next_pool"`
+	Comment  string `json:"comment"`
+}
+
+// PoolWarning is a pool at or above the utilization threshold, or a router
+// whose pools could not be checked.
+type PoolWarning struct {
+	RouterID   uint    `json:"router_id"`
+	RouterName string  `json:"router_name"`
+	Pool       string  `json:"pool,omitempty"`
+	Used       int     `json:"used,omitempty"`
+	Size       int     `json:"size,omitempty"`
+	Percent    float64 `json:"percent,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+type IPPoolUsecase struct {
+	routerRepo    repositories.RouterRepository
+	poolService   *mikrotik.IPPoolsService
+	warnThreshold float64
+}
+
+func NewIPPoolUsecase(routerRepo repositories.RouterRepository, poolService *mikrotik.IPPoolsService, warnThreshold float64) *IPPoolUsecase {
+	return &IPPoolUsecase{
+		routerRepo:    routerRepo,
+		poolService:   poolService,
+		warnThreshold: warnThreshold,
+	}
+}
+
+func (u *IPPoolUsecase) List(ctx context.Context, routerID uint) ([]mikrotik.IPPool, error) {
+	if _, err := u.routerRepo.FindByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+	return u.poolService.List(ctx, routerID)
+}
+
+func (u *IPPoolUsecase) Create(ctx context.Context, routerID uint, req IPPoolRequest) (*mikrotik.IPPool, error) {
+	if _, err := u.routerRepo.FindByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+	pool := mikrotik.IPPool{
+		Name:     req.Name,
+		Ranges:   req.Ranges,
+		NextPool: req.NextPool,
+		Comment:  req.Comment,
+	}
+	if err := u.poolService.Create(ctx, routerID, pool); err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+func (u *IPPoolUsecase) Update(ctx context.Context, routerID uint, name string, req IPPoolRequest) (*mikrotik.IPPool, error) {
+	pool := mikrotik.IPPool{
+		Name:     req.Name,
+		Ranges:   req.Ranges,
+		NextPool: req.NextPool,
+		Comment:  req.Comment,
+	}
+	if err := u.poolService.Update(ctx, routerID, name, pool); err != nil {
+		return nil, err
+	}
+	if pool.Name == "" {
+		pool.Name = name
+	}
+	return &pool, nil
+}
+
+func (u *IPPoolUsecase) Delete(ctx context.Context, routerID uint, name string) error {
+	return u.poolService.Remove(ctx, routerID, name)
+}
+
+// AssignToProfile sets pool as the remote address of a PPP profile.
+func (u *IPPoolUsecase) AssignToProfile(ctx context.Context, routerID uint, name, profile string) error {
+	if profile == "" {
+		return fmt.Errorf("profile is required")
+	}
+	return u.poolService.AssignToProfile(ctx, routerID, name, profile)
+}
+
+func (u *IPPoolUsecase) Utilization(ctx context.Context, routerID uint) ([]mikrotik.PoolUtilization, error) {
+	if _, err := u.routerRepo.FindByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+	return u.poolService.Utilization(ctx, routerID, u.warnThreshold)
+}
+
+// Warnings checks every router and returns the pools above the threshold.
+func (u *IPPoolUsecase) Warnings(ctx context.Context) ([]PoolWarning, error) {
+	routers, err := u.routerRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	perRouter := make([][]PoolWarning, len(routers))
+	var wg sync.WaitGroup
+	for i, router := range routers {
+		wg.Add(1)
+		go func(i int, router *entities.Router) {
+			defer wg.Done()
+			pools, err := u.poolService.Utilization(ctx, router.ID, u.warnThreshold)
+			if err != nil {
+				perRouter[i] = []PoolWarning{{RouterID: router.ID, RouterName: router.Name, Error: err.Error()}}
+				return
+			}
+			for _, pool := range pools {
+				if !pool.Warning {
+					continue
+				}
+				perRouter[i] = append(perRouter[i], PoolWarning{
+					RouterID:   router.ID,
+					RouterName: router.Name,
+					Pool:       pool.Name,
+					Used:       pool.Used,
+					Size:       pool.Size,
+					Percent:    pool.Percent,
+				})
+			}
+		}(i, router)
+	}
+	wg.Wait()
+
+	warnings := []PoolWarning{}
+	for _, w := range perRouter {
+		warnings = append(warnings, w...)
+	}
+	return warnings, nil
+}
+
+// Threshold returns the utilization percentage that triggers a warning.
+func (u *IPPoolUsecase) Threshold() float64 {
+	return u.warnThreshold
+}
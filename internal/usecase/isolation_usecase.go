@@ -0,0 +1,457 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/tripay"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type IsolationUsecase struct {
+	routerRepo     repositories.RouterRepository
+	customerRepo   repositories.CustomerRepository
+	invoiceRepo    repositories.InvoiceRepository
+	paymentUsecase *PaymentUsecase
+	mikrotikClient *mikrotik.MikroTikClient
+	firewall       mikrotik.IsolationFirewall
+	appName        string
+
+	// refreshMu serializes refreshes so a manual run does not race the
+	// background one.
+	refreshMu sync.Mutex
+
+	// misses remembers addresses recently found not to belong to an
+	// isolated customer, so public captive page requests cannot drive a
+	// database and router lookup each.
+	missMu sync.Mutex
+	misses map[string]time.Time
+}
+
+const (
+	// isolationMissTTL is how long an address without an isolated customer
+	// is answered from memory. Refresh forgets misses early.
+	isolationMissTTL = 30 * time.Second
+	// maxIsolationMisses bounds the memory used for misses.
+	maxIsolationMisses = 10000
+)
+
+func NewIsolationUsecase(
+	routerRepo repositories.RouterRepository,
+	customerRepo repositories.CustomerRepository,
+	invoiceRepo repositories.InvoiceRepository,
+	paymentUsecase *PaymentUsecase,
+	mikrotikClient *mikrotik.MikroTikClient,
+	firewall mikrotik.IsolationFirewall,
+	appName string,
+) *IsolationUsecase {
+	return &IsolationUsecase{
+		routerRepo:     routerRepo,
+		customerRepo:   customerRepo,
+		invoiceRepo:    invoiceRepo,
+		paymentUsecase: paymentUsecase,
+		mikrotikClient: mikrotikClient,
+		firewall:       firewall,
+		appName:        appName,
+		misses:         make(map[string]time.Time),
+	}
+}
+
+// SetupRouter installs the address-list isolation firewall rules on a
+// router. The router's isolation_mode decides whether they are used.
+func (u *IsolationUsecase) SetupRouter(ctx context.Context, routerID uint) (*entities.Router, error) {
+	router, err := u.routerRepo.FindByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+	if err := u.mikrotikClient.SetupIsolationFirewall(ctx, router.ID, u.firewall); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// CaptiveURL returns the address of the captive page as the router
+// redirects to it, or "" when no captive address is configured.
+func (u *IsolationUsecase) CaptiveURL() string {
+	if u.firewall.CaptiveAddress == "" {
+		return ""
+	}
+	return fmt.Sprintf("http://%s/isolir", net.JoinHostPort(u.firewall.CaptiveAddress, strconv.Itoa(u.firewall.CaptivePort)))
+}
+
+// IsCaptiveHost reports whether host, a request's Host header, names the
+// captive page itself rather than a site the router redirected.
+func (u *IsolationUsecase) IsCaptiveHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.EqualFold(host, u.firewall.CaptiveAddress)
+}
+
+// IsolatedCustomer returns the isolated customer browsing from ip. A
+// customer matched by a dynamic address is only returned while their own
+// session still holds it, so whoever gets the address from the pool next is
+// never shown another customer's invoices. Addresses without an isolated
+// customer are remembered for isolationMissTTL.
+func (u *IsolationUsecase) IsolatedCustomer(ctx context.Context, ip string) (*entities.Customer, error) {
+	if u.recentMiss(ip) {
+		return nil, fmt.Errorf("no isolated customer at %s", ip)
+	}
+	customers, err := u.customerRepo.FindIsolatedByIP(ip)
+	if err != nil {
+		return nil, fmt.Errorf("no isolated customer at %s", ip)
+	}
+	for _, customer := range customers {
+		if strings.TrimSuffix(customer.StaticIP, "/32") == ip {
+			return customer, nil
+		}
+		if customer.PPPoEUsername == "" {
+			continue
+		}
+		routerID, err := u.mikrotikClient.CustomerRouterID(customer)
+		if err != nil {
+			continue
+		}
+		session, err := u.mikrotikClient.GetActiveSession(ctx, routerID, customer.PPPoEUsername)
+		if err != nil {
+			logger.Warn("Failed to confirm isolated customer session",
+				zap.Uint("customer_id", customer.ID),
+				zap.String("ip", ip),
+				zap.Error(err),
+			)
+			continue
+		}
+		if session != nil && session.Address == ip {
+			return customer, nil
+		}
+	}
+	u.addMiss(ip)
+	return nil, fmt.Errorf("no isolated customer at %s", ip)
+}
+
+func (u *IsolationUsecase) recentMiss(ip string) bool {
+	u.missMu.Lock()
+	defer u.missMu.Unlock()
+	at, ok := u.misses[ip]
+	if ok && time.Since(at) >= isolationMissTTL {
+		delete(u.misses, ip)
+		return false
+	}
+	return ok
+}
+
+func (u *IsolationUsecase) addMiss(ip string) {
+	u.missMu.Lock()
+	defer u.missMu.Unlock()
+	if len(u.misses) >= maxIsolationMisses {
+		for addr, at := range u.misses {
+			if time.Since(at) >= isolationMissTTL {
+				delete(u.misses, addr)
+			}
+		}
+		if len(u.misses) >= maxIsolationMisses {
+			u.misses = make(map[string]time.Time)
+		}
+	}
+	u.misses[ip] = time.Now()
+}
+
+// forgetMisses drops the remembered misses, since customers may have been
+// isolated or connected since.
+func (u *IsolationUsecase) forgetMisses() {
+	u.missMu.Lock()
+	defer u.missMu.Unlock()
+	u.misses = make(map[string]time.Time)
+}
+
+// IsolationRefreshResult summarizes one pass of the isolation refresh.
+type IsolationRefreshResult struct {
+	Routers int `json:"routers"`
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Failed  int `json:"failed"`
+}
+
+// Refresh brings the isolation address-list of every address_list router in
+// line with who is isolated and the addresses their sessions hold now.
+// Entries of customers who went offline, reconnected with another address
+// or are no longer isolated are removed, and customers who came online are
+// added. Entries not tagged for a customer are left alone.
+func (u *IsolationUsecase) Refresh(ctx context.Context) (*IsolationRefreshResult, error) {
+	u.refreshMu.Lock()
+	defer u.refreshMu.Unlock()
+
+	routers, err := u.routerRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	result := &IsolationRefreshResult{}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, router := range routers {
+		if router.IsolationMode != entities.IsolationAddressList {
+			continue
+		}
+		result.Routers++
+		wg.Add(1)
+		go func(router *entities.Router) {
+			defer wg.Done()
+			added, removed, err := u.refreshRouter(ctx, router)
+			mu.Lock()
+			defer mu.Unlock()
+			result.Added += added
+			result.Removed += removed
+			if err != nil {
+				logger.Warn("Isolation refresh failed",
+					zap.Uint("router_id", router.ID),
+					zap.Error(err),
+				)
+				result.Failed++
+			}
+		}(router)
+	}
+	wg.Wait()
+	u.forgetMisses()
+
+	return result, nil
+}
+
+// refreshRouter syncs the isolation address-list of one router. Customers
+// without a router are isolated on the active router.
+func (u *IsolationUsecase) refreshRouter(ctx context.Context, router *entities.Router) (int, int, error) {
+	customers, err := u.customerRepo.FindByRouterID(router.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if router.IsActive {
+		unassigned, err := u.customerRepo.FindByRouterID(0)
+		if err != nil {
+			return 0, 0, err
+		}
+		customers = append(customers, unassigned...)
+	}
+	sessions, err := u.mikrotikClient.GetActiveSessions(ctx, router.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+	entries, err := u.mikrotikClient.GetAddressList(ctx, router.ID, u.firewall.AddressList)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sessionByName := make(map[string]*mikrotik.ActiveSession, len(sessions))
+	for i := range sessions {
+		sessionByName[sessions[i].Name] = &sessions[i]
+	}
+	want := make(map[string]string)
+	isolated := make(map[string]*entities.Customer)
+	for _, customer := range customers {
+		if customer.Status != "isolated" {
+			continue
+		}
+		comment := mikrotik.IsolationComment(customer)
+		want[comment] = mikrotik.IsolationAddress(customer, sessionByName[customer.PPPoEUsername])
+		isolated[comment] = customer
+	}
+
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	added, removed := 0, 0
+	current := make(map[string]bool)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Comment, mikrotik.IsolationCommentPrefix) {
+			continue
+		}
+		if address := want[entry.Comment]; address != "" && entry.Address == address && !current[entry.Comment] {
+			current[entry.Comment] = true
+			continue
+		}
+		if err := u.mikrotikClient.RemoveAddressListEntry(ctx, router.ID, entry.ID); err != nil {
+			fail(err)
+			continue
+		}
+		removed++
+	}
+
+	for comment, address := range want {
+		customer := isolated[comment]
+		if address != "" && !current[comment] {
+			if err := u.mikrotikClient.AddToAddressList(ctx, router.ID, u.firewall.AddressList, address, comment); err != nil {
+				fail(err)
+				continue
+			}
+			added++
+		}
+		if customer.IsolatedIP != address {
+			logger.Info("Isolated customer address changed",
+				zap.Uint("customer_id", customer.ID),
+				zap.String("from", customer.IsolatedIP),
+				zap.String("to", address),
+			)
+			if err := u.customerRepo.UpdateIsolatedIP(customer.ID, address); err != nil {
+				fail(err)
+			}
+		}
+	}
+
+	return added, removed, firstErr
+}
+
+// StartRefreshing runs Refresh every interval and returns a function that
+// stops the loop.
+func (u *IsolationUsecase) StartRefreshing(interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				result, err := u.Refresh(ctx)
+				if err != nil {
+					logger.Error("Isolation refresh failed", zap.Error(err))
+				} else if result.Added > 0 || result.Removed > 0 || result.Failed > 0 {
+					logger.Info("Isolation address-lists refreshed",
+						zap.Int("added", result.Added),
+						zap.Int("removed", result.Removed),
+						zap.Int("failed", result.Failed),
+					)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+var captivePageTemplate = template.Must(template.New("captive").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.AppName}} - Layanan Diisolir</title>
+<style>
+  body { font-family: Arial, sans-serif; background: #f4f4f4; margin: 0; padding: 16px; color: #222; }
+  .box { max-width: 480px; margin: 0 auto; background: #fff; border-radius: 8px; padding: 20px; box-shadow: 0 1px 4px rgba(0,0,0,.15); }
+  h2 { margin-top: 0; color: #c0392b; }
+  .invoice { border-top: 1px solid #ddd; padding: 12px 0; }
+  .amount { font-size: 20px; font-weight: bold; }
+  .error { background: #fdecea; color: #c0392b; padding: 8px; border-radius: 4px; }
+  select, button { width: 100%; padding: 10px; margin-top: 8px; font-size: 15px; }
+  button { background: #27ae60; color: #fff; border: 0; border-radius: 4px; }
+</style>
+</head>
+<body>
+<div class="box">
+  <h2>Layanan Internet Diisolir</h2>
+  {{if .Message}}<p class="error">{{.Message}}</p>{{end}}
+  {{if .Customer}}
+  <p>Halo <b>{{.Customer.Name}}</b>, koneksi internet Anda sementara diisolir karena ada tagihan yang belum dibayar.</p>
+  {{range .Invoices}}
+  <div class="invoice">
+    <div>No: {{.Number}} &middot; Periode {{.Period}}</div>
+    <div>Jatuh tempo: {{.DueDate.Format "2006-01-02"}}</div>
+    <div class="amount">Rp {{printf "%.0f" .Amount}}</div>
+    <form method="post" action="/isolir/pay">
+      <input type="hidden" name="invoice_id" value="{{.ID}}">
+      <select name="method">
+        {{range $.Channels}}{{if .Active}}<option value="{{.Code}}">{{.Name}}</option>{{end}}{{end}}
+      </select>
+      <button type="submit">Bayar Sekarang</button>
+    </form>
+  </div>
+  {{else}}
+  <p>Tidak ada tagihan terbuka. Silakan hubungi admin {{.AppName}} untuk mengaktifkan kembali layanan Anda.</p>
+  {{end}}
+  {{else}}
+  <p>Koneksi internet Anda sementara diisolir. Silakan hubungi admin {{.AppName}}.</p>
+  {{end}}
+  <p><small>Layanan aktif kembali otomatis setelah pembayaran diterima.</small></p>
+</div>
+</body>
+</html>
+`))
+
+// RenderCaptivePage renders the page shown to isolated customers: their
+// open invoices with a pay button per invoice. Visitors who cannot be
+// matched to a customer get a generic notice.
+func (u *IsolationUsecase) RenderCaptivePage(ctx context.Context, ip, message string) ([]byte, error) {
+	data := struct {
+		AppName  string
+		Message  string
+		Customer *entities.Customer
+		Invoices []*entities.Invoice
+		Channels []tripay.TripayPaymentChannel
+	}{
+		AppName: u.appName,
+		Message: message,
+	}
+
+	if customer, err := u.IsolatedCustomer(ctx, ip); err == nil {
+		data.Customer = customer
+		invoices, err := u.invoiceRepo.FindUnpaidByCustomerIDs([]uint{customer.ID})
+		if err != nil {
+			return nil, err
+		}
+		data.Invoices = invoices
+		if len(invoices) > 0 {
+			channels, err := u.paymentUsecase.GetPaymentGateways()
+			if err != nil {
+				return nil, err
+			}
+			data.Channels = channels
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := captivePageTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render captive page: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Pay starts a payment for an open invoice of the isolated customer at ip.
+// Invoices of other customers are reported as not found.
+func (u *IsolationUsecase) Pay(ctx context.Context, ip string, invoiceID uint, method string) (*CreatePaymentResponse, error) {
+	customer, err := u.IsolatedCustomer(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := u.invoiceRepo.FindByID(invoiceID)
+	if err != nil || invoice.CustomerID != customer.ID {
+		return nil, fmt.Errorf("invoice not found")
+	}
+
+	return u.paymentUsecase.CreateTransaction(CreatePaymentRequest{
+		InvoiceID:     invoice.ID,
+		PaymentMethod: method,
+	})
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLogLimit = 100
+	maxLogLimit     = 1000
+)
+
+// logTopicGroups maps the topic filters offered by the API to the RouterOS
+// topics they cover. Other filter values are matched as a single topic.
+var logTopicGroups = map[string][]string{
+	"hotspot": {"hotspot"},
+	"pppoe":   {"pppoe", "ppp"},
+	"system":  {"system"},
+	"error":   {"error", "critical"},
+}
+
+// LogQuery selects router logs. Stored reads the shipped copy in the
+// database instead of the router's live buffer; with Stored a zero RouterID
+// covers every router, otherwise it means the active router.
+type LogQuery struct {
+	RouterID uint
+	Topic    string
+	Since    time.Time
+	Until    time.Time
+	Search   string
+	Limit    int
+	Stored   bool
+}
+
+type LogUsecase struct {
+	routerRepo     repositories.RouterRepository
+	logRepo        repositories.RouterLogRepository
+	mikrotikClient *mikrotik.MikroTikClient
+}
+
+func NewLogUsecase(routerRepo repositories.RouterRepository, logRepo repositories.RouterLogRepository, mikrotikClient *mikrotik.MikroTikClient) *LogUsecase {
+	return &LogUsecase{
+		routerRepo:     routerRepo,
+		logRepo:        logRepo,
+		mikrotikClient: mikrotikClient,
+	}
+}
+
+func logTopics(filter string) []string {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return nil
+	}
+	if topics, ok := logTopicGroups[filter]; ok {
+		return topics
+	}
+	return []string{filter}
+}
+
+func hasTopic(entryTopics string, topics []string) bool {
+	for _, t := range strings.Split(entryTopics, ",") {
+		for _, want := range topics {
+			if t == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func logFingerprint(routerID uint, entry mikrotik.HotspotLog) string {
+	sum := sha1.Sum([]byte(strconv.FormatUint(uint64(routerID), 10) + "|" +
+		entry.Time.UTC().Format(time.RFC3339) + "|" + entry.Topic + "|" + entry.Message))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetLogs returns matching log entries, newest first.
+func (u *LogUsecase) GetLogs(ctx context.Context, q LogQuery) ([]mikrotik.HotspotLog, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultLogLimit
+	}
+	if q.Limit > maxLogLimit {
+		q.Limit = maxLogLimit
+	}
+	topics := logTopics(q.Topic)
+
+	if q.Stored {
+		stored, err := u.logRepo.Find(repositories.RouterLogFilter{
+			RouterID: q.RouterID,
+			Topics:   topics,
+			Since:    q.Since,
+			Until:    q.Until,
+			Search:   q.Search,
+			Limit:    q.Limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		logs := make([]mikrotik.HotspotLog, 0, len(stored))
+		for _, l := range stored {
+			logs = append(logs, mikrotik.HotspotLog{
+				RouterID: l.RouterID,
+				Time:     l.LoggedAt,
+				Topic:    l.Topics,
+				Message:  l.Message,
+			})
+		}
+		return logs, nil
+	}
+
+	routerID := q.RouterID
+	if routerID == 0 {
+		router, err := u.routerRepo.FindActive()
+		if err != nil {
+			return nil, fmt.Errorf("no active router found")
+		}
+		routerID = router.ID
+	}
+
+	entries, err := u.mikrotikClient.GetLogs(ctx, routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	search := strings.ToLower(q.Search)
+	logs := []mikrotik.HotspotLog{}
+	for i := len(entries) - 1; i >= 0 && len(logs) < q.Limit; i-- {
+		entry := entries[i]
+		if len(topics) > 0 && !hasTopic(entry.Topic, topics) {
+			continue
+		}
+		if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && entry.Time.After(q.Until) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(entry.Message), search) {
+			continue
+		}
+		logs = append(logs, entry)
+	}
+	return logs, nil
+}
+
+// Ship copies new log entries from every router into the database and
+// returns how many were stored. Routers that cannot be reached are skipped.
+func (u *LogUsecase) Ship(ctx context.Context) (int64, error) {
+	routers, err := u.routerRepo.FindAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		mu     sync.Mutex
+		stored int64
+		wg     sync.WaitGroup
+	)
+	for _, router := range routers {
+		wg.Add(1)
+		go func(router *entities.Router) {
+			defer wg.Done()
+			n, err := u.shipRouter(ctx, router.ID)
+			if err != nil {
+				logger.Warn("Router log shipping failed",
+					zap.Uint("router_id", router.ID),
+					zap.Error(err),
+				)
+				return
+			}
+			mu.Lock()
+			stored += n
+			mu.Unlock()
+		}(router)
+	}
+	wg.Wait()
+
+	return stored, nil
+}
+
+func (u *LogUsecase) shipRouter(ctx context.Context, routerID uint) (int64, error) {
+	entries, err := u.mikrotikClient.GetLogs(ctx, routerID)
+	if err != nil {
+		return 0, err
+	}
+	latest, err := u.logRepo.LatestTime(routerID)
+	if err != nil {
+		return 0, err
+	}
+
+	var logs []*entities.RouterLog
+	for _, entry := range entries {
+		// Entries from the same second as the newest stored one are resent;
+		// the fingerprint drops the ones already stored.
+		if entry.Time.IsZero() || entry.Time.Before(latest) {
+			continue
+		}
+		logs = append(logs, &entities.RouterLog{
+			RouterID:    routerID,
+			LoggedAt:    entry.Time,
+			Topics:      entry.Topic,
+			Message:     entry.Message,
+			Fingerprint: logFingerprint(routerID, entry),
+		})
+	}
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].LoggedAt.Before(logs[j].LoggedAt) })
+
+	return u.logRepo.CreateMany(logs)
+}
+
+// StartShipping ships logs every interval and prunes stored logs older than
+// retention. It returns a function that stops the loop.
+func (u *LogUsecase) StartShipping(interval, retention time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			stored, err := u.Ship(ctx)
+			if err != nil {
+				logger.Error("Router log shipping failed", zap.Error(err))
+			} else if stored > 0 {
+				logger.Info("Router logs shipped", zap.Int64("stored", stored))
+			}
+
+			if retention > 0 {
+				if _, err := u.logRepo.DeleteBefore(time.Now().Add(-retention)); err != nil {
+					logger.Error("Failed to prune router logs", zap.Error(err))
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,354 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Migration outcomes reported per customer.
+const (
+	MigrationPlanned  = "planned"
+	MigrationMigrated = "migrated"
+	MigrationFailed   = "failed"
+	MigrationSkipped  = "skipped"
+)
+
+// MigrationRequest moves customers to another router. With DryRun every
+// check runs but nothing is changed.
+type MigrationRequest struct {
+	CustomerIDs []uint `json:"customer_ids"`
+	DryRun      bool   `json:"dry_run"`
+}
+
+// MigrationResult is the outcome for one customer. RolledBack is set when a
+// step failed after the router was changed and the change was undone.
+// Warnings are follow-up steps that failed after the move was committed.
+type MigrationResult struct {
+	CustomerID   uint     `json:"customer_id"`
+	Username     string   `json:"username,omitempty"`
+	FromRouterID uint     `json:"from_router_id,omitempty"`
+	Profile      string   `json:"profile,omitempty"`
+	Status       string   `json:"status"`
+	Error        string   `json:"error,omitempty"`
+	RolledBack   bool     `json:"rolled_back,omitempty"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+type MigrationResponse struct {
+	RouterID uint              `json:"router_id"`
+	DryRun   bool              `json:"dry_run"`
+	Migrated int               `json:"migrated"`
+	Failed   int               `json:"failed"`
+	Skipped  int               `json:"skipped"`
+	Results  []MigrationResult `json:"results"`
+}
+
+// migrationPlan is what moving one customer takes: the router it is served
+// by, the secret to create on the target and the one to remove from the
+// source, nil when the source has none.
+type migrationPlan struct {
+	customer *entities.Customer
+	sourceID uint
+	source   *mikrotik.PPPoEUser
+	secret   mikrotik.PPPoEUser
+}
+
+// MigrationUsecase moves customers and their PPPoE secrets between routers,
+// e.g. when a POP is split.
+type MigrationUsecase struct {
+	routerRepo     repositories.RouterRepository
+	customerRepo   repositories.CustomerRepository
+	mikrotikClient *mikrotik.MikroTikClient
+	queueService   *mikrotik.QueueService
+	isolationList  string
+}
+
+func NewMigrationUsecase(routerRepo repositories.RouterRepository, customerRepo repositories.CustomerRepository, mikrotikClient *mikrotik.MikroTikClient, queueService *mikrotik.QueueService, isolationList string) *MigrationUsecase {
+	return &MigrationUsecase{
+		routerRepo:     routerRepo,
+		customerRepo:   customerRepo,
+		mikrotikClient: mikrotikClient,
+		queueService:   queueService,
+		isolationList:  isolationList,
+	}
+}
+
+// Migrate moves the requested customers to routerID one at a time. Each
+// customer's secret is created on the target and read back before it is
+// removed from the source and the customer is updated; when a step fails
+// the earlier ones are undone and the customer stays where it was.
+func (u *MigrationUsecase) Migrate(ctx context.Context, routerID uint, req MigrationRequest) (*MigrationResponse, error) {
+	if len(req.CustomerIDs) == 0 {
+		return nil, fmt.Errorf("select customers to migrate")
+	}
+	target, err := u.routerRepo.FindByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+	profiles, err := u.mikrotikClient.GetAllProfiles(ctx, routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles of the target router: %w", err)
+	}
+	targetProfiles := make(map[string]bool, len(profiles))
+	for _, p := range profiles {
+		targetProfiles[p.Name] = true
+	}
+
+	resp := &MigrationResponse{
+		RouterID: routerID,
+		DryRun:   req.DryRun,
+		Results:  []MigrationResult{},
+	}
+	seen := make(map[uint]bool, len(req.CustomerIDs))
+	for _, id := range req.CustomerIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := MigrationResult{CustomerID: id}
+		plan, err := u.plan(ctx, target, targetProfiles, id, &result)
+		switch {
+		case result.Status == MigrationSkipped:
+		case err != nil:
+			result.Status = MigrationFailed
+			result.Error = err.Error()
+		case req.DryRun:
+			result.Status = MigrationPlanned
+		default:
+			u.apply(ctx, target, plan, &result)
+		}
+
+		switch result.Status {
+		case MigrationMigrated:
+			resp.Migrated++
+		case MigrationFailed:
+			resp.Failed++
+		case MigrationSkipped:
+			resp.Skipped++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	if !req.DryRun {
+		logger.Info("Customer migration applied",
+			zap.Uint("router_id", routerID),
+			zap.Int("migrated", resp.Migrated),
+			zap.Int("failed", resp.Failed),
+			zap.Int("skipped", resp.Skipped),
+		)
+	}
+
+	return resp, nil
+}
+
+// plan checks that a customer can be moved to target without changing
+// anything. Customers that need no move are marked skipped on result.
+func (u *MigrationUsecase) plan(ctx context.Context, target *entities.Router, targetProfiles map[string]bool, customerID uint, result *MigrationResult) (*migrationPlan, error) {
+	customer, err := u.customerRepo.FindByID(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("customer not found")
+	}
+	result.Username = customer.PPPoEUsername
+
+	skip := func(reason string) (*migrationPlan, error) {
+		result.Status = MigrationSkipped
+		result.Error = reason
+		return nil, nil
+	}
+	if customer.PPPoEUsername == "" {
+		return skip("customer has no PPPoE username")
+	}
+	// Customers without a router are served by the active router.
+	sourceID, err := u.mikrotikClient.CustomerRouterID(customer)
+	if err != nil {
+		return nil, err
+	}
+	result.FromRouterID = sourceID
+	if sourceID == target.ID {
+		return skip("customer is already on this router")
+	}
+
+	source, err := u.mikrotikClient.GetUser(ctx, sourceID, customer.PPPoEUsername)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the secret on the source router: %w", err)
+	}
+	existing, err := u.mikrotikClient.GetUser(ctx, target.ID, customer.PPPoEUsername)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the target router: %w", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("a secret named %s already exists on the target router", customer.PPPoEUsername)
+	}
+
+	// The secret keeps its password, caller ID, address and comment; the
+	// profile and disabled flag follow the customer on the new router.
+	secret := mikrotik.PPPoEUser{Name: customer.PPPoEUsername, Service: "pppoe"}
+	if source != nil {
+		secret = *source
+	}
+	if secret.Password == "" {
+		if customer.PPPoEPassword == "" || isHashedPassword(customer.PPPoEPassword) {
+			return nil, fmt.Errorf("the PPPoE password cannot be read from the source router or the database")
+		}
+		secret.Password = customer.PPPoEPassword
+	}
+	secret.Profile = expectedProfile(customer, target.IsolationMode)
+	if secret.Profile == "" && source != nil {
+		secret.Profile = source.Profile
+	}
+	if secret.Profile == "" {
+		secret.Profile = "default"
+	}
+	if !targetProfiles[secret.Profile] {
+		return nil, fmt.Errorf("profile %s does not exist on the target router", secret.Profile)
+	}
+	secret.Disabled = expectedDisabled(customer)
+
+	result.Profile = secret.Profile
+	if source == nil {
+		result.Warnings = append(result.Warnings, "secret was missing on the source router; it is created from the database")
+	}
+	return &migrationPlan{customer: customer, sourceID: sourceID, source: source, secret: secret}, nil
+}
+
+// apply moves one planned customer and records the outcome on result.
+func (u *MigrationUsecase) apply(ctx context.Context, target *entities.Router, plan *migrationPlan, result *MigrationResult) {
+	customer := plan.customer
+	sourceID := plan.sourceID
+	username := customer.PPPoEUsername
+
+	fail := func(err error, undo ...func() error) {
+		result.Status = MigrationFailed
+		result.Error = err.Error()
+		var undoErrs []string
+		for _, step := range undo {
+			if err := step(); err != nil {
+				undoErrs = append(undoErrs, err.Error())
+			}
+		}
+		if len(undo) > 0 {
+			if len(undoErrs) == 0 {
+				result.RolledBack = true
+			} else {
+				result.Error += "; rollback failed: " + strings.Join(undoErrs, "; ")
+			}
+		}
+		logger.Warn("Customer migration failed",
+			zap.Uint("customer_id", customer.ID),
+			zap.Uint("from_router_id", sourceID),
+			zap.Uint("to_router_id", target.ID),
+			zap.String("error", result.Error),
+			zap.Bool("rolled_back", result.RolledBack),
+		)
+	}
+	removeFromTarget := func() error {
+		return u.mikrotikClient.RemoveUser(ctx, target.ID, username)
+	}
+	restoreSource := func() error {
+		if plan.source == nil {
+			return nil
+		}
+		return u.mikrotikClient.AddSecret(ctx, sourceID, *plan.source)
+	}
+
+	if err := u.mikrotikClient.AddSecret(ctx, target.ID, plan.secret); err != nil {
+		fail(fmt.Errorf("failed to create the secret on the target router: %w", err))
+		return
+	}
+	if err := u.verify(ctx, target.ID, plan.secret); err != nil {
+		fail(err, removeFromTarget)
+		return
+	}
+	if plan.source != nil {
+		if err := u.mikrotikClient.RemoveUser(ctx, sourceID, username); err != nil {
+			fail(fmt.Errorf("failed to remove the secret from the source router: %w", err), removeFromTarget)
+			return
+		}
+	}
+
+	routerID, isolatedIP := customer.RouterID, customer.IsolatedIP
+	customer.RouterID = target.ID
+	customer.IsolatedIP = ""
+	if err := u.customerRepo.Update(customer); err != nil {
+		customer.RouterID = routerID
+		customer.IsolatedIP = isolatedIP
+		fail(fmt.Errorf("failed to update the customer: %w", err), restoreSource, removeFromTarget)
+		return
+	}
+
+	result.Status = MigrationMigrated
+	logger.Info("Customer migrated",
+		zap.Uint("customer_id", customer.ID),
+		zap.Uint("from_router_id", sourceID),
+		zap.Uint("to_router_id", target.ID),
+		zap.String("username", username),
+	)
+
+	// The move is committed; what is left only tidies the source router
+	// and is reported rather than rolled back.
+	warn := func(format string, args ...interface{}) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(format, args...))
+	}
+	if session, err := u.mikrotikClient.GetActiveSession(ctx, sourceID, username); err != nil {
+		warn("failed to look up the session on the source router: %v", err)
+	} else if session != nil {
+		if err := u.mikrotikClient.DisconnectUser(ctx, sourceID, username); err != nil {
+			warn("failed to disconnect the session on the source router: %v", err)
+		}
+	}
+	if isolatedIP != "" {
+		if _, err := u.mikrotikClient.RemoveFromAddressList(ctx, sourceID, u.isolationList, mikrotik.IsolationComment(customer)); err != nil {
+			warn("failed to remove the isolation address-list entry from the source router: %v", err)
+		}
+	}
+	u.moveQueue(ctx, customer, sourceID, target.ID, warn)
+}
+
+// verify reads a created secret back and compares it with what was asked
+// for. The password is only compared when the API user may read it.
+func (u *MigrationUsecase) verify(ctx context.Context, routerID uint, want mikrotik.PPPoEUser) error {
+	got, err := u.mikrotikClient.GetUser(ctx, routerID, want.Name)
+	if err != nil {
+		return fmt.Errorf("failed to verify the secret on the target router: %w", err)
+	}
+	switch {
+	case got == nil:
+		return fmt.Errorf("secret was not found on the target router after creating it")
+	case got.Profile != want.Profile:
+		return fmt.Errorf("secret on the target router has profile %s, expected %s", got.Profile, want.Profile)
+	case got.Disabled != want.Disabled:
+		return fmt.Errorf("secret on the target router has disabled=%s, expected %s", yesNo(got.Disabled), yesNo(want.Disabled))
+	case got.Password != "" && got.Password != want.Password:
+		return fmt.Errorf("secret on the target router has a different password")
+	}
+	return nil
+}
+
+// moveQueue copies the customer's own simple queue, if any, to the target
+// router and removes it from the source.
+func (u *MigrationUsecase) moveQueue(ctx context.Context, customer *entities.Customer, sourceID, targetID uint, warn func(string, ...interface{})) {
+	name := CustomerQueueName(customer)
+	queue, err := u.queueService.Get(ctx, sourceID, name)
+	if err != nil {
+		warn("failed to look up the queue on the source router: %v", err)
+		return
+	}
+	if queue == nil || queue.Dynamic {
+		return
+	}
+	if err := u.queueService.Create(ctx, targetID, *queue); err != nil {
+		warn("failed to create queue %s on the target router: %v", name, err)
+		return
+	}
+	if err := u.queueService.Remove(ctx, sourceID, name); err != nil {
+		warn("failed to remove queue %s from the source router: %v", name, err)
+	}
+}
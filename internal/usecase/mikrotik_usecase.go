@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
@@ -8,30 +9,30 @@ import (
 )
 
 type MikroTikUsecase interface {
-	GetRouters() ([]*mikrotik.RouterStatus, error)
+	GetRouters(ctx context.Context) ([]*mikrotik.RouterStatus, error)
 	GetRouter(id uint) (*dto.RouterDetail, error)
 	CreateRouter(router *dto.RouterCreate) error
 	UpdateRouter(id uint, router *dto.RouterUpdate) error
 	DeleteRouter(id uint) error
-	TestRouterConnection(id uint) (*dto.ConnectionTestResult, error)
+	TestRouterConnection(ctx context.Context, id uint) (*dto.ConnectionTestResult, error)
 	ActivateRouter(id uint) error
 	GetRouterStatus(id uint) (*dto.RouterStatus, error)
-	GetAllRoutersStatus() ([]*dto.RouterStatus, error)
+	GetAllRoutersStatus(ctx context.Context) ([]*dto.RouterStatus, error)
 
-	GetPPPUsers(routerID uint) (*dto.PPPUsersResponse, error)
-	GetActiveSessions(routerID uint) (*dto.ActiveSessionsResponse, error)
-	GetPPPProfiles(routerID uint) (*dto.ProfilesResponse, error)
-	AddPPPUser(req *dto.AddPPPUserRequest) error
-	RemovePPPUser(username string, routerID uint) error
-	UpdatePPPUser(username string, routerID uint, params map[string]interface{}) error
-	DisconnectUser(username string, routerID uint) error
+	GetPPPUsers(ctx context.Context, routerID uint) (*dto.PPPUsersResponse, error)
+	GetActiveSessions(ctx context.Context, routerID uint) (*dto.ActiveSessionsResponse, error)
+	GetPPPProfiles(ctx context.Context, routerID uint) (*dto.ProfilesResponse, error)
+	AddPPPUser(ctx context.Context, req *dto.AddPPPUserRequest) error
+	RemovePPPUser(ctx context.Context, username string, routerID uint) error
+	UpdatePPPUser(ctx context.Context, username string, routerID uint, params map[string]interface{}) error
+	DisconnectUser(ctx context.Context, username string, routerID uint) error
 
-	IsolateCustomer(id uint) error
-	ActivateCustomer(id uint) error
-	BulkIsolate(ids []uint) error
-	BulkActivate(ids []uint) error
-	SyncCustomer(id uint) error
-	SyncAllCustomers() error
+	IsolateCustomer(ctx context.Context, id uint) error
+	ActivateCustomer(ctx context.Context, id uint) error
+	BulkIsolate(ctx context.Context, ids []uint) error
+	BulkActivate(ctx context.Context, ids []uint) error
+	SyncCustomer(ctx context.Context, id uint) error
+	SyncAllCustomers(ctx context.Context) error
 }
 
 type mikrotikUsecase struct {
@@ -44,8 +45,8 @@ func NewMikroTikUsecase(mikrotikService *mikrotik.MikroTikService) MikroTikUseca
 	}
 }
 
-func (u *mikrotikUsecase) GetRouters() ([]*mikrotik.RouterStatus, error) {
-	statuses, err := u.mikrotikService.GetAllRoutersStatus()
+func (u *mikrotikUsecase) GetRouters(ctx context.Context) ([]*mikrotik.RouterStatus, error) {
+	statuses, err := u.mikrotikService.GetAllRoutersStatus(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -74,8 +75,8 @@ func (u *mikrotikUsecase) DeleteRouter(id uint) error {
 	return nil
 }
 
-func (u *mikrotikUsecase) TestRouterConnection(id uint) (*dto.ConnectionTestResult, error) {
-	err := u.mikrotikService.TestConnection(id)
+func (u *mikrotikUsecase) TestRouterConnection(ctx context.Context, id uint) (*dto.ConnectionTestResult, error) {
+	err := u.mikrotikService.TestConnection(ctx, id)
 	if err != nil {
 		return &dto.ConnectionTestResult{
 			Success: false,
@@ -96,33 +97,22 @@ func (u *mikrotikUsecase) GetRouterStatus(id uint) (*dto.RouterStatus, error) {
 	return nil, nil
 }
 
-func (u *mikrotikUsecase) GetAllRoutersStatus() ([]*dto.RouterStatus, error) {
-	statuses, err := u.mikrotikService.GetAllRoutersStatus()
+func (u *mikrotikUsecase) GetAllRoutersStatus(ctx context.Context) ([]*dto.RouterStatus, error) {
+	statuses, err := u.mikrotikService.GetAllRoutersStatus(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	result := make([]*dto.RouterStatus, len(statuses))
 	for i, status := range statuses {
-		result[i] = &dto.RouterStatus{
-			ID:          status.RouterID,
-			Name:        status.Name,
-			Host:        status.Host,
-			Status:      status.Status,
-			LastCheck:   status.LastCheck.Format("2006-01-02 15:04:05"),
-			ActiveUsers: status.ActiveUsers,
-			CPU:         status.CPU,
-			Memory:      status.Memory,
-			Uptime:      status.Uptime,
-			Error:       status.Error,
-		}
+		result[i] = toRouterStatusDTO(&status)
 	}
 
 	return result, nil
 }
 
-func (u *mikrotikUsecase) GetPPPUsers(routerID uint) (*dto.PPPUsersResponse, error) {
-	users, err := u.mikrotikService.GetPPPUsersByRouter(routerID)
+func (u *mikrotikUsecase) GetPPPUsers(ctx context.Context, routerID uint) (*dto.PPPUsersResponse, error) {
+	users, err := u.mikrotikService.GetPPPUsersByRouter(ctx, routerID)
 	if err != nil {
 		return nil, err
 	}
@@ -145,8 +135,8 @@ func (u *mikrotikUsecase) GetPPPUsers(routerID uint) (*dto.PPPUsersResponse, err
 	}, nil
 }
 
-func (u *mikrotikUsecase) GetActiveSessions(routerID uint) (*dto.ActiveSessionsResponse, error) {
-	sessions, err := u.mikrotikService.GetActiveSessionsByRouter(routerID)
+func (u *mikrotikUsecase) GetActiveSessions(ctx context.Context, routerID uint) (*dto.ActiveSessionsResponse, error) {
+	sessions, err := u.mikrotikService.GetActiveSessionsByRouter(ctx, routerID)
 	if err != nil {
 		return nil, err
 	}
@@ -170,8 +160,8 @@ func (u *mikrotikUsecase) GetActiveSessions(routerID uint) (*dto.ActiveSessionsR
 	}, nil
 }
 
-func (u *mikrotikUsecase) GetPPPProfiles(routerID uint) (*dto.ProfilesResponse, error) {
-	profiles, err := u.mikrotikService.GetPPPProfilesByRouter(routerID)
+func (u *mikrotikUsecase) GetPPPProfiles(ctx context.Context, routerID uint) (*dto.ProfilesResponse, error) {
+	profiles, err := u.mikrotikService.GetPPPProfilesByRouter(ctx, routerID)
 	if err != nil {
 		return nil, err
 	}
@@ -192,64 +182,64 @@ func (u *mikrotikUsecase) GetPPPProfiles(routerID uint) (*dto.ProfilesResponse,
 	}, nil
 }
 
-func (u *mikrotikUsecase) AddPPPUser(req *dto.AddPPPUserRequest) error {
-	return u.mikrotikService.AddPPPUser(req.Username, req.Password, req.Profile, req.RouterID)
+func (u *mikrotikUsecase) AddPPPUser(ctx context.Context, req *dto.AddPPPUserRequest) error {
+	return u.mikrotikService.AddPPPUser(ctx, req.Username, req.Password, req.Profile, req.RouterID)
 }
 
-func (u *mikrotikUsecase) RemovePPPUser(username string, routerID uint) error {
-	return u.mikrotikService.RemovePPPUser(username, routerID)
+func (u *mikrotikUsecase) RemovePPPUser(ctx context.Context, username string, routerID uint) error {
+	return u.mikrotikService.RemovePPPUser(ctx, username, routerID)
 }
 
-func (u *mikrotikUsecase) UpdatePPPUser(username string, routerID uint, params map[string]interface{}) error {
-	return u.mikrotikService.UpdatePPPUser(username, routerID, params)
+func (u *mikrotikUsecase) UpdatePPPUser(ctx context.Context, username string, routerID uint, params map[string]interface{}) error {
+	return u.mikrotikService.UpdatePPPUser(ctx, username, routerID, params)
 }
 
-func (u *mikrotikUsecase) DisconnectUser(username string, routerID uint) error {
-	return u.mikrotikService.DisconnectUser(username, routerID)
+func (u *mikrotikUsecase) DisconnectUser(ctx context.Context, username string, routerID uint) error {
+	return u.mikrotikService.DisconnectUser(ctx, username, routerID)
 }
 
-func (u *mikrotikUsecase) IsolateCustomer(id uint) error {
+func (u *mikrotikUsecase) IsolateCustomer(ctx context.Context, id uint) error {
 	customer, err := u.mikrotikService.GetCustomerByID(id)
 	if err != nil {
 		return fmt.Errorf("customer not found: %w", err)
 	}
-	return u.mikrotikService.IsolateCustomer(customer)
+	return u.mikrotikService.IsolateCustomer(ctx, customer)
 }
 
-func (u *mikrotikUsecase) ActivateCustomer(id uint) error {
+func (u *mikrotikUsecase) ActivateCustomer(ctx context.Context, id uint) error {
 	customer, err := u.mikrotikService.GetCustomerByID(id)
 	if err != nil {
 		return fmt.Errorf("customer not found: %w", err)
 	}
-	return u.mikrotikService.ActivateCustomer(customer)
+	return u.mikrotikService.ActivateCustomer(ctx, customer)
 }
 
-func (u *mikrotikUsecase) BulkIsolate(ids []uint) error {
+func (u *mikrotikUsecase) BulkIsolate(ctx context.Context, ids []uint) error {
 	for _, id := range ids {
 		if customer, err := u.mikrotikService.GetCustomerByID(id); err == nil {
-			_ = u.mikrotikService.IsolateCustomer(customer)
+			_ = u.mikrotikService.IsolateCustomer(ctx, customer)
 		}
 	}
 	return nil
 }
 
-func (u *mikrotikUsecase) BulkActivate(ids []uint) error {
+func (u *mikrotikUsecase) BulkActivate(ctx context.Context, ids []uint) error {
 	for _, id := range ids {
 		if customer, err := u.mikrotikService.GetCustomerByID(id); err == nil {
-			_ = u.mikrotikService.ActivateCustomer(customer)
+			_ = u.mikrotikService.ActivateCustomer(ctx, customer)
 		}
 	}
 	return nil
 }
 
-func (u *mikrotikUsecase) SyncCustomer(id uint) error {
+func (u *mikrotikUsecase) SyncCustomer(ctx context.Context, id uint) error {
 	customer, err := u.mikrotikService.GetCustomerByID(id)
 	if err != nil {
 		return fmt.Errorf("customer not found: %w", err)
 	}
-	return u.mikrotikService.SyncCustomerToMikroTik(customer)
+	return u.mikrotikService.SyncCustomerToMikroTik(ctx, customer)
 }
 
-func (u *mikrotikUsecase) SyncAllCustomers() error {
-	return u.mikrotikService.SyncAllCustomers()
+func (u *mikrotikUsecase) SyncAllCustomers(ctx context.Context) error {
+	return u.mikrotikService.SyncAllCustomers(ctx)
 }
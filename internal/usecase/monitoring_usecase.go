@@ -0,0 +1,422 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/whatsapp"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxMetricPoints bounds the points of a metrics series when no step
+	// is given.
+	maxMetricPoints = 720
+
+	metricPruneInterval = time.Hour
+)
+
+// AlertRules are the thresholds router alerts fire on. A zero threshold or
+// duration disables its rule.
+type AlertRules struct {
+	// CPUThreshold fires when the CPU load stays above it for CPUDuration.
+	CPUThreshold float64
+	CPUDuration  time.Duration
+	// MemoryThreshold fires when memory use, in percent, stays above it
+	// for MemoryDuration.
+	MemoryThreshold int
+	MemoryDuration  time.Duration
+	// UnreachableAfter fires when a router cannot be polled for this long.
+	UnreachableAfter time.Duration
+	// Reboot fires when a router's uptime goes backwards.
+	Reboot bool
+}
+
+// MetricPoint aggregates the samples of one step of a metrics series. CPU
+// and Memory are averages over the reachable samples.
+type MetricPoint struct {
+	Time          time.Time `json:"time"`
+	CPU           float64   `json:"cpu"`
+	CPUMax        float64   `json:"cpu_max"`
+	Memory        float64   `json:"memory"`
+	MemoryMax     int       `json:"memory_max"`
+	ActiveUsers   int       `json:"active_users"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+	Samples       int       `json:"samples"`
+	Unreachable   int       `json:"unreachable"`
+}
+
+// MetricHistory is a router's resource time series. Availability is the
+// percentage of polls that reached the router.
+type MetricHistory struct {
+	RouterID     uint          `json:"router_id"`
+	From         time.Time     `json:"from"`
+	To           time.Time     `json:"to"`
+	Step         string        `json:"step"`
+	Points       []MetricPoint `json:"points"`
+	Availability float64       `json:"availability"`
+}
+
+type MonitoringUsecase struct {
+	routerRepo      repositories.RouterRepository
+	metricRepo      repositories.RouterMetricRepository
+	alertRepo       repositories.RouterAlertRepository
+	mikrotikClient  *mikrotik.MikroTikClient
+	whatsappService *whatsapp.WhatsAppService
+	rules           AlertRules
+	retention       time.Duration
+
+	// mu serializes polls so a manual poll cannot open the same alert as
+	// a scheduled one.
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+// NewMonitoringUsecase keeps metrics for retention; zero keeps them forever.
+func NewMonitoringUsecase(routerRepo repositories.RouterRepository, metricRepo repositories.RouterMetricRepository, alertRepo repositories.RouterAlertRepository, mikrotikClient *mikrotik.MikroTikClient, whatsappService *whatsapp.WhatsAppService, rules AlertRules, retention time.Duration) *MonitoringUsecase {
+	return &MonitoringUsecase{
+		routerRepo:      routerRepo,
+		metricRepo:      metricRepo,
+		alertRepo:       alertRepo,
+		mikrotikClient:  mikrotikClient,
+		whatsappService: whatsappService,
+		rules:           rules,
+		retention:       retention,
+	}
+}
+
+// Poll samples the resources of every router, stores them and evaluates
+// the alert rules. It returns how many routers were reached.
+func (u *MonitoringUsecase) Poll(ctx context.Context) (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	routers, err := u.routerRepo.FindAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		mu      sync.Mutex
+		reached int
+		wg      sync.WaitGroup
+	)
+	for _, router := range routers {
+		wg.Add(1)
+		go func(router *entities.Router) {
+			defer wg.Done()
+			ok, err := u.pollRouter(ctx, router)
+			if err != nil {
+				logger.Warn("Router metrics poll failed",
+					zap.Uint("router_id", router.ID),
+					zap.Error(err),
+				)
+				return
+			}
+			if ok {
+				mu.Lock()
+				reached++
+				mu.Unlock()
+			}
+		}(router)
+	}
+	wg.Wait()
+
+	if u.retention > 0 && time.Since(u.lastPrune) >= metricPruneInterval {
+		if n, err := u.metricRepo.DeleteBefore(time.Now().Add(-u.retention)); err != nil {
+			logger.Error("Failed to prune router metrics", zap.Error(err))
+		} else {
+			u.lastPrune = time.Now()
+			if n > 0 {
+				logger.Info("Pruned router metrics", zap.Int64("deleted", n))
+			}
+		}
+	}
+
+	return reached, nil
+}
+
+func (u *MonitoringUsecase) pollRouter(ctx context.Context, router *entities.Router) (bool, error) {
+	status, err := u.mikrotikClient.GetRouterStatus(ctx, router.ID)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	metric := &entities.RouterMetric{
+		RouterID:  router.ID,
+		Reachable: status.Status == "connected",
+		SampledAt: now,
+	}
+	if metric.Reachable {
+		metric.CPU = status.CPU
+		metric.Memory = status.Memory
+		metric.ActiveUsers = status.ActiveUsers
+		if uptime, err := mikrotik.ParseUptime(status.Uptime); err == nil {
+			metric.UptimeSeconds = int64(uptime / time.Second)
+		}
+	}
+
+	// The previous sample has to be read before this one is stored.
+	previous, _ := u.metricRepo.FindLatest(router.ID)
+	if err := u.metricRepo.Create(metric); err != nil {
+		return metric.Reachable, err
+	}
+
+	open, err := u.alertRepo.FindOpen(router.ID)
+	if err != nil {
+		return metric.Reachable, err
+	}
+
+	unreachableMessage := "Router unreachable"
+	if status.Error != "" {
+		unreachableMessage += ": " + status.Error
+	}
+	u.evaluate(router, open, entities.AlertRuleUnreachable, u.rules.UnreachableAfter > 0,
+		!metric.Reachable, u.rules.UnreachableAfter, 0, unreachableMessage, now)
+
+	// Resource readings are unknown while the router is unreachable, so
+	// their alerts neither advance nor recover until it is back.
+	if !metric.Reachable {
+		return false, nil
+	}
+
+	u.evaluate(router, open, entities.AlertRuleCPU, u.rules.CPUThreshold > 0 && u.rules.CPUDuration > 0,
+		metric.CPU > u.rules.CPUThreshold, u.rules.CPUDuration, metric.CPU,
+		fmt.Sprintf("CPU load %.0f%% above %.0f%%", metric.CPU, u.rules.CPUThreshold), now)
+	u.evaluate(router, open, entities.AlertRuleMemory, u.rules.MemoryThreshold > 0 && u.rules.MemoryDuration > 0,
+		metric.Memory > u.rules.MemoryThreshold, u.rules.MemoryDuration, float64(metric.Memory),
+		fmt.Sprintf("Memory use %d%% above %d%%", metric.Memory, u.rules.MemoryThreshold), now)
+
+	if u.rules.Reboot && previous != nil && metric.UptimeSeconds > 0 && metric.UptimeSeconds < previous.UptimeSeconds {
+		u.reboot(router, metric, now)
+	}
+
+	return true, nil
+}
+
+// evaluate moves the open alert of a rule along. An alert is opened as
+// pending when the condition first holds and fires, notifying the admins
+// once, when it has held for hold. When the condition clears a pending
+// alert is dropped and a fired one is resolved with a recovery notice.
+func (u *MonitoringUsecase) evaluate(router *entities.Router, open map[string]*entities.RouterAlert, rule string, enabled, active bool, hold time.Duration, value float64, message string, now time.Time) {
+	alert := open[rule]
+	if !enabled {
+		active = false
+	}
+
+	if !active {
+		if alert == nil {
+			return
+		}
+		if alert.FiredAt == nil {
+			if err := u.alertRepo.Delete(alert.ID); err != nil {
+				logger.Error("Failed to drop pending router alert", zap.Uint("alert_id", alert.ID), zap.Error(err))
+			}
+			return
+		}
+		alert.ResolvedAt = &now
+		if err := u.alertRepo.Update(alert); err != nil {
+			logger.Error("Failed to resolve router alert", zap.Uint("alert_id", alert.ID), zap.Error(err))
+			return
+		}
+		logger.Info("Router alert resolved",
+			zap.Uint("router_id", router.ID),
+			zap.String("rule", rule),
+		)
+		u.notify(router, alert)
+		return
+	}
+
+	if alert == nil {
+		alert = &entities.RouterAlert{
+			RouterID:  router.ID,
+			Rule:      rule,
+			Message:   message,
+			Value:     value,
+			StartedAt: now,
+		}
+		if err := u.alertRepo.Create(alert); err != nil {
+			logger.Error("Failed to open router alert", zap.Uint("router_id", router.ID), zap.Error(err))
+			return
+		}
+	}
+	if alert.FiredAt != nil || now.Sub(alert.StartedAt) < hold {
+		return
+	}
+
+	alert.Message = message
+	alert.Value = value
+	alert.FiredAt = &now
+	if err := u.alertRepo.Update(alert); err != nil {
+		logger.Error("Failed to fire router alert", zap.Uint("alert_id", alert.ID), zap.Error(err))
+		return
+	}
+	logger.Warn("Router alert fired",
+		zap.Uint("router_id", router.ID),
+		zap.String("rule", rule),
+		zap.String("message", message),
+	)
+	u.notify(router, alert)
+}
+
+// reboot records a reboot as an alert that fires and resolves at once.
+func (u *MonitoringUsecase) reboot(router *entities.Router, metric *entities.RouterMetric, now time.Time) {
+	uptime := time.Duration(metric.UptimeSeconds) * time.Second
+	alert := &entities.RouterAlert{
+		RouterID:   router.ID,
+		Rule:       entities.AlertRuleReboot,
+		Message:    fmt.Sprintf("Router rebooted, uptime %s", uptime),
+		Value:      float64(metric.UptimeSeconds),
+		StartedAt:  now.Add(-uptime),
+		FiredAt:    &now,
+		ResolvedAt: &now,
+	}
+	if err := u.alertRepo.Create(alert); err != nil {
+		logger.Error("Failed to record router reboot", zap.Uint("router_id", router.ID), zap.Error(err))
+		return
+	}
+	logger.Warn("Router reboot detected",
+		zap.Uint("router_id", router.ID),
+		zap.Duration("uptime", uptime),
+	)
+	u.notify(router, alert)
+}
+
+func (u *MonitoringUsecase) notify(router *entities.Router, alert *entities.RouterAlert) {
+	if u.whatsappService == nil {
+		return
+	}
+	if err := u.whatsappService.SendRouterAlert(router, alert); err != nil {
+		logger.Warn("Failed to send router alert",
+			zap.Uint("alert_id", alert.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// StartPolling runs Poll every interval. It returns a function that stops
+// the loop.
+func (u *MonitoringUsecase) StartPolling(interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if _, err := u.Poll(ctx); err != nil {
+				logger.Error("Router metrics poll failed", zap.Error(err))
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Metrics returns a router's resource history between from and to in steps
+// of step. Zero bounds default to the last 24 hours; a zero step is picked
+// so the series has at most maxMetricPoints points.
+func (u *MonitoringUsecase) Metrics(routerID uint, from, to time.Time, step time.Duration) (*MetricHistory, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-24 * time.Hour)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	if step <= 0 {
+		// Round up to whole minutes.
+		exact := to.Sub(from) / maxMetricPoints
+		step = exact.Truncate(time.Minute)
+		if step < exact || step == 0 {
+			step += time.Minute
+		}
+	}
+	if step < time.Second {
+		return nil, fmt.Errorf("step must be at least 1s")
+	}
+	if _, err := u.routerRepo.FindByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+
+	metrics, err := u.metricRepo.Find(routerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &MetricHistory{
+		RouterID: routerID,
+		From:     from,
+		To:       to,
+		Step:     step.String(),
+		Points:   []MetricPoint{},
+	}
+	var (
+		point     *MetricPoint
+		reachable int
+		reached   int
+	)
+	finish := func() {
+		if point == nil {
+			return
+		}
+		if reachable > 0 {
+			point.CPU /= float64(reachable)
+			point.Memory /= float64(reachable)
+		}
+		history.Points = append(history.Points, *point)
+	}
+	for _, m := range metrics {
+		bucket := m.SampledAt.Truncate(step)
+		if point == nil || !point.Time.Equal(bucket) {
+			finish()
+			point = &MetricPoint{Time: bucket}
+			reachable = 0
+		}
+		point.Samples++
+		if !m.Reachable {
+			point.Unreachable++
+			continue
+		}
+		reachable++
+		reached++
+		point.CPU += m.CPU
+		point.CPUMax = max(point.CPUMax, m.CPU)
+		point.Memory += float64(m.Memory)
+		point.MemoryMax = max(point.MemoryMax, m.Memory)
+		point.ActiveUsers = max(point.ActiveUsers, m.ActiveUsers)
+		point.UptimeSeconds = m.UptimeSeconds
+	}
+	finish()
+
+	if len(metrics) > 0 {
+		history.Availability = float64(reached) * 100 / float64(len(metrics))
+	}
+	return history, nil
+}
+
+// Alerts lists fired alerts, newest first. A zero routerID lists every
+// router; openOnly leaves out resolved alerts.
+func (u *MonitoringUsecase) Alerts(routerID uint, openOnly bool, page, perPage int) ([]*entities.RouterAlert, int64, error) {
+	return u.alertRepo.Find(routerID, openOnly, page, perPage)
+}
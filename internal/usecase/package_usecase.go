@@ -1,18 +1,33 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
 )
 
+// IsolationRateLimit is applied to an isolation profile only when it is
+// first created; existing isolation profiles are left as configured.
+const IsolationRateLimit = "256k/256k"
+
 type PackageUsecase struct {
-	packageRepo repositories.PackageRepository
+	packageRepo    repositories.PackageRepository
+	routerRepo     repositories.RouterRepository
+	mikrotikClient *mikrotik.MikroTikClient
 }
 
-func NewPackageUsecase(packageRepo repositories.PackageRepository) *PackageUsecase {
-	return &PackageUsecase{packageRepo: packageRepo}
+func NewPackageUsecase(packageRepo repositories.PackageRepository, routerRepo repositories.RouterRepository, mikrotikClient *mikrotik.MikroTikClient) *PackageUsecase {
+	return &PackageUsecase{
+		packageRepo:    packageRepo,
+		routerRepo:     routerRepo,
+		mikrotikClient: mikrotikClient,
+	}
 }
 
 // GetAll returns all packages.
@@ -37,36 +52,228 @@ type CreatePackageRequest struct {
 	Description   string  `json:"description"`
 	ProfileNormal string  `json:"profile_normal"`
 	ProfileIsolir string  `json:"profile_isolir"`
+	PoolName      string  `json:"pool_name"`
 	Status        string  `json:"status"`
+	// QuotaGB sets the monthly fair-usage allowance; 0 removes it. Left out
+	// on update, the current quota is kept.
+	QuotaGB         *int   `json:"quota_gb"`
+	ProfileThrottle string `json:"profile_throttle"`
+	ThrottleSpeed   string `json:"throttle_speed"`
+	// Schedules replaces the package's speed schedules; an empty list
+	// removes them. Left out on update, the current schedules are kept.
+	Schedules *[]PackageScheduleRequest `json:"schedules"`
+	// RouterIDs limits profile provisioning to these routers; empty means
+	// every router.
+	RouterIDs []uint `json:"router_ids"`
+}
+
+// PackageScheduleRequest is a daily window with another speed, e.g.
+// {"name": "Night boost", "start_time": "00:00", "end_time": "06:00",
+// "speed": "20M"}.
+// Days limits it to the weekdays it starts on.
+type PackageScheduleRequest struct {
+	Name  string   `json:"name"`
+	Start string   `json:"start_time"`
+	End   string   `json:"end_time"`
+	Days  []string `json:"days"`
+	Speed string   `json:"speed"`
+}
+
+// ProfileProvisionResult reports one /ppp/profile change on one router.
+type ProfileProvisionResult struct {
+	RouterID   uint   `json:"router_id"`
+	RouterName string `json:"router_name"`
+	Profile    string `json:"profile"`
+	RateLimit  string `json:"rate_limit,omitempty"`
+	Action     string `json:"action"`
+	Error      string `json:"error,omitempty"`
+}
+
+// validateQuota checks the fair-usage settings and names the throttle
+// profile after the normal one when none is given.
+func validateQuota(pkg *entities.Package) error {
+	if pkg.QuotaGB < 0 {
+		return fmt.Errorf("quota_gb cannot be negative")
+	}
+	if pkg.ThrottleSpeed != "" {
+		if _, err := mikrotik.ParseRateLimit(pkg.ThrottleSpeed); err != nil {
+			return err
+		}
+	}
+	if pkg.QuotaGB == 0 {
+		return nil
+	}
+	if pkg.ThrottleSpeed == "" {
+		return fmt.Errorf("throttle_speed is required when quota_gb is set")
+	}
+	if pkg.ProfileThrottle == "" {
+		pkg.ProfileThrottle = pkg.ProfileNormal + "-fup"
+	}
+	return nil
+}
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseClock returns the minutes after midnight of an "HH:MM" time.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func isWeekday(day string) bool {
+	for _, wd := range weekdays {
+		if wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSchedules validates schedule requests. The package needs a speed of
+// its own to return to when a window ends.
+func buildSchedules(pkg *entities.Package, reqs []PackageScheduleRequest) ([]entities.PackageSchedule, error) {
+	if len(reqs) > 0 && pkg.Speed == "" {
+		return nil, fmt.Errorf("speed is required when schedules are set")
+	}
+	schedules := make([]entities.PackageSchedule, 0, len(reqs))
+	for i, req := range reqs {
+		start, err := parseClock(req.Start)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %d: %w", i+1, err)
+		}
+		end, err := parseClock(req.End)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %d: %w", i+1, err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("schedule %d: start and end must differ", i+1)
+		}
+		if req.Speed == "" {
+			return nil, fmt.Errorf("schedule %d: speed is required", i+1)
+		}
+		if _, err := mikrotik.ParseRateLimit(req.Speed); err != nil {
+			return nil, fmt.Errorf("schedule %d: %w", i+1, err)
+		}
+		days := make([]string, 0, len(req.Days))
+		for _, name := range req.Days {
+			day := strings.ToLower(strings.TrimSpace(name))
+			if len(day) > 3 {
+				day = day[:3]
+			}
+			if !isWeekday(day) {
+				return nil, fmt.Errorf("schedule %d: invalid day %q", i+1, name)
+			}
+			days = append(days, day)
+		}
+		schedules = append(schedules, entities.PackageSchedule{
+			Name:      req.Name,
+			StartTime: req.Start,
+			EndTime:   req.End,
+			Days:      strings.Join(days, ","),
+			Speed:     req.Speed,
+		})
+	}
+	return schedules, nil
+}
+
+// scheduleActive reports whether t falls in a schedule's window. A window
+// past midnight belongs to the day it starts on.
+func scheduleActive(schedule *entities.PackageSchedule, t time.Time) bool {
+	start, err := parseClock(schedule.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(schedule.EndTime)
+	if err != nil {
+		return false
+	}
+	onDay := func(d time.Weekday) bool {
+		return schedule.Days == "" || strings.Contains(schedule.Days, weekdays[d])
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	if start < end {
+		return now >= start && now < end && onDay(t.Weekday())
+	}
+	return (now >= start && onDay(t.Weekday())) || (now < end && onDay(t.AddDate(0, 0, -1).Weekday()))
+}
+
+// ScheduledSpeed returns the speed of a package at t: that of the first
+// schedule whose window contains t, else Package.Speed.
+func ScheduledSpeed(pkg *entities.Package, t time.Time) (string, *entities.PackageSchedule) {
+	for i := range pkg.Schedules {
+		if scheduleActive(&pkg.Schedules[i], t) {
+			return pkg.Schedules[i].Speed, &pkg.Schedules[i]
+		}
+	}
+	return pkg.Speed, nil
 }
 
-// Create creates a new internet package.
-func (u *PackageUsecase) Create(req CreatePackageRequest) (*entities.Package, error) {
+// Create creates a new internet package and provisions its profiles. The
+// normal profile defaults to the package name.
+func (u *PackageUsecase) Create(ctx context.Context, req CreatePackageRequest) (*entities.Package, []ProfileProvisionResult, error) {
 	if req.Status == "" {
 		req.Status = "active"
 	}
+	if req.ProfileNormal == "" {
+		req.ProfileNormal = req.Name
+	}
+	if req.Speed != "" {
+		if _, err := mikrotik.ParseRateLimit(req.Speed); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	pkg := &entities.Package{
-		Name:          req.Name,
-		Price:         req.Price,
-		Speed:         req.Speed,
-		Description:   req.Description,
-		ProfileNormal: req.ProfileNormal,
-		ProfileIsolir: req.ProfileIsolir,
-		Status:        req.Status,
+		Name:            req.Name,
+		Price:           req.Price,
+		Speed:           req.Speed,
+		Description:     req.Description,
+		ProfileNormal:   req.ProfileNormal,
+		ProfileIsolir:   req.ProfileIsolir,
+		PoolName:        req.PoolName,
+		Status:          req.Status,
+		ProfileThrottle: req.ProfileThrottle,
+		ThrottleSpeed:   req.ThrottleSpeed,
+	}
+	if req.QuotaGB != nil {
+		pkg.QuotaGB = *req.QuotaGB
+	}
+	if err := validateQuota(pkg); err != nil {
+		return nil, nil, err
+	}
+	if req.Schedules != nil {
+		schedules, err := buildSchedules(pkg, *req.Schedules)
+		if err != nil {
+			return nil, nil, err
+		}
+		pkg.Schedules = schedules
 	}
 
 	if err := u.packageRepo.Create(pkg); err != nil {
-		return nil, fmt.Errorf("failed to create package: %w", err)
+		return nil, nil, fmt.Errorf("failed to create package: %w", err)
 	}
-	return pkg, nil
+
+	results, err := u.provision(ctx, pkg, req.RouterIDs)
+	if err != nil {
+		return pkg, nil, err
+	}
+	return pkg, results, nil
 }
 
-// Update updates an existing package.
-func (u *PackageUsecase) Update(id uint, req CreatePackageRequest) (*entities.Package, error) {
+// Update updates an existing package and re-provisions its profiles.
+func (u *PackageUsecase) Update(ctx context.Context, id uint, req CreatePackageRequest) (*entities.Package, []ProfileProvisionResult, error) {
 	pkg, err := u.packageRepo.FindByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("package not found")
+		return nil, nil, fmt.Errorf("package not found")
+	}
+	if req.Speed != "" {
+		if _, err := mikrotik.ParseRateLimit(req.Speed); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	if req.Name != "" {
@@ -87,14 +294,179 @@ func (u *PackageUsecase) Update(id uint, req CreatePackageRequest) (*entities.Pa
 	if req.ProfileIsolir != "" {
 		pkg.ProfileIsolir = req.ProfileIsolir
 	}
+	if req.PoolName != "" {
+		pkg.PoolName = req.PoolName
+	}
 	if req.Status != "" {
 		pkg.Status = req.Status
 	}
+	if req.QuotaGB != nil {
+		pkg.QuotaGB = *req.QuotaGB
+	}
+	if req.ProfileThrottle != "" {
+		pkg.ProfileThrottle = req.ProfileThrottle
+	}
+	if req.ThrottleSpeed != "" {
+		pkg.ThrottleSpeed = req.ThrottleSpeed
+	}
+	if err := validateQuota(pkg); err != nil {
+		return nil, nil, err
+	}
+	schedules := pkg.Schedules
+	if req.Schedules != nil {
+		if schedules, err = buildSchedules(pkg, *req.Schedules); err != nil {
+			return nil, nil, err
+		}
+	} else if len(schedules) > 0 && pkg.Speed == "" {
+		return nil, nil, fmt.Errorf("speed is required when schedules are set")
+	}
 
 	if err := u.packageRepo.Update(pkg); err != nil {
-		return nil, fmt.Errorf("failed to update package: %w", err)
+		return nil, nil, fmt.Errorf("failed to update package: %w", err)
 	}
-	return pkg, nil
+	if req.Schedules != nil {
+		if err := u.packageRepo.ReplaceSchedules(pkg.ID, schedules); err != nil {
+			return nil, nil, fmt.Errorf("failed to update package schedules: %w", err)
+		}
+		pkg.Schedules = schedules
+	}
+
+	results, err := u.provision(ctx, pkg, req.RouterIDs)
+	if err != nil {
+		return pkg, nil, err
+	}
+	return pkg, results, nil
+}
+
+// Provision creates or updates the package's PPP profiles on the given
+// routers, or on every router when routerIDs is empty.
+func (u *PackageUsecase) Provision(ctx context.Context, id uint, routerIDs []uint) ([]ProfileProvisionResult, error) {
+	pkg, err := u.packageRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("package not found")
+	}
+	return u.provision(ctx, pkg, routerIDs)
+}
+
+// provision pushes the package profiles to each router concurrently. The
+// normal profile gets the rate-limit derived from the speed scheduled now
+// (Package.Speed outside schedule windows) and hands
+// out addresses from Package.PoolName; the throttle profile does the same
+// with Package.ThrottleSpeed. The isolation profile is only created when
+// missing.
+func (u *PackageUsecase) provision(ctx context.Context, pkg *entities.Package, routerIDs []uint) ([]ProfileProvisionResult, error) {
+	if u.mikrotikClient == nil {
+		return nil, nil
+	}
+
+	var routers []*entities.Router
+	if len(routerIDs) == 0 {
+		all, err := u.routerRepo.FindAll()
+		if err != nil {
+			return nil, err
+		}
+		routers = all
+	} else {
+		for _, id := range routerIDs {
+			router, err := u.routerRepo.FindByID(id)
+			if err != nil {
+				return nil, fmt.Errorf("router %d not found", id)
+			}
+			routers = append(routers, router)
+		}
+	}
+
+	var normalArgs []string
+	rateLimit := ""
+	if speed, _ := ScheduledSpeed(pkg, time.Now()); speed != "" {
+		rl, err := mikrotik.ParseRateLimit(speed)
+		if err != nil {
+			return nil, err
+		}
+		rateLimit = rl
+		normalArgs = append(normalArgs, "=rate-limit="+rl)
+	}
+	var throttleArgs []string
+	throttleLimit := ""
+	if pkg.ProfileThrottle != "" && pkg.ThrottleSpeed != "" {
+		rl, err := mikrotik.ParseRateLimit(pkg.ThrottleSpeed)
+		if err != nil {
+			return nil, err
+		}
+		throttleLimit = rl
+		throttleArgs = append(throttleArgs, "=rate-limit="+rl)
+	}
+	if pkg.PoolName != "" {
+		normalArgs = append(normalArgs, "=remote-address="+pkg.PoolName)
+		throttleArgs = append(throttleArgs, "=remote-address="+pkg.PoolName)
+	}
+
+	perRouter := make([][]ProfileProvisionResult, len(routers))
+	var wg sync.WaitGroup
+	for i, router := range routers {
+		wg.Add(1)
+		go func(i int, router *entities.Router) {
+			defer wg.Done()
+			var results []ProfileProvisionResult
+			if pkg.ProfileNormal != "" {
+				results = append(results, u.upsertProfile(ctx, router, pkg.ProfileNormal, rateLimit, false, normalArgs...))
+			}
+			if throttleLimit != "" {
+				results = append(results, u.upsertProfile(ctx, router, pkg.ProfileThrottle, throttleLimit, false, throttleArgs...))
+			}
+			if pkg.ProfileIsolir != "" {
+				results = append(results, u.upsertProfile(ctx, router, pkg.ProfileIsolir, IsolationRateLimit, true))
+			}
+			perRouter[i] = results
+		}(i, router)
+	}
+	wg.Wait()
+
+	results := []ProfileProvisionResult{}
+	for _, r := range perRouter {
+		results = append(results, r...)
+	}
+	return results, nil
+}
+
+// upsertProfile provisions one profile on one router. With createOnly the
+// profile is created with rateLimit if missing and otherwise left alone.
+func (u *PackageUsecase) upsertProfile(ctx context.Context, router *entities.Router, name, rateLimit string, createOnly bool, args ...string) ProfileProvisionResult {
+	result := ProfileProvisionResult{
+		RouterID:   router.ID,
+		RouterName: router.Name,
+		Profile:    name,
+		RateLimit:  rateLimit,
+	}
+
+	if createOnly {
+		profiles, err := u.mikrotikClient.GetAllProfiles(ctx, router.ID)
+		if err != nil {
+			result.Action = "failed"
+			result.Error = err.Error()
+			return result
+		}
+		for _, p := range profiles {
+			if p.Name == name {
+				result.Action = "unchanged"
+				result.RateLimit = p.RateLimit
+				return result
+			}
+		}
+		args = []string{"=rate-limit=" + rateLimit}
+	}
+
+	created, err := u.mikrotikClient.UpsertProfile(ctx, router.ID, name, args...)
+	switch {
+	case err != nil:
+		result.Action = "failed"
+		result.Error = err.Error()
+	case created:
+		result.Action = "created"
+	default:
+		result.Action = "updated"
+	}
+	return result
 }
 
 // Delete removes a package.
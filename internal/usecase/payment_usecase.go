@@ -1,36 +1,78 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/tripay"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/webhook"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/whatsapp"
 	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"github.com/alijayanet/gembok-backend/pkg/qrcode"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// Payment transaction statuses, mirroring the Tripay callback statuses.
+const (
+	PaymentStatusUnpaid  = "unpaid"
+	PaymentStatusPaid    = "paid"
+	PaymentStatusExpired = "expired"
+	PaymentStatusFailed  = "failed"
+	PaymentStatusRefund  = "refund"
+)
+
+// paymentTransactionTTL is how long a new Tripay transaction stays payable.
+const paymentTransactionTTL = 24 * time.Hour
+
+// paymentReuseMinRemaining is the minimum time left before expiry for an
+// open transaction to be handed out again instead of creating a new one.
+const paymentReuseMinRemaining = 5 * time.Minute
+
+// QR code image formats served for QRIS transactions.
+const (
+	QRFormatPNG = "png"
+	QRFormatSVG = "svg"
+)
+
+// qrModuleScale is the size of one QR module in pixels.
+const qrModuleScale = 8
+
 type PaymentUsecase struct {
 	invoiceRepo  repositories.InvoiceRepository
 	customerRepo repositories.CustomerRepository
+	trxRepo      repositories.PaymentTransactionRepository
 	tripay       *tripay.TripayClient
 	mikrotikSvc  *mikrotik.MikroTikService
+	whatsappSvc  *whatsapp.WhatsAppService
+	webhooks     *webhook.Dispatcher
 	appURL       string
 }
 
 func NewPaymentUsecase(
 	invoiceRepo repositories.InvoiceRepository,
 	customerRepo repositories.CustomerRepository,
+	trxRepo repositories.PaymentTransactionRepository,
 	tripay *tripay.TripayClient,
 	mikrotikSvc *mikrotik.MikroTikService,
+	whatsappSvc *whatsapp.WhatsAppService,
+	webhooks *webhook.Dispatcher,
 	appURL string,
 ) *PaymentUsecase {
 	return &PaymentUsecase{
 		invoiceRepo:  invoiceRepo,
 		customerRepo: customerRepo,
+		trxRepo:      trxRepo,
 		tripay:       tripay,
 		mikrotikSvc:  mikrotikSvc,
+		whatsappSvc:  whatsappSvc,
+		webhooks:     webhooks,
 		appURL:       appURL,
 	}
 }
@@ -41,11 +83,19 @@ type CreatePaymentRequest struct {
 }
 
 type CreatePaymentResponse struct {
-	PaymentURL  string `json:"payment_url"`
-	Reference   string `json:"reference"`
-	MerchantRef string `json:"merchant_ref"`
-	Amount      int64  `json:"amount"`
-	ExpiredTime int64  `json:"expired_time"`
+	TransactionID uint   `json:"transaction_id"`
+	PaymentURL    string `json:"payment_url"`
+	CheckoutURL   string `json:"checkout_url"`
+	Reference     string `json:"reference"`
+	MerchantRef   string `json:"merchant_ref"`
+	Method        string `json:"method"`
+	Amount        int64  `json:"amount"`
+	PayCode       string `json:"pay_code,omitempty"`
+	QRString      string `json:"qr_string,omitempty"`
+	QRImageURL    string `json:"qr_image_url,omitempty"`
+	Status        string `json:"status"`
+	ExpiredTime   int64  `json:"expired_time"`
+	Reused        bool   `json:"reused"`
 }
 
 func (u *PaymentUsecase) CreateTransaction(req CreatePaymentRequest) (*CreatePaymentResponse, error) {
@@ -58,6 +108,10 @@ func (u *PaymentUsecase) CreateTransaction(req CreatePaymentRequest) (*CreatePay
 		return nil, fmt.Errorf("invoice is already paid")
 	}
 
+	if req.PaymentMethod == "" {
+		return nil, fmt.Errorf("payment method is required")
+	}
+
 	if !u.tripay.IsConfigured() {
 		return nil, fmt.Errorf("payment gateway not configured, please set tripay credentials in config")
 	}
@@ -71,7 +125,21 @@ func (u *PaymentUsecase) CreateTransaction(req CreatePaymentRequest) (*CreatePay
 		customer = cust
 	}
 
-	expiredTime := time.Now().Add(24 * time.Hour).Unix()
+	open, err := u.findOpenTransaction(invoice, req.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+	if open != nil {
+		logger.Info("Reusing open payment transaction",
+			zap.Uint("invoice_id", invoice.ID),
+			zap.String("reference", open.Reference),
+		)
+		resp := u.toPaymentResponse(open)
+		resp.Reused = true
+		return resp, nil
+	}
+
+	expiredTime := time.Now().Add(paymentTransactionTTL).Unix()
 
 	tripayReq := tripay.TripayTransactionRequest{
 		Method:        req.PaymentMethod,
@@ -101,18 +169,173 @@ func (u *PaymentUsecase) CreateTransaction(req CreatePaymentRequest) (*CreatePay
 		return nil, fmt.Errorf("payment gateway error: %s", resp.Message)
 	}
 
+	checkoutURL := resp.Data.CheckoutURL
+	if checkoutURL == "" {
+		checkoutURL = resp.Data.PaymentURL
+	}
+	expiredAt := time.Unix(expiredTime, 0)
+	if resp.Data.ExpiredTime > 0 {
+		expiredAt = time.Unix(resp.Data.ExpiredTime, 0)
+	}
+
+	trx := &entities.PaymentTransaction{
+		InvoiceID:   invoice.ID,
+		CustomerID:  invoice.CustomerID,
+		Reference:   resp.Data.Reference,
+		MerchantRef: resp.Data.MerchantRef,
+		Method:      req.PaymentMethod,
+		Amount:      invoice.Amount,
+		CheckoutURL: checkoutURL,
+		PayCode:     resp.Data.PayCode,
+		QRString:    resp.Data.QRString,
+		Status:      PaymentStatusUnpaid,
+		ExpiredAt:   expiredAt,
+	}
+	// Without the stored transaction the next request could not reuse it and
+	// would open a duplicate one at Tripay, so fail instead.
+	if err := u.trxRepo.Create(trx); err != nil {
+		logger.Error("Failed to save payment transaction",
+			zap.String("reference", trx.Reference),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to save payment transaction: %w", err)
+	}
+
 	// Save reference to invoice
 	invoice.PaymentReference = resp.Data.Reference
 	invoice.PaymentMethod = req.PaymentMethod
-	_ = u.invoiceRepo.Update(invoice)
+	if err := u.invoiceRepo.Update(invoice); err != nil {
+		logger.Warn("Failed to save payment reference on invoice",
+			zap.Uint("invoice_id", invoice.ID),
+			zap.String("reference", trx.Reference),
+			zap.Error(err),
+		)
+	}
 
-	return &CreatePaymentResponse{
-		PaymentURL:  resp.Data.PaymentURL,
-		Reference:   resp.Data.Reference,
-		MerchantRef: resp.Data.MerchantRef,
-		Amount:      resp.Data.Amount,
-		ExpiredTime: resp.Data.ExpiredTime,
-	}, nil
+	return u.toPaymentResponse(trx), nil
+}
+
+// findOpenTransaction returns the latest transaction for the invoice and
+// method if it can still be paid. Unpaid transactions found past their
+// expiry are marked expired so that a fresh one gets created.
+func (u *PaymentUsecase) findOpenTransaction(invoice *entities.Invoice, method string) (*entities.PaymentTransaction, error) {
+	trx, err := u.trxRepo.FindLatestByInvoiceAndMethod(invoice.ID, method)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up payment transaction: %w", err)
+	}
+	if trx.Status != PaymentStatusUnpaid {
+		return nil, nil
+	}
+
+	now := time.Now()
+	if !trx.ExpiredAt.After(now) {
+		trx.Status = PaymentStatusExpired
+		if err := u.trxRepo.Update(trx); err != nil {
+			return nil, fmt.Errorf("failed to expire payment transaction: %w", err)
+		}
+		return nil, nil
+	}
+
+	if trx.ExpiredAt.Before(now.Add(paymentReuseMinRemaining)) || trx.Amount != invoice.Amount {
+		return nil, nil
+	}
+	return trx, nil
+}
+
+func (u *PaymentUsecase) toPaymentResponse(trx *entities.PaymentTransaction) *CreatePaymentResponse {
+	resp := &CreatePaymentResponse{
+		TransactionID: trx.ID,
+		PaymentURL:    trx.CheckoutURL,
+		CheckoutURL:   trx.CheckoutURL,
+		Reference:     trx.Reference,
+		MerchantRef:   trx.MerchantRef,
+		Method:        trx.Method,
+		Amount:        int64(trx.Amount),
+		PayCode:       trx.PayCode,
+		QRString:      trx.QRString,
+		Status:        trx.Status,
+		ExpiredTime:   trx.ExpiredAt.Unix(),
+	}
+	if trx.QRString != "" {
+		resp.QRImageURL = u.QRImageURL(trx, QRFormatPNG)
+	}
+	return resp
+}
+
+// QRImageURL returns the public URL of the rendered QR code of a transaction.
+func (u *PaymentUsecase) QRImageURL(trx *entities.PaymentTransaction, format string) string {
+	return fmt.Sprintf("%s/api/payment/qr/%s/%s", strings.TrimRight(u.appURL, "/"), trx.Reference, format)
+}
+
+// GetTransaction returns a payment transaction by ID.
+func (u *PaymentUsecase) GetTransaction(id uint) (*entities.PaymentTransaction, error) {
+	trx, err := u.trxRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("payment transaction not found")
+	}
+	return trx, nil
+}
+
+// RenderQRCode renders the QRIS string of a transaction as a PNG or SVG
+// image and returns it together with its content type.
+func (u *PaymentUsecase) RenderQRCode(reference, format string) ([]byte, string, error) {
+	trx, err := u.trxRepo.FindByReference(reference)
+	if err != nil {
+		return nil, "", fmt.Errorf("payment transaction not found")
+	}
+	if trx.QRString == "" {
+		return nil, "", fmt.Errorf("payment transaction has no QR code")
+	}
+
+	code, err := qrcode.Encode(trx.QRString, qrcode.Medium)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	switch format {
+	case QRFormatPNG:
+		img, err := code.PNG(qrModuleScale, qrcode.DefaultBorder)
+		if err != nil {
+			return nil, "", err
+		}
+		return img, "image/png", nil
+	case QRFormatSVG:
+		return []byte(code.SVG(qrModuleScale, qrcode.DefaultBorder)), "image/svg+xml", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported QR format: %s", format)
+	}
+}
+
+// SendQRCode sends the QR code of an open QRIS transaction to the
+// customer's WhatsApp number.
+func (u *PaymentUsecase) SendQRCode(trxID uint) error {
+	if u.whatsappSvc == nil {
+		return fmt.Errorf("whatsapp service not configured")
+	}
+
+	trx, err := u.trxRepo.FindByID(trxID)
+	if err != nil {
+		return fmt.Errorf("payment transaction not found")
+	}
+	if trx.QRString == "" {
+		return fmt.Errorf("payment transaction has no QR code")
+	}
+	if trx.Status != PaymentStatusUnpaid || !trx.ExpiredAt.After(time.Now()) {
+		return fmt.Errorf("payment transaction is no longer payable")
+	}
+
+	invoice := trx.Invoice
+	if invoice == nil {
+		invoice, err = u.invoiceRepo.FindByID(trx.InvoiceID)
+		if err != nil {
+			return fmt.Errorf("invoice not found")
+		}
+	}
+
+	return u.whatsappSvc.SendPaymentQRCode(invoice, trx, u.QRImageURL(trx, QRFormatPNG))
 }
 
 func (u *PaymentUsecase) GetPaymentGateways() ([]tripay.TripayPaymentChannel, error) {
@@ -127,7 +350,7 @@ func (u *PaymentUsecase) GetPaymentGateways() ([]tripay.TripayPaymentChannel, er
 	return u.tripay.GetPaymentChannels()
 }
 
-func (u *PaymentUsecase) HandleCallback(payload tripay.TripayCallbackPayload, rawBody, signature string) error {
+func (u *PaymentUsecase) HandleCallback(ctx context.Context, payload tripay.TripayCallbackPayload, rawBody, signature string) error {
 	if !u.tripay.ValidateCallback(signature, rawBody) {
 		return fmt.Errorf("invalid callback signature")
 	}
@@ -137,29 +360,85 @@ func (u *PaymentUsecase) HandleCallback(payload tripay.TripayCallbackPayload, ra
 		return fmt.Errorf("invoice not found: %s", payload.MerchantRef)
 	}
 
-	if payload.Status == "PAID" {
-		now := time.Now()
-		invoice.Status = "paid"
-		invoice.PaidAt = &now
-		invoice.PaymentReference = payload.Reference
+	status := strings.ToLower(payload.Status)
 
-		if err := u.invoiceRepo.Update(invoice); err != nil {
-			return fmt.Errorf("failed to update invoice: %w", err)
-		}
+	trx, err := u.trxRepo.FindByReference(payload.Reference)
+	if err != nil {
+		trx = nil
+		logger.Warn("Callback for unknown payment transaction",
+			zap.String("reference", payload.Reference),
+			zap.String("status", payload.Status),
+		)
+	}
 
-		// Auto-activate customer after payment
-		customer, err := u.customerRepo.FindByID(invoice.CustomerID)
-		if err == nil && customer.Status == "isolated" {
-			if err := u.mikrotikSvc.ActivateCustomer(customer); err != nil {
-				logger.Warn("Failed to auto-activate customer after payment",
-					zap.Uint("customer_id", customer.ID),
-					zap.Error(err),
-				)
-			} else {
-				logger.Info("Customer auto-activated after payment",
-					zap.Uint("customer_id", customer.ID),
-				)
+	if trx != nil && trx.Status != status {
+		trx.Status = status
+		if status == PaymentStatusPaid {
+			paidAt := time.Now()
+			if payload.PaidAt > 0 {
+				paidAt = time.Unix(payload.PaidAt, 0)
 			}
+			trx.PaidAt = &paidAt
+		}
+		if err := u.trxRepo.Update(trx); err != nil {
+			return fmt.Errorf("failed to update payment transaction: %w", err)
+		}
+	}
+
+	switch status {
+	case PaymentStatusPaid:
+		if invoice.Status == "paid" {
+			return nil
+		}
+
+		paidAt := time.Now()
+		if payload.PaidAt > 0 {
+			paidAt = time.Unix(payload.PaidAt, 0)
+		}
+		method := payload.PaymentMethodCode
+		if method == "" {
+			method = invoice.PaymentMethod
+		}
+		return u.MarkInvoicePaid(ctx, invoice, method, payload.Reference, paidAt)
+	case PaymentStatusExpired, PaymentStatusFailed, PaymentStatusRefund:
+		logger.Info("Payment transaction closed without payment",
+			zap.String("reference", payload.Reference),
+			zap.String("merchant_ref", payload.MerchantRef),
+			zap.String("status", status),
+		)
+	}
+
+	return nil
+}
+
+// MarkInvoicePaid records an invoice as paid, publishes invoice.paid and
+// re-activates the customer if they were isolated for non-payment.
+func (u *PaymentUsecase) MarkInvoicePaid(ctx context.Context, invoice *entities.Invoice, method, reference string, paidAt time.Time) error {
+	invoice.Status = "paid"
+	invoice.PaidAt = &paidAt
+	invoice.PaymentMethod = method
+	invoice.PaymentReference = reference
+
+	if err := u.invoiceRepo.Update(invoice); err != nil {
+		return fmt.Errorf("failed to update invoice: %w", err)
+	}
+
+	if u.webhooks != nil {
+		u.webhooks.Publish(webhook.EventInvoicePaid, invoice)
+	}
+
+	// Auto-activate customer after payment
+	customer, err := u.customerRepo.FindByID(invoice.CustomerID)
+	if err == nil && customer.Status == "isolated" {
+		if err := u.mikrotikSvc.ActivateCustomer(ctx, customer); err != nil {
+			logger.Warn("Failed to auto-activate customer after payment",
+				zap.Uint("customer_id", customer.ID),
+				zap.Error(err),
+			)
+		} else {
+			logger.Info("Customer auto-activated after payment",
+				zap.Uint("customer_id", customer.ID),
+			)
 		}
 	}
 
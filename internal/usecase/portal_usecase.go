@@ -5,29 +5,37 @@ import (
 
 	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/tripay"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/webhook"
 	"github.com/alijayanet/gembok-backend/pkg/utils"
 )
 
 // PortalUsecase handles customer portal operations
 type PortalUsecase struct {
-	customerRepo repositories.CustomerRepository
-	invoiceRepo  repositories.InvoiceRepository
-	ticketRepo   repositories.TroubleTicketRepository
-	jwtSecret    string
-	jwtExpiry    interface{} // time.Duration
+	customerRepo   repositories.CustomerRepository
+	invoiceRepo    repositories.InvoiceRepository
+	ticketRepo     repositories.TroubleTicketRepository
+	paymentUsecase *PaymentUsecase
+	webhooks       *webhook.Dispatcher
+	jwtSecret      string
+	jwtExpiry      interface{} // time.Duration
 }
 
 func NewPortalUsecase(
 	customerRepo repositories.CustomerRepository,
 	invoiceRepo repositories.InvoiceRepository,
 	ticketRepo repositories.TroubleTicketRepository,
+	paymentUsecase *PaymentUsecase,
+	webhooks *webhook.Dispatcher,
 	jwtSecret string,
 ) *PortalUsecase {
 	return &PortalUsecase{
-		customerRepo: customerRepo,
-		invoiceRepo:  invoiceRepo,
-		ticketRepo:   ticketRepo,
-		jwtSecret:    jwtSecret,
+		customerRepo:   customerRepo,
+		invoiceRepo:    invoiceRepo,
+		ticketRepo:     ticketRepo,
+		paymentUsecase: paymentUsecase,
+		webhooks:       webhooks,
+		jwtSecret:      jwtSecret,
 	}
 }
 
@@ -111,6 +119,35 @@ func (u *PortalUsecase) GetInvoices(customerID uint, page, perPage int) ([]*enti
 	return u.invoiceRepo.FindByCustomerID(customerID, page, perPage)
 }
 
+func (u *PortalUsecase) GetPaymentChannels() ([]tripay.TripayPaymentChannel, error) {
+	return u.paymentUsecase.GetPaymentGateways()
+}
+
+// PayInvoice creates (or reuses) a payment transaction for one of the
+// customer's own invoices. Invoices of other customers are reported as not
+// found so their existence is not leaked.
+func (u *PortalUsecase) PayInvoice(customerID, invoiceID uint, paymentMethod string) (*CreatePaymentResponse, error) {
+	invoice, err := u.invoiceRepo.FindByID(invoiceID)
+	if err != nil || invoice.CustomerID != customerID {
+		return nil, fmt.Errorf("invoice not found")
+	}
+
+	return u.paymentUsecase.CreateTransaction(CreatePaymentRequest{
+		InvoiceID:     invoice.ID,
+		PaymentMethod: paymentMethod,
+	})
+}
+
+// SendPaymentQRCode sends the QR code of one of the customer's own payment
+// transactions to their WhatsApp number.
+func (u *PortalUsecase) SendPaymentQRCode(customerID, trxID uint) error {
+	trx, err := u.paymentUsecase.GetTransaction(trxID)
+	if err != nil || trx.CustomerID != customerID {
+		return fmt.Errorf("payment transaction not found")
+	}
+	return u.paymentUsecase.SendQRCode(trx.ID)
+}
+
 func (u *PortalUsecase) GetTickets(customerID uint) ([]*entities.TroubleTicket, error) {
 	return u.ticketRepo.FindByCustomerID(customerID)
 }
@@ -132,5 +169,9 @@ func (u *PortalUsecase) CreateTicket(customerID uint, description, priority, sub
 		return nil, fmt.Errorf("failed to create ticket: %w", err)
 	}
 
+	if u.webhooks != nil {
+		u.webhooks.Publish(webhook.EventTicketCreated, ticket)
+	}
+
 	return ticket, nil
 }
@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+)
+
+// QueueRequest creates or updates a simple queue. With CustomerID the target
+// defaults to the customer's static IP and the limit to its package speed.
+// With only PackageID the queue is the package's shared parent queue, which
+// customer queues on the same router attach to automatically.
+type QueueRequest struct {
+	Name       string `json:"name"`
+	Target     string `json:"target"`
+	Parent     string `json:"parent"`
+	MaxLimit   string `json:"max_limit"`
+	LimitAt    string `json:"limit_at"`
+	Priority   string `json:"priority"`
+	Comment    string `json:"comment"`
+	Disabled   bool   `json:"disabled"`
+	CustomerID uint   `json:"customer_id"`
+	PackageID  uint   `json:"package_id"`
+}
+
+type QueueUsecase struct {
+	routerRepo   repositories.RouterRepository
+	customerRepo repositories.CustomerRepository
+	packageRepo  repositories.PackageRepository
+	queueService *mikrotik.QueueService
+}
+
+func NewQueueUsecase(routerRepo repositories.RouterRepository, customerRepo repositories.CustomerRepository, packageRepo repositories.PackageRepository, queueService *mikrotik.QueueService) *QueueUsecase {
+	return &QueueUsecase{
+		routerRepo:   routerRepo,
+		customerRepo: customerRepo,
+		packageRepo:  packageRepo,
+		queueService: queueService,
+	}
+}
+
+// CustomerQueueName is the queue name used for a customer's own queue.
+func CustomerQueueName(customer *entities.Customer) string {
+	if customer.PPPoEUsername != "" {
+		return "cust-" + customer.PPPoEUsername
+	}
+	return "cust-" + strconv.FormatUint(uint64(customer.ID), 10)
+}
+
+// PackageQueueName is the queue name used for a package's shared parent queue.
+func PackageQueueName(pkg *entities.Package) string {
+	return "pkg-" + strings.ReplaceAll(pkg.Name, " ", "-")
+}
+
+// hostTarget turns a bare address into a /32 queue target.
+func hostTarget(ip string) string {
+	if ip == "" || strings.Contains(ip, "/") {
+		return ip
+	}
+	return ip + "/32"
+}
+
+func (u *QueueUsecase) List(ctx context.Context, routerID uint) ([]mikrotik.SimpleQueue, error) {
+	if _, err := u.routerRepo.FindByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+	return u.queueService.List(ctx, routerID)
+}
+
+// resolve fills in the queue settings implied by the bound customer and
+// package.
+func (u *QueueUsecase) resolve(ctx context.Context, routerID uint, req QueueRequest) (mikrotik.SimpleQueue, error) {
+	q := mikrotik.SimpleQueue{
+		Name:     req.Name,
+		Target:   req.Target,
+		Parent:   req.Parent,
+		MaxLimit: req.MaxLimit,
+		LimitAt:  req.LimitAt,
+		Priority: req.Priority,
+		Comment:  req.Comment,
+		Disabled: req.Disabled,
+	}
+
+	var customer *entities.Customer
+	if req.CustomerID != 0 {
+		c, err := u.customerRepo.FindByID(req.CustomerID)
+		if err != nil {
+			return q, fmt.Errorf("customer not found")
+		}
+		if c.RouterID != 0 && c.RouterID != routerID {
+			return q, fmt.Errorf("customer is assigned to router %d", c.RouterID)
+		}
+		customer = c
+		if q.Target == "" {
+			q.Target = hostTarget(c.StaticIP)
+		}
+		if q.Target == "" {
+			return q, fmt.Errorf("customer has no static IP; set static_ip or target")
+		}
+		if q.Name == "" {
+			q.Name = CustomerQueueName(c)
+		}
+		if q.Comment == "" {
+			q.Comment = fmt.Sprintf("customer:%d %s", c.ID, c.Name)
+		}
+		if req.PackageID == 0 {
+			req.PackageID = c.PackageID
+		}
+	}
+
+	if req.PackageID != 0 {
+		pkg, err := u.packageRepo.FindByID(req.PackageID)
+		if err != nil {
+			return q, fmt.Errorf("package not found")
+		}
+		if q.MaxLimit == "" && pkg.Speed != "" {
+			limit, err := mikrotik.ParseRateLimit(pkg.Speed)
+			if err != nil {
+				return q, err
+			}
+			q.MaxLimit = limit
+		}
+
+		if customer == nil {
+			if q.Name == "" {
+				q.Name = PackageQueueName(pkg)
+			}
+			if q.Comment == "" {
+				q.Comment = fmt.Sprintf("package:%d %s", pkg.ID, pkg.Name)
+			}
+		} else if q.Parent == "" {
+			parent, err := u.queueService.Get(ctx, routerID, PackageQueueName(pkg))
+			if err != nil {
+				return q, err
+			}
+			if parent != nil {
+				q.Parent = parent.Name
+			}
+		}
+	}
+
+	if q.Name == "" {
+		return q, fmt.Errorf("queue name is required")
+	}
+	return q, nil
+}
+
+func (u *QueueUsecase) Create(ctx context.Context, routerID uint, req QueueRequest) (*mikrotik.SimpleQueue, error) {
+	if _, err := u.routerRepo.FindByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+	q, err := u.resolve(ctx, routerID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.queueService.Create(ctx, routerID, q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// Update replaces the settings of an existing queue; fields derived from a
+// bound customer or package are recomputed.
+func (u *QueueUsecase) Update(ctx context.Context, routerID uint, name string, req QueueRequest) (*mikrotik.SimpleQueue, error) {
+	if req.Name == "" {
+		req.Name = name
+	}
+	q, err := u.resolve(ctx, routerID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.queueService.Update(ctx, routerID, name, q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (u *QueueUsecase) SetDisabled(ctx context.Context, routerID uint, name string, disabled bool) error {
+	return u.queueService.SetDisabled(ctx, routerID, name, disabled)
+}
+
+func (u *QueueUsecase) Delete(ctx context.Context, routerID uint, name string) error {
+	return u.queueService.Remove(ctx, routerID, name)
+}
+
+// SyncCustomer creates or updates a customer's queue from its static IP and
+// current package, on the customer's router.
+func (u *QueueUsecase) SyncCustomer(ctx context.Context, customerID uint) (*mikrotik.SimpleQueue, error) {
+	customer, err := u.customerRepo.FindByID(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("customer not found")
+	}
+	if customer.RouterID == 0 {
+		return nil, fmt.Errorf("customer has no router assigned")
+	}
+
+	name := CustomerQueueName(customer)
+	existing, err := u.queueService.Get(ctx, customer.RouterID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	req := QueueRequest{CustomerID: customer.ID}
+	if existing != nil {
+		return u.Update(ctx, customer.RouterID, name, req)
+	}
+	return u.Create(ctx, customer.RouterID, req)
+}
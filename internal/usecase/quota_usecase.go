@@ -0,0 +1,322 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/whatsapp"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// bytesPerGB is the unit of Package.QuotaGB.
+	bytesPerGB = 1 << 30
+
+	// QuotaWarnPercent is the usage at which customers are warned before
+	// being throttled at 100%.
+	QuotaWarnPercent = 80
+
+	quotaPageSize = 500
+)
+
+// QuotaStatus is a customer's fair-usage standing in the current billing
+// period, which is the calendar month.
+type QuotaStatus struct {
+	CustomerID uint      `json:"customer_id"`
+	PackageID  uint      `json:"package_id"`
+	Period     string    `json:"period"`
+	ResetsAt   time.Time `json:"resets_at"`
+	QuotaGB    int       `json:"quota_gb"`
+	UsedBytes  int64     `json:"used_bytes"`
+	UsedGB     float64   `json:"used_gb"`
+	Percent    float64   `json:"percent"`
+	Throttled  bool      `json:"throttled"`
+	Unlimited  bool      `json:"unlimited"`
+}
+
+// QuotaCheckResult summarizes one run of the quota job.
+type QuotaCheckResult struct {
+	Checked   int `json:"checked"`
+	Warned    int `json:"warned"`
+	Throttled int `json:"throttled"`
+	Restored  int `json:"restored"`
+	Failed    int `json:"failed"`
+}
+
+type QuotaUsecase struct {
+	customerRepo    repositories.CustomerRepository
+	packageRepo     repositories.PackageRepository
+	usageRepo       repositories.UsageRepository
+	mikrotikClient  *mikrotik.MikroTikClient
+	whatsappService *whatsapp.WhatsAppService
+}
+
+func NewQuotaUsecase(customerRepo repositories.CustomerRepository, packageRepo repositories.PackageRepository, usageRepo repositories.UsageRepository, mikrotikClient *mikrotik.MikroTikClient, whatsappService *whatsapp.WhatsAppService) *QuotaUsecase {
+	return &QuotaUsecase{
+		customerRepo:    customerRepo,
+		packageRepo:     packageRepo,
+		usageRepo:       usageRepo,
+		mikrotikClient:  mikrotikClient,
+		whatsappService: whatsappService,
+	}
+}
+
+// quotaPeriod returns the billing period key and start for t.
+func quotaPeriod(t time.Time) (string, time.Time) {
+	start, _ := UsagePeriodStart(entities.UsageMonth, t)
+	return start.Format("2006-01"), start
+}
+
+func (u *QuotaUsecase) monthUsage(customerID uint, start time.Time) (int64, error) {
+	records, err := u.usageRepo.FindUsage(customerID, entities.UsageMonth, start, start)
+	if err != nil {
+		return 0, err
+	}
+	var used int64
+	for _, r := range records {
+		used += r.UploadBytes + r.DownloadBytes
+	}
+	return used, nil
+}
+
+// Status returns a customer's quota usage for the current period.
+func (u *QuotaUsecase) Status(customerID uint) (*QuotaStatus, error) {
+	customer, err := u.customerRepo.FindByID(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("customer not found")
+	}
+
+	period, start := quotaPeriod(time.Now())
+	used, err := u.monthUsage(customer.ID, start)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &QuotaStatus{
+		CustomerID: customer.ID,
+		PackageID:  customer.PackageID,
+		Period:     period,
+		ResetsAt:   start.AddDate(0, 1, 0),
+		UsedBytes:  used,
+		UsedGB:     float64(used) / bytesPerGB,
+		Throttled:  customer.QuotaThrottled,
+	}
+	if customer.Package == nil || customer.Package.QuotaGB == 0 {
+		status.Unlimited = true
+		return status, nil
+	}
+	status.QuotaGB = customer.Package.QuotaGB
+	status.Percent = float64(used) * 100 / float64(int64(status.QuotaGB)*bytesPerGB)
+	return status, nil
+}
+
+// setProfile moves a customer's secret to profile and drops the active
+// session so the new limits apply when it reconnects.
+func (u *QuotaUsecase) setProfile(ctx context.Context, customer *entities.Customer, profile string) error {
+	if customer.PPPoEUsername == "" {
+		return fmt.Errorf("customer has no PPPoE username")
+	}
+	routerID, err := u.mikrotikClient.CustomerRouterID(customer)
+	if err != nil {
+		return err
+	}
+	if err := u.mikrotikClient.SetActiveProfile(ctx, routerID, customer.PPPoEUsername, profile); err != nil {
+		return err
+	}
+	// Not being connected is fine; the profile applies on the next login.
+	_ = u.mikrotikClient.DisconnectUser(ctx, routerID, customer.PPPoEUsername)
+	return nil
+}
+
+// Check enforces fair-usage quotas. Customers throttled in an earlier
+// period, or whose package no longer has a quota, get their normal profile
+// back. Customers on a package with a quota are warned at
+// QuotaWarnPercent and moved to the throttle profile at 100%.
+func (u *QuotaUsecase) Check(ctx context.Context) (*QuotaCheckResult, error) {
+	result := &QuotaCheckResult{}
+	period, start := quotaPeriod(time.Now())
+
+	throttled, err := u.customerRepo.FindQuotaThrottled()
+	if err != nil {
+		return nil, err
+	}
+	for _, customer := range throttled {
+		if customer.QuotaPeriod == period && customer.Package != nil && customer.Package.QuotaGB > 0 {
+			continue
+		}
+		if err := u.restore(ctx, customer, period); err != nil {
+			logger.Warn("Failed to lift quota throttle",
+				zap.Uint("customer_id", customer.ID),
+				zap.Error(err),
+			)
+			result.Failed++
+			continue
+		}
+		result.Restored++
+	}
+
+	packages, err := u.packageRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range packages {
+		if pkg.QuotaGB == 0 {
+			continue
+		}
+		for page := 1; ; page++ {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			customers, total, err := u.customerRepo.FindByPackageID(pkg.ID, page, quotaPageSize)
+			if err != nil {
+				return result, err
+			}
+			for _, customer := range customers {
+				if err := u.checkCustomer(ctx, customer, pkg, period, start, result); err != nil {
+					logger.Warn("Quota check failed",
+						zap.Uint("customer_id", customer.ID),
+						zap.Error(err),
+					)
+					result.Failed++
+				}
+			}
+			if int64(page*quotaPageSize) >= total {
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (u *QuotaUsecase) restore(ctx context.Context, customer *entities.Customer, period string) error {
+	// Isolated customers are left alone; activation sets the profile from
+	// the quota flag in either isolation mode, so it picks the normal one
+	// once the flag is cleared.
+	if customer.Status == "active" && customer.Package != nil && customer.Package.ProfileNormal != "" {
+		if err := u.setProfile(ctx, customer, customer.Package.ProfileNormal); err != nil {
+			return err
+		}
+	}
+	customer.QuotaThrottled = false
+	customer.QuotaNotified = 0
+	customer.QuotaPeriod = period
+	if err := u.customerRepo.UpdateQuota(customer); err != nil {
+		return err
+	}
+	logger.Info("Quota throttle lifted",
+		zap.Uint("customer_id", customer.ID),
+		zap.String("username", customer.PPPoEUsername),
+	)
+	return nil
+}
+
+func (u *QuotaUsecase) checkCustomer(ctx context.Context, customer *entities.Customer, pkg *entities.Package, period string, start time.Time, result *QuotaCheckResult) error {
+	result.Checked++
+
+	changed := false
+	if customer.QuotaPeriod != period {
+		customer.QuotaPeriod = period
+		customer.QuotaNotified = 0
+		changed = true
+	}
+
+	used, err := u.monthUsage(customer.ID, start)
+	if err != nil {
+		return err
+	}
+	percent := int(used * 100 / (int64(pkg.QuotaGB) * bytesPerGB))
+
+	if percent >= 100 && !customer.QuotaThrottled {
+		// An isolated customer is only flagged; activation sets the
+		// throttle profile in either isolation mode.
+		if customer.Status == "active" {
+			if err := u.setProfile(ctx, customer, pkg.ProfileThrottle); err != nil {
+				return err
+			}
+		}
+		customer.QuotaThrottled = true
+		changed = true
+		result.Throttled++
+		logger.Info("Customer throttled for exceeding quota",
+			zap.Uint("customer_id", customer.ID),
+			zap.String("username", customer.PPPoEUsername),
+			zap.Int64("used_bytes", used),
+			zap.Int("quota_gb", pkg.QuotaGB),
+		)
+	}
+
+	level := 0
+	switch {
+	case percent >= 100:
+		level = 100
+	case percent >= QuotaWarnPercent:
+		level = QuotaWarnPercent
+	}
+	if level > customer.QuotaNotified {
+		customer.QuotaNotified = level
+		changed = true
+		if level < 100 {
+			result.Warned++
+		}
+		if u.whatsappService != nil && customer.Phone != "" {
+			if err := u.whatsappService.SendQuotaNotification(customer, pkg, float64(used)/bytesPerGB, level); err != nil {
+				logger.Warn("Failed to send quota notification",
+					zap.Uint("customer_id", customer.ID),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return u.customerRepo.UpdateQuota(customer)
+}
+
+// StartChecking runs Check every interval. It returns a function that stops
+// the loop.
+func (u *QuotaUsecase) StartChecking(interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			result, err := u.Check(ctx)
+			if err != nil {
+				logger.Error("Quota check failed", zap.Error(err))
+				continue
+			}
+			if result.Warned+result.Throttled+result.Restored+result.Failed > 0 {
+				logger.Info("Quota check finished",
+					zap.Int("checked", result.Checked),
+					zap.Int("warned", result.Warned),
+					zap.Int("throttled", result.Throttled),
+					zap.Int("restored", result.Restored),
+					zap.Int("failed", result.Failed),
+				)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
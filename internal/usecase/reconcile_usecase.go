@@ -0,0 +1,389 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Drift types reported when the database and /ppp/secret disagree.
+const (
+	DriftMissingOnRouter  = "missing_on_router"
+	DriftMissingInDB      = "missing_in_db"
+	DriftProfileMismatch  = "profile_mismatch"
+	DriftDisabledMismatch = "disabled_mismatch"
+	DriftPasswordMismatch = "password_mismatch"
+)
+
+// Fix outcomes reported per drift item.
+const (
+	FixStatusPlanned = "planned"
+	FixStatusFixed   = "fixed"
+	FixStatusFailed  = "failed"
+	FixStatusSkipped = "skipped"
+)
+
+// DriftItem is one difference between a customer and its PPPoE secret.
+// Key identifies the item when requesting a fix.
+type DriftItem struct {
+	Key          string `json:"key"`
+	Type         string `json:"type"`
+	Username     string `json:"username"`
+	CustomerID   *uint  `json:"customer_id,omitempty"`
+	CustomerName string `json:"customer_name,omitempty"`
+	RouterValue  string `json:"router_value,omitempty"`
+	Expected     string `json:"expected,omitempty"`
+	Action       string `json:"action"`
+	Fixable      bool   `json:"fixable"`
+	Note         string `json:"note,omitempty"`
+
+	customer *entities.Customer
+	// keyOnly items are left out of a fix with All and must be selected by
+	// key, because their fix cannot be undone from the database.
+	keyOnly bool
+}
+
+type ReconcileReport struct {
+	RouterID   uint           `json:"router_id"`
+	RouterName string         `json:"router_name"`
+	CheckedAt  time.Time      `json:"checked_at"`
+	Customers  int            `json:"customers"`
+	Secrets    int            `json:"secrets"`
+	Summary    map[string]int `json:"summary"`
+	Items      []DriftItem    `json:"items"`
+}
+
+// ReconcileFixRequest selects drift items by key, or every item with All.
+// All does not fix missing_in_db items; they are reported as skipped, since
+// removing a secret always needs its key. With DryRun nothing is changed and each result is reported as planned.
+type ReconcileFixRequest struct {
+	Items  []string `json:"items"`
+	All    bool     `json:"all"`
+	DryRun bool     `json:"dry_run"`
+}
+
+type ReconcileFixResult struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	Username string `json:"username"`
+	Action   string `json:"action"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+type ReconcileFixResponse struct {
+	RouterID uint                 `json:"router_id"`
+	DryRun   bool                 `json:"dry_run"`
+	Fixed    int                  `json:"fixed"`
+	Failed   int                  `json:"failed"`
+	Skipped  int                  `json:"skipped"`
+	Results  []ReconcileFixResult `json:"results"`
+}
+
+// ReconcileUsecase compares customers with the PPPoE secrets on their
+// router and repairs the router side. The database is the source of truth.
+type ReconcileUsecase struct {
+	routerRepo     repositories.RouterRepository
+	customerRepo   repositories.CustomerRepository
+	mikrotikClient *mikrotik.MikroTikClient
+}
+
+func NewReconcileUsecase(routerRepo repositories.RouterRepository, customerRepo repositories.CustomerRepository, mikrotikClient *mikrotik.MikroTikClient) *ReconcileUsecase {
+	return &ReconcileUsecase{
+		routerRepo:     routerRepo,
+		customerRepo:   customerRepo,
+		mikrotikClient: mikrotikClient,
+	}
+}
+
+// isHashedPassword reports whether a stored PPPoE password is a bcrypt hash
+// (set by the portal password change) and so cannot be compared or pushed.
+func isHashedPassword(password string) bool {
+	return strings.HasPrefix(password, "$2a$") || strings.HasPrefix(password, "$2b$") || strings.HasPrefix(password, "$2y$")
+}
+
+// expectedProfile returns the secret profile a customer should have, or ""
+// when the package does not say. Routers isolating by address-list keep
+// isolated customers on their active profile.
+func expectedProfile(customer *entities.Customer, isolationMode string) string {
+	if customer.Package == nil {
+		return ""
+	}
+	switch customer.Status {
+	case "active":
+		return mikrotik.ActiveProfile(customer, customer.Package)
+	case "isolated":
+		if isolationMode == entities.IsolationAddressList {
+			return mikrotik.ActiveProfile(customer, customer.Package)
+		}
+		return customer.Package.ProfileIsolir
+	}
+	return ""
+}
+
+// expectedDisabled reports whether a customer's secret should be disabled.
+func expectedDisabled(customer *entities.Customer) bool {
+	return customer.Status == "inactive"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func newDriftItem(driftType, username string, customer *entities.Customer) DriftItem {
+	item := DriftItem{
+		Key:      driftType + ":" + username,
+		Type:     driftType,
+		Username: username,
+		Fixable:  true,
+		customer: customer,
+	}
+	if customer != nil {
+		id := customer.ID
+		item.CustomerID = &id
+		item.CustomerName = customer.Name
+	}
+	return item
+}
+
+// GetReport builds the drift report for one router.
+func (u *ReconcileUsecase) GetReport(ctx context.Context, routerID uint) (*ReconcileReport, error) {
+	router, err := u.routerRepo.FindByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+
+	customers, err := u.customerRepo.FindByRouterID(routerID)
+	if err != nil {
+		return nil, err
+	}
+	// Customers without a router are served by the active router; without
+	// them their secrets would be reported as missing in the database.
+	if router.IsActive {
+		unassigned, err := u.customerRepo.FindByRouterID(0)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, unassigned...)
+	}
+
+	secrets, err := u.mikrotikClient.GetAllUsers(ctx, routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	secretByName := make(map[string]*mikrotik.PPPoEUser, len(secrets))
+	for i := range secrets {
+		secretByName[secrets[i].Name] = &secrets[i]
+	}
+
+	report := &ReconcileReport{
+		RouterID:   router.ID,
+		RouterName: router.Name,
+		CheckedAt:  time.Now(),
+		Secrets:    len(secrets),
+		Summary:    make(map[string]int),
+		Items:      []DriftItem{},
+	}
+
+	known := make(map[string]bool, len(customers))
+	for _, customer := range customers {
+		if customer.PPPoEUsername == "" {
+			continue
+		}
+		report.Customers++
+		known[customer.PPPoEUsername] = true
+
+		secret, ok := secretByName[customer.PPPoEUsername]
+		if !ok {
+			if customer.Status == "inactive" {
+				continue
+			}
+			item := newDriftItem(DriftMissingOnRouter, customer.PPPoEUsername, customer)
+			item.Expected = expectedProfile(customer, router.IsolationMode)
+			item.Action = "create secret on router"
+			if isHashedPassword(customer.PPPoEPassword) || customer.PPPoEPassword == "" {
+				item.Fixable = false
+				item.Note = "customer has no usable PPPoE password"
+			}
+			report.Items = append(report.Items, item)
+			continue
+		}
+
+		if profile := expectedProfile(customer, router.IsolationMode); profile != "" && secret.Profile != profile {
+			item := newDriftItem(DriftProfileMismatch, customer.PPPoEUsername, customer)
+			item.RouterValue = secret.Profile
+			item.Expected = profile
+			item.Action = "set profile to " + profile
+			report.Items = append(report.Items, item)
+		}
+
+		if disabled := expectedDisabled(customer); secret.Disabled != disabled {
+			item := newDriftItem(DriftDisabledMismatch, customer.PPPoEUsername, customer)
+			item.RouterValue = yesNo(secret.Disabled)
+			item.Expected = yesNo(disabled)
+			item.Action = "set disabled=" + yesNo(disabled)
+			report.Items = append(report.Items, item)
+		}
+
+		if customer.PPPoEPassword != "" && !isHashedPassword(customer.PPPoEPassword) && secret.Password != customer.PPPoEPassword {
+			item := newDriftItem(DriftPasswordMismatch, customer.PPPoEUsername, customer)
+			item.Action = "set password from database"
+			report.Items = append(report.Items, item)
+		}
+	}
+
+	for i := range secrets {
+		secret := &secrets[i]
+		if known[secret.Name] {
+			continue
+		}
+		item := newDriftItem(DriftMissingInDB, secret.Name, nil)
+		item.RouterValue = secret.Profile
+		item.Action = "remove secret from router"
+		item.Note = "select by key to remove; not included in all"
+		item.keyOnly = true
+		report.Items = append(report.Items, item)
+	}
+
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		if report.Items[i].Type != report.Items[j].Type {
+			return report.Items[i].Type < report.Items[j].Type
+		}
+		return report.Items[i].Username < report.Items[j].Username
+	})
+	for _, item := range report.Items {
+		report.Summary[item.Type]++
+	}
+
+	return report, nil
+}
+
+// Fix repairs the selected drift items on the router. The report is rebuilt
+// first so only items that still drift are touched.
+func (u *ReconcileUsecase) Fix(ctx context.Context, routerID uint, req ReconcileFixRequest) (*ReconcileFixResponse, error) {
+	if !req.All && len(req.Items) == 0 {
+		return nil, fmt.Errorf("select items to fix or set all")
+	}
+
+	report, err := u.GetReport(ctx, routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ReconcileFixResponse{
+		RouterID: routerID,
+		DryRun:   req.DryRun,
+		Results:  []ReconcileFixResult{},
+	}
+
+	byKey := make(map[string]DriftItem, len(report.Items))
+	for _, item := range report.Items {
+		byKey[item.Key] = item
+	}
+
+	var selected []DriftItem
+	if req.All {
+		for _, item := range report.Items {
+			if item.keyOnly {
+				resp.Results = append(resp.Results, ReconcileFixResult{
+					Key:      item.Key,
+					Type:     item.Type,
+					Username: item.Username,
+					Action:   item.Action,
+					Status:   FixStatusSkipped,
+					Error:    item.Note,
+				})
+				resp.Skipped++
+				continue
+			}
+			selected = append(selected, item)
+		}
+	} else {
+		for _, key := range req.Items {
+			item, ok := byKey[key]
+			if !ok {
+				resp.Results = append(resp.Results, ReconcileFixResult{
+					Key:    key,
+					Status: FixStatusSkipped,
+					Error:  "item is no longer drifting",
+				})
+				resp.Skipped++
+				continue
+			}
+			selected = append(selected, item)
+		}
+	}
+
+	for _, item := range selected {
+		result := ReconcileFixResult{
+			Key:      item.Key,
+			Type:     item.Type,
+			Username: item.Username,
+			Action:   item.Action,
+		}
+
+		switch {
+		case !item.Fixable:
+			result.Status = FixStatusSkipped
+			result.Error = item.Note
+			resp.Skipped++
+		case req.DryRun:
+			result.Status = FixStatusPlanned
+		default:
+			if err := u.apply(ctx, routerID, item); err != nil {
+				result.Status = FixStatusFailed
+				result.Error = err.Error()
+				resp.Failed++
+			} else {
+				result.Status = FixStatusFixed
+				resp.Fixed++
+			}
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	if !req.DryRun {
+		logger.Info("Router reconciliation applied",
+			zap.Uint("router_id", routerID),
+			zap.Int("fixed", resp.Fixed),
+			zap.Int("failed", resp.Failed),
+			zap.Int("skipped", resp.Skipped),
+		)
+	}
+
+	return resp, nil
+}
+
+// apply performs the router change for one drift item.
+func (u *ReconcileUsecase) apply(ctx context.Context, routerID uint, item DriftItem) error {
+	switch item.Type {
+	case DriftMissingOnRouter:
+		profile := item.Expected
+		if profile == "" {
+			profile = "default"
+		}
+		return u.mikrotikClient.AddUser(ctx, routerID, item.Username, item.customer.PPPoEPassword, profile)
+	case DriftMissingInDB:
+		return u.mikrotikClient.RemoveUser(ctx, routerID, item.Username)
+	case DriftProfileMismatch:
+		return u.mikrotikClient.UpdateUser(ctx, routerID, item.Username, "=profile="+item.Expected)
+	case DriftDisabledMismatch:
+		return u.mikrotikClient.UpdateUser(ctx, routerID, item.Username, "=disabled="+item.Expected)
+	case DriftPasswordMismatch:
+		return u.mikrotikClient.UpdateUser(ctx, routerID, item.Username, "=password="+item.customer.PPPoEPassword)
+	}
+	return fmt.Errorf("unknown drift type %q", item.Type)
+}
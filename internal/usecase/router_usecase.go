@@ -1,6 +1,9 @@
 package usecase
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
 	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
@@ -13,11 +16,11 @@ type RouterUsecase interface {
 	Create(req *dto.RouterCreate) error
 	Update(id uint, req *dto.RouterUpdate) error
 	Delete(id uint) error
-	TestConnection(id uint) (*dto.ConnectionTestResult, error)
+	TestConnection(ctx context.Context, id uint) (*dto.ConnectionTestResult, error)
 	SetActive(id uint) error
 	GetActive() (*dto.RouterDetail, error)
-	GetStatus(id uint) (*dto.RouterStatus, error)
-	GetAllStatus() ([]*dto.RouterStatus, error)
+	GetStatus(ctx context.Context, id uint) (*dto.RouterStatus, error)
+	GetAllStatus(ctx context.Context) ([]*dto.RouterStatus, error)
 }
 
 type routerUsecase struct {
@@ -26,9 +29,11 @@ type routerUsecase struct {
 }
 
 type MikroTikClientInterface interface {
-	HealthCheck(routerID uint) error
-	GetRouterStatus(routerID uint) (*mikrotik.RouterStatus, error)
-	GetAllRoutersStatus() ([]mikrotik.RouterStatus, error)
+	HealthCheck(ctx context.Context, routerID uint) error
+	GetRouterStatus(ctx context.Context, routerID uint) (*mikrotik.RouterStatus, error)
+	GetAllRoutersStatus(ctx context.Context) ([]mikrotik.RouterStatus, error)
+	Reset(routerID uint)
+	Remove(routerID uint)
 }
 
 func NewRouterUsecase(routerRepo repositories.RouterRepository, mikrotikClient MikroTikClientInterface) RouterUsecase {
@@ -38,6 +43,24 @@ func NewRouterUsecase(routerRepo repositories.RouterRepository, mikrotikClient M
 	}
 }
 
+func toRouterDetail(router *entities.Router) *dto.RouterDetail {
+	return &dto.RouterDetail{
+		ID:             router.ID,
+		Name:           router.Name,
+		Host:           router.Host,
+		Username:       router.Username,
+		Port:           router.Port,
+		IsActive:       router.IsActive,
+		IsolationMode:  router.IsolationMode,
+		Transport:      router.Transport,
+		UseTLS:         router.UseTLS,
+		TLSCACert:      router.TLSCACert,
+		TLSFingerprint: router.TLSFingerprint,
+		CreatedAt:      router.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      router.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
 func (u *routerUsecase) GetAll() ([]*dto.RouterDetail, error) {
 	routers, err := u.routerRepo.FindAll()
 	if err != nil {
@@ -46,16 +69,7 @@ func (u *routerUsecase) GetAll() ([]*dto.RouterDetail, error) {
 
 	dtos := make([]*dto.RouterDetail, len(routers))
 	for i, router := range routers {
-		dtos[i] = &dto.RouterDetail{
-			ID:        router.ID,
-			Name:      router.Name,
-			Host:      router.Host,
-			Username:  router.Username,
-			Port:      router.Port,
-			IsActive:  router.IsActive,
-			CreatedAt: router.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt: router.UpdatedAt.Format("2006-01-02 15:04:05"),
-		}
+		dtos[i] = toRouterDetail(router)
 	}
 
 	return dtos, nil
@@ -67,26 +81,28 @@ func (u *routerUsecase) GetByID(id uint) (*dto.RouterDetail, error) {
 		return nil, err
 	}
 
-	return &dto.RouterDetail{
-		ID:        router.ID,
-		Name:      router.Name,
-		Host:      router.Host,
-		Username:  router.Username,
-		Port:      router.Port,
-		IsActive:  router.IsActive,
-		CreatedAt: router.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt: router.UpdatedAt.Format("2006-01-02 15:04:05"),
-	}, nil
+	return toRouterDetail(router), nil
 }
 
 func (u *routerUsecase) Create(req *dto.RouterCreate) error {
 	router := &entities.Router{
-		Name:     req.Name,
-		Host:     req.Host,
-		Username: req.Username,
-		Password: req.Password,
-		Port:     req.Port,
-		IsActive: req.IsActive,
+		Name:           req.Name,
+		Host:           req.Host,
+		Username:       req.Username,
+		Password:       req.Password,
+		Port:           req.Port,
+		IsActive:       req.IsActive,
+		IsolationMode:  req.IsolationMode,
+		Transport:      req.Transport,
+		UseTLS:         req.UseTLS,
+		TLSCACert:      req.TLSCACert,
+		TLSFingerprint: req.TLSFingerprint,
+	}
+	if err := validateIsolationMode(router); err != nil {
+		return err
+	}
+	if err := validateTransport(router); err != nil {
+		return err
 	}
 
 	return u.routerRepo.Create(router)
@@ -98,6 +114,11 @@ func (u *routerUsecase) Update(id uint, req *dto.RouterUpdate) error {
 		return err
 	}
 
+	if router.Transport == "" {
+		router.Transport = entities.TransportAPI
+	}
+	transport, useTLS := router.Transport, router.UseTLS
+
 	if req.Name != "" {
 		router.Name = req.Name
 	}
@@ -110,22 +131,108 @@ func (u *routerUsecase) Update(id uint, req *dto.RouterUpdate) error {
 	if req.Password != "" {
 		router.Password = req.Password
 	}
-	if req.Port != nil {
-		router.Port = *req.Port
-	}
 	if req.IsActive != nil {
 		router.IsActive = *req.IsActive
 	}
+	if req.IsolationMode != "" {
+		router.IsolationMode = req.IsolationMode
+	}
+	if req.Transport != "" {
+		router.Transport = req.Transport
+	}
+	if req.UseTLS != nil {
+		router.UseTLS = *req.UseTLS
+	}
+	if req.TLSCACert != nil {
+		router.TLSCACert = *req.TLSCACert
+	}
+	if req.TLSFingerprint != nil {
+		router.TLSFingerprint = *req.TLSFingerprint
+	}
+	// A port kept from another transport or TLS setting would point at the
+	// wrong service, so switching either without a port picks the new
+	// standard one.
+	switch {
+	case req.Port != nil:
+		router.Port = *req.Port
+	case router.Transport != transport || router.UseTLS != useTLS:
+		router.Port = 0
+	}
+	if err := validateIsolationMode(router); err != nil {
+		return err
+	}
+	if err := validateTransport(router); err != nil {
+		return err
+	}
+
+	if err := u.routerRepo.Update(router); err != nil {
+		return err
+	}
 
-	return u.routerRepo.Update(router)
+	// Drop the pooled session so new host or credentials take effect.
+	u.mikrotikClient.Reset(id)
+	return nil
+}
+
+// validateIsolationMode defaults the mode to profile swapping.
+func validateIsolationMode(router *entities.Router) error {
+	switch router.IsolationMode {
+	case "":
+		router.IsolationMode = entities.IsolationProfile
+	case entities.IsolationProfile, entities.IsolationAddressList:
+	default:
+		return fmt.Errorf("isolation_mode must be %q or %q", entities.IsolationProfile, entities.IsolationAddressList)
+	}
+	return nil
+}
+
+// validateTransport defaults the transport to the binary API and an unset
+// port to the transport's standard one, and checks the TLS settings.
+func validateTransport(router *entities.Router) error {
+	switch router.Transport {
+	case "":
+		router.Transport = entities.TransportAPI
+	case entities.TransportAPI, entities.TransportREST:
+	default:
+		return fmt.Errorf("transport must be %q or %q", entities.TransportAPI, entities.TransportREST)
+	}
+	if router.Port == 0 {
+		switch {
+		case router.Transport == entities.TransportREST:
+			router.Port = 443
+		case router.UseTLS:
+			router.Port = 8729
+		default:
+			router.Port = 8728
+		}
+	}
+
+	if router.TLSFingerprint != "" {
+		fp, err := mikrotik.NormalizeFingerprint(router.TLSFingerprint)
+		if err != nil {
+			return fmt.Errorf("tls_fingerprint: %w", err)
+		}
+		router.TLSFingerprint = fp
+	}
+	if router.TLSCACert != "" {
+		if _, err := mikrotik.ParseCACert(router.TLSCACert); err != nil {
+			return fmt.Errorf("tls_ca_cert: %w", err)
+		}
+	}
+	return nil
 }
 
 func (u *routerUsecase) Delete(id uint) error {
-	return u.routerRepo.Delete(id)
+	if err := u.routerRepo.Delete(id); err != nil {
+		return err
+	}
+
+	u.mikrotikClient.Remove(id)
+	return nil
 }
 
-func (u *routerUsecase) TestConnection(id uint) (*dto.ConnectionTestResult, error) {
-	err := u.mikrotikClient.HealthCheck(id)
+func (u *routerUsecase) TestConnection(ctx context.Context, id uint) (*dto.ConnectionTestResult, error) {
+	err := u.mikrotikClient.HealthCheck(ctx, id)
 	if err != nil {
 		return &dto.ConnectionTestResult{
 			Success: false,
@@ -163,59 +270,59 @@ func (u *routerUsecase) GetActive() (*dto.RouterDetail, error) {
 		return nil, err
 	}
 
-	return &dto.RouterDetail{
-		ID:        router.ID,
-		Name:      router.Name,
-		Host:      router.Host,
-		Username:  router.Username,
-		Port:      router.Port,
-		IsActive:  router.IsActive,
-		CreatedAt: router.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt: router.UpdatedAt.Format("2006-01-02 15:04:05"),
-	}, nil
+	return toRouterDetail(router), nil
 }
 
-func (u *routerUsecase) GetStatus(id uint) (*dto.RouterStatus, error) {
-	status, err := u.mikrotikClient.GetRouterStatus(id)
+func (u *routerUsecase) GetStatus(ctx context.Context, id uint) (*dto.RouterStatus, error) {
+	status, err := u.mikrotikClient.GetRouterStatus(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	return &dto.RouterStatus{
-		ID:          status.RouterID,
-		Name:        status.Name,
-		Host:        status.Host,
-		Status:      status.Status,
-		LastCheck:   status.LastCheck.Format("2006-01-02 15:04:05"),
-		ActiveUsers: status.ActiveUsers,
-		CPU:         status.CPU,
-		Memory:      status.Memory,
-		Uptime:      status.Uptime,
-		Error:       status.Error,
-	}, nil
+	return toRouterStatusDTO(status), nil
 }
 
-func (u *routerUsecase) GetAllStatus() ([]*dto.RouterStatus, error) {
-	statuses, err := u.mikrotikClient.GetAllRoutersStatus()
+func (u *routerUsecase) GetAllStatus(ctx context.Context) ([]*dto.RouterStatus, error) {
+	statuses, err := u.mikrotikClient.GetAllRoutersStatus(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	dtos := make([]*dto.RouterStatus, len(statuses))
 	for i, status := range statuses {
-		dtos[i] = &dto.RouterStatus{
-			ID:          status.RouterID,
-			Name:        status.Name,
-			Host:        status.Host,
-			Status:      status.Status,
-			LastCheck:   status.LastCheck.Format("2006-01-02 15:04:05"),
-			ActiveUsers: status.ActiveUsers,
-			CPU:         status.CPU,
-			Memory:      status.Memory,
-			Uptime:      status.Uptime,
-			Error:       status.Error,
-		}
+		dtos[i] = toRouterStatusDTO(&status)
 	}
 
 	return dtos, nil
 }
+
+// toRouterStatusDTO maps a live router status, including its pool
+// connection state, to the API representation.
+func toRouterStatusDTO(status *mikrotik.RouterStatus) *dto.RouterStatus {
+	result := &dto.RouterStatus{
+		ID:                status.RouterID,
+		Name:              status.Name,
+		Host:              status.Host,
+		Status:            status.Status,
+		LastCheck:         status.LastCheck.Format("2006-01-02 15:04:05"),
+		ActiveUsers:       status.ActiveUsers,
+		CPU:               status.CPU,
+		Memory:            status.Memory,
+		Uptime:            status.Uptime,
+		Error:             status.Error,
+		ConnectionState:   status.ConnectionState,
+		LastError:         status.LastError,
+		ReconnectFailures: status.ReconnectFailures,
+		CertificateError:  status.CertificateError,
+	}
+	if status.LastErrorAt != nil {
+		result.LastErrorAt = status.LastErrorAt.Format("2006-01-02 15:04:05")
+	}
+	if status.ConnectedAt != nil {
+		result.ConnectedAt = status.ConnectedAt.Format("2006-01-02 15:04:05")
+	}
+	if status.NextRetry != nil {
+		result.NextRetry = status.NextRetry.Format("2006-01-02 15:04:05")
+	}
+	return result
+}
@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// ScheduleRunResult summarizes one pass of the speed scheduler. Changed
+// counts normal profiles whose rate-limit was switched. Conflicts lists
+// profiles left alone because packages sharing them want different speeds.
+type ScheduleRunResult struct {
+	Routers      int      `json:"routers"`
+	Changed      int      `json:"changed"`
+	Failed       int      `json:"failed"`
+	Disconnected int      `json:"disconnected"`
+	Conflicts    []string `json:"conflicts,omitempty"`
+}
+
+// ScheduleUsecase applies package speed schedules by setting the rate-limit
+// of each package's normal profile on every router. It compares the
+// routers with the speed due now rather than acting on window edges, so a
+// boundary missed while the server was down is caught up on the next run.
+type ScheduleUsecase struct {
+	packageRepo    repositories.PackageRepository
+	routerRepo     repositories.RouterRepository
+	mikrotikClient *mikrotik.MikroTikClient
+	reconnect      bool
+
+	// mu serializes runs so a manual run does not race the scheduler.
+	mu sync.Mutex
+	// conflicts holds the profiles last reported as conflicting, so each
+	// conflict is logged once rather than on every run.
+	conflicts map[string]bool
+}
+
+// NewScheduleUsecase drops the sessions on a profile after changing its
+// rate-limit when reconnect is set, so the new speed applies at once
+// instead of on the customer's next login.
+func NewScheduleUsecase(packageRepo repositories.PackageRepository, routerRepo repositories.RouterRepository, mikrotikClient *mikrotik.MikroTikClient, reconnect bool) *ScheduleUsecase {
+	return &ScheduleUsecase{
+		packageRepo:    packageRepo,
+		routerRepo:     routerRepo,
+		mikrotikClient: mikrotikClient,
+		reconnect:      reconnect,
+		conflicts:      make(map[string]bool),
+	}
+}
+
+// Apply brings the normal profile of every scheduled package to the speed
+// due now on every router. Routers that cannot be reached are counted as
+// failed and retried on the next run. A profile shared by packages that
+// want different speeds now is skipped, as either speed would be wrong for
+// the other package's customers.
+func (u *ScheduleUsecase) Apply(ctx context.Context) (*ScheduleRunResult, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	packages, err := u.packageRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	want := make(map[string]string)
+	scheduled := make(map[string]bool)
+	conflicts := make(map[string]bool)
+	for _, pkg := range packages {
+		if pkg.ProfileNormal == "" {
+			continue
+		}
+		// Packages without schedules count too: they share the profile at
+		// their own speed.
+		speed, _ := ScheduledSpeed(pkg, now)
+		rateLimit, err := mikrotik.ParseRateLimit(speed)
+		if err != nil || rateLimit == "" {
+			continue
+		}
+		if current, ok := want[pkg.ProfileNormal]; ok && current != rateLimit {
+			conflicts[pkg.ProfileNormal] = true
+		}
+		want[pkg.ProfileNormal] = rateLimit
+		if len(pkg.Schedules) > 0 {
+			scheduled[pkg.ProfileNormal] = true
+		}
+	}
+	for profile := range want {
+		if !scheduled[profile] {
+			delete(want, profile)
+		}
+	}
+
+	routers, err := u.routerRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	result := &ScheduleRunResult{Routers: len(routers)}
+	for profile := range conflicts {
+		if !scheduled[profile] {
+			continue
+		}
+		delete(want, profile)
+		result.Conflicts = append(result.Conflicts, profile)
+		if !u.conflicts[profile] {
+			logger.Warn("Packages sharing a profile want different speeds, schedule skipped",
+				zap.String("profile", profile),
+			)
+		}
+	}
+	sort.Strings(result.Conflicts)
+	u.conflicts = make(map[string]bool, len(result.Conflicts))
+	for _, profile := range result.Conflicts {
+		u.conflicts[profile] = true
+	}
+	if len(want) == 0 {
+		return result, nil
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, router := range routers {
+		wg.Add(1)
+		go func(router *entities.Router) {
+			defer wg.Done()
+			changed, disconnected, err := u.applyRouter(ctx, router.ID, want)
+			mu.Lock()
+			defer mu.Unlock()
+			result.Changed += changed
+			result.Disconnected += disconnected
+			if err != nil {
+				logger.Warn("Speed schedule failed",
+					zap.Uint("router_id", router.ID),
+					zap.Error(err),
+				)
+				result.Failed++
+			}
+		}(router)
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+// applyRouter sets the rate-limit of each profile in want that differs on
+// one router. Profiles the router does not have are left to provisioning.
+func (u *ScheduleUsecase) applyRouter(ctx context.Context, routerID uint, want map[string]string) (int, int, error) {
+	profiles, err := u.mikrotikClient.GetAllProfiles(ctx, routerID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var changed []string
+	var firstErr error
+	for _, profile := range profiles {
+		rateLimit, ok := want[profile.Name]
+		if !ok || profile.RateLimit == rateLimit {
+			continue
+		}
+		if _, err := u.mikrotikClient.UpsertProfile(ctx, routerID, profile.Name, "=rate-limit="+rateLimit); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		logger.Info("Scheduled speed applied",
+			zap.Uint("router_id", routerID),
+			zap.String("profile", profile.Name),
+			zap.String("from", profile.RateLimit),
+			zap.String("to", rateLimit),
+		)
+		changed = append(changed, profile.Name)
+	}
+
+	disconnected := 0
+	if u.reconnect && len(changed) > 0 {
+		n, err := u.disconnectProfiles(ctx, routerID, changed)
+		disconnected = n
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return len(changed), disconnected, firstErr
+}
+
+// disconnectProfiles drops the active sessions of secrets on the given
+// profiles so they log in again with the new rate-limit.
+func (u *ScheduleUsecase) disconnectProfiles(ctx context.Context, routerID uint, profiles []string) (int, error) {
+	onProfile := make(map[string]bool, len(profiles))
+	for _, p := range profiles {
+		onProfile[p] = true
+	}
+	users, err := u.mikrotikClient.GetAllUsers(ctx, routerID)
+	if err != nil {
+		return 0, err
+	}
+	affected := make(map[string]bool)
+	for _, user := range users {
+		if onProfile[user.Profile] {
+			affected[user.Name] = true
+		}
+	}
+	sessions, err := u.mikrotikClient.GetActiveSessions(ctx, routerID)
+	if err != nil {
+		return 0, err
+	}
+
+	disconnected := 0
+	for _, session := range sessions {
+		if !affected[session.Name] {
+			continue
+		}
+		if err := u.mikrotikClient.DisconnectUser(ctx, routerID, session.Name); err != nil {
+			logger.Warn("Failed to reconnect session for speed schedule",
+				zap.Uint("router_id", routerID),
+				zap.String("username", session.Name),
+				zap.Error(err),
+			)
+			continue
+		}
+		disconnected++
+	}
+	return disconnected, nil
+}
+
+// StartScheduling runs Apply once right away, which restores the right
+// speeds after a restart, and then every interval. It returns a function
+// that stops the loop.
+func (u *ScheduleUsecase) StartScheduling(interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			result, err := u.Apply(ctx)
+			if err != nil {
+				logger.Error("Speed schedules failed", zap.Error(err))
+			} else if result.Changed > 0 || result.Failed > 0 {
+				logger.Info("Speed schedules applied",
+					zap.Int("changed", result.Changed),
+					zap.Int("failed", result.Failed),
+					zap.Int("disconnected", result.Disconnected),
+				)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
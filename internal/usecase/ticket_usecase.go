@@ -6,17 +6,20 @@ import (
 
 	"github.com/alijayanet/gembok-backend/internal/domain/entities"
 	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/webhook"
 )
 
 type TroubleTicketUsecase struct {
 	ticketRepo   repositories.TroubleTicketRepository
 	customerRepo repositories.CustomerRepository
+	webhooks     *webhook.Dispatcher
 }
 
-func NewTroubleTicketUsecase(ticketRepo repositories.TroubleTicketRepository, customerRepo repositories.CustomerRepository) *TroubleTicketUsecase {
+func NewTroubleTicketUsecase(ticketRepo repositories.TroubleTicketRepository, customerRepo repositories.CustomerRepository, webhooks *webhook.Dispatcher) *TroubleTicketUsecase {
 	return &TroubleTicketUsecase{
 		ticketRepo:   ticketRepo,
 		customerRepo: customerRepo,
+		webhooks:     webhooks,
 	}
 }
 
@@ -54,6 +57,10 @@ func (u *TroubleTicketUsecase) Create(req CreateTicketRequest) (*entities.Troubl
 		return nil, fmt.Errorf("failed to create ticket: %w", err)
 	}
 
+	if u.webhooks != nil {
+		u.webhooks.Publish(webhook.EventTicketCreated, ticket)
+	}
+
 	return ticket, nil
 }
 
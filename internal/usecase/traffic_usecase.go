@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// TrafficInterval is the cadence of streamed traffic samples.
+	TrafficInterval = time.Second
+
+	// trafficMaxErrors ends a stream after this many failed readings in a
+	// row, e.g. when a PPPoE session goes away.
+	trafficMaxErrors = 5
+)
+
+// TrafficEvent is one streamed reading, or the error that replaced it.
+type TrafficEvent struct {
+	Sample *mikrotik.TrafficSample
+	Err    error
+}
+
+// TrafficTarget is the router interface a stream watches.
+type TrafficTarget struct {
+	RouterID   uint   `json:"router_id"`
+	Interface  string `json:"interface"`
+	CustomerID uint   `json:"customer_id,omitempty"`
+}
+
+type TrafficUsecase struct {
+	routerRepo     repositories.RouterRepository
+	customerRepo   repositories.CustomerRepository
+	mikrotikClient *mikrotik.MikroTikClient
+	streams        atomic.Int64
+}
+
+func NewTrafficUsecase(routerRepo repositories.RouterRepository, customerRepo repositories.CustomerRepository, mikrotikClient *mikrotik.MikroTikClient) *TrafficUsecase {
+	return &TrafficUsecase{
+		routerRepo:     routerRepo,
+		customerRepo:   customerRepo,
+		mikrotikClient: mikrotikClient,
+	}
+}
+
+// InterfaceTarget resolves an interface on a router; router 0 is the
+// active router.
+func (u *TrafficUsecase) InterfaceTarget(routerID uint, iface string) (*TrafficTarget, error) {
+	if iface == "" {
+		return nil, fmt.Errorf("interface is required")
+	}
+	if routerID == 0 {
+		router, err := u.routerRepo.FindActive()
+		if err != nil {
+			return nil, fmt.Errorf("no active router found")
+		}
+		routerID = router.ID
+	} else if _, err := u.routerRepo.FindByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+	return &TrafficTarget{RouterID: routerID, Interface: iface}, nil
+}
+
+// CustomerTarget resolves a customer's PPPoE session interface on the
+// customer's router, or the active router when none is assigned.
+func (u *TrafficUsecase) CustomerTarget(customerID uint) (*TrafficTarget, error) {
+	customer, err := u.customerRepo.FindByID(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("customer not found")
+	}
+	if customer.PPPoEUsername == "" {
+		return nil, fmt.Errorf("customer has no PPPoE username")
+	}
+	routerID, err := u.mikrotikClient.CustomerRouterID(customer)
+	if err != nil {
+		return nil, err
+	}
+	return &TrafficTarget{
+		RouterID:   routerID,
+		Interface:  mikrotik.PPPoEInterfaceName(customer.PPPoEUsername),
+		CustomerID: customer.ID,
+	}, nil
+}
+
+func (u *TrafficUsecase) Sample(ctx context.Context, target *TrafficTarget) (*mikrotik.TrafficSample, error) {
+	return u.mikrotikClient.MonitorTraffic(ctx, target.RouterID, target.Interface)
+}
+
+// Watch samples the target every TrafficInterval until ctx is done or the
+// router keeps failing. The channel is closed when watching stops.
+func (u *TrafficUsecase) Watch(ctx context.Context, target *TrafficTarget) <-chan TrafficEvent {
+	events := make(chan TrafficEvent)
+
+	go func() {
+		defer close(events)
+
+		active := u.streams.Add(1)
+		defer u.streams.Add(-1)
+		logger.Info("Traffic stream started",
+			zap.Uint("router_id", target.RouterID),
+			zap.String("interface", target.Interface),
+			zap.Int64("active_streams", active),
+		)
+		defer logger.Info("Traffic stream stopped",
+			zap.Uint("router_id", target.RouterID),
+			zap.String("interface", target.Interface),
+		)
+
+		ticker := time.NewTicker(TrafficInterval)
+		defer ticker.Stop()
+
+		failures := 0
+		for {
+			sample, err := u.mikrotikClient.MonitorTraffic(ctx, target.RouterID, target.Interface)
+
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				failures++
+			} else {
+				failures = 0
+			}
+
+			select {
+			case events <- TrafficEvent{Sample: sample, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if failures >= trafficMaxErrors {
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// ActiveStreams returns how many traffic streams are open.
+func (u *TrafficUsecase) ActiveStreams() int64 {
+	return u.streams.Load()
+}
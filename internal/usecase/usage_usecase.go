@@ -0,0 +1,321 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const defaultUsageTopLimit = 50
+
+// UsagePoint is a customer's traffic in one hour, day or month.
+type UsagePoint struct {
+	PeriodStart   time.Time `json:"period_start"`
+	UploadBytes   int64     `json:"upload_bytes"`
+	DownloadBytes int64     `json:"download_bytes"`
+	TotalBytes    int64     `json:"total_bytes"`
+}
+
+// UsageHistory is a customer's usage time series with its totals.
+type UsageHistory struct {
+	CustomerID    uint         `json:"customer_id"`
+	Period        string       `json:"period"`
+	From          time.Time    `json:"from"`
+	To            time.Time    `json:"to"`
+	Points        []UsagePoint `json:"points"`
+	UploadBytes   int64        `json:"upload_bytes"`
+	DownloadBytes int64        `json:"download_bytes"`
+	TotalBytes    int64        `json:"total_bytes"`
+}
+
+// CustomerUsage is one customer's traffic in the period of a usage ranking.
+type CustomerUsage struct {
+	CustomerID    uint   `json:"customer_id"`
+	Name          string `json:"name"`
+	PPPoEUsername string `json:"pppoe_username"`
+	UploadBytes   int64  `json:"upload_bytes"`
+	DownloadBytes int64  `json:"download_bytes"`
+	TotalBytes    int64  `json:"total_bytes"`
+}
+
+type UsageUsecase struct {
+	routerRepo     repositories.RouterRepository
+	customerRepo   repositories.CustomerRepository
+	usageRepo      repositories.UsageRepository
+	mikrotikClient *mikrotik.MikroTikClient
+}
+
+func NewUsageUsecase(routerRepo repositories.RouterRepository, customerRepo repositories.CustomerRepository, usageRepo repositories.UsageRepository, mikrotikClient *mikrotik.MikroTikClient) *UsageUsecase {
+	return &UsageUsecase{
+		routerRepo:     routerRepo,
+		customerRepo:   customerRepo,
+		usageRepo:      usageRepo,
+		mikrotikClient: mikrotikClient,
+	}
+}
+
+// UsagePeriodStart returns the start of the hour, day or month containing t.
+func UsagePeriodStart(period string, t time.Time) (time.Time, error) {
+	switch period {
+	case entities.UsageHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()), nil
+	case entities.UsageDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+	case entities.UsageMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("period must be hour, day or month")
+}
+
+// counterDelta returns the bytes transferred since prev. A different session
+// ID or a counter that went backwards means the customer reconnected and the
+// counters restarted from zero, so the whole current count is new traffic.
+func counterDelta(prev *entities.UsageCounter, cur mikrotik.SessionCounter) (rx, tx int64) {
+	if prev == nil || prev.SessionID != cur.SessionID || cur.RxBytes < prev.RxBytes || cur.TxBytes < prev.TxBytes {
+		return cur.RxBytes, cur.TxBytes
+	}
+	return cur.RxBytes - prev.RxBytes, cur.TxBytes - prev.TxBytes
+}
+
+// Collect samples session counters on every router and adds the traffic
+// since the previous sample to each customer's hourly, daily and monthly
+// usage. It returns how many customers had new traffic. Routers that cannot
+// be reached are skipped.
+func (u *UsageUsecase) Collect(ctx context.Context) (int, error) {
+	routers, err := u.routerRepo.FindAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		mu      sync.Mutex
+		updated int
+		wg      sync.WaitGroup
+	)
+	for _, router := range routers {
+		wg.Add(1)
+		go func(router *entities.Router) {
+			defer wg.Done()
+			n, err := u.collectRouter(ctx, router.ID)
+			if err != nil {
+				logger.Warn("Usage collection failed",
+					zap.Uint("router_id", router.ID),
+					zap.Error(err),
+				)
+				return
+			}
+			mu.Lock()
+			updated += n
+			mu.Unlock()
+		}(router)
+	}
+	wg.Wait()
+
+	return updated, nil
+}
+
+func (u *UsageUsecase) collectRouter(ctx context.Context, routerID uint) (int, error) {
+	sessions, err := u.mikrotikClient.GetSessionCounters(ctx, routerID)
+	if err != nil {
+		return 0, err
+	}
+	stored, err := u.usageRepo.FindCounters(routerID)
+	if err != nil {
+		return 0, err
+	}
+	previous := make(map[string]*entities.UsageCounter, len(stored))
+	for _, counter := range stored {
+		previous[counter.Username] = counter
+	}
+	customers, err := u.customerRepo.FindByRouterID(routerID)
+	if err != nil {
+		return 0, err
+	}
+	byUsername := make(map[string]*entities.Customer, len(customers))
+	for _, customer := range customers {
+		if customer.PPPoEUsername != "" {
+			byUsername[customer.PPPoEUsername] = customer
+		}
+	}
+
+	now := time.Now()
+	updated := 0
+	counters := make([]*entities.UsageCounter, 0, len(sessions))
+	for _, session := range sessions {
+		rx, tx := counterDelta(previous[session.Username], session)
+		if rx > 0 || tx > 0 {
+			// Customers without a router assigned use whichever router
+			// they dial into.
+			customer, ok := byUsername[session.Username]
+			if !ok {
+				customer, _ = u.customerRepo.FindByPPPoEUsername(session.Username)
+			}
+			if customer != nil {
+				if err := u.addUsage(customer.ID, now, rx, tx); err != nil {
+					// Keep the previous counter so the traffic is
+					// picked up again on the next sample.
+					logger.Warn("Failed to record usage",
+						zap.Uint("customer_id", customer.ID),
+						zap.Error(err),
+					)
+					continue
+				}
+				updated++
+			}
+		}
+
+		counters = append(counters, &entities.UsageCounter{
+			RouterID:  routerID,
+			Username:  session.Username,
+			SessionID: session.SessionID,
+			RxBytes:   session.RxBytes,
+			TxBytes:   session.TxBytes,
+			SampledAt: now,
+		})
+	}
+
+	if err := u.usageRepo.SaveCounters(counters); err != nil {
+		return updated, err
+	}
+	return updated, nil
+}
+
+// addUsage records traffic in every aggregate. The router's rx is the
+// customer's upload and its tx their download.
+func (u *UsageUsecase) addUsage(customerID uint, at time.Time, rx, tx int64) error {
+	for _, period := range []string{entities.UsageHour, entities.UsageDay, entities.UsageMonth} {
+		start, _ := UsagePeriodStart(period, at)
+		if err := u.usageRepo.AddUsage(customerID, period, start, rx, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartCollecting runs Collect every interval. It returns a function that
+// stops the loop.
+func (u *UsageUsecase) StartCollecting(interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if _, err := u.Collect(ctx); err != nil {
+				logger.Error("Usage collection failed", zap.Error(err))
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// History returns a customer's usage per period between from and to. Zero
+// bounds default to the last 24 hours, 30 days or 12 months.
+func (u *UsageUsecase) History(customerID uint, period string, from, to time.Time) (*UsageHistory, error) {
+	if period == "" {
+		period = entities.UsageDay
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		switch period {
+		case entities.UsageHour:
+			from = to.Add(-24 * time.Hour)
+		case entities.UsageDay:
+			from = to.AddDate(0, 0, -30)
+		default:
+			from = to.AddDate(0, -11, 0)
+		}
+	}
+	from, err := UsagePeriodStart(period, from)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.customerRepo.FindByID(customerID); err != nil {
+		return nil, fmt.Errorf("customer not found")
+	}
+
+	records, err := u.usageRepo.FindUsage(customerID, period, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &UsageHistory{
+		CustomerID: customerID,
+		Period:     period,
+		From:       from,
+		To:         to,
+		Points:     make([]UsagePoint, 0, len(records)),
+	}
+	for _, r := range records {
+		history.Points = append(history.Points, UsagePoint{
+			PeriodStart:   r.PeriodStart,
+			UploadBytes:   r.UploadBytes,
+			DownloadBytes: r.DownloadBytes,
+			TotalBytes:    r.UploadBytes + r.DownloadBytes,
+		})
+		history.UploadBytes += r.UploadBytes
+		history.DownloadBytes += r.DownloadBytes
+	}
+	history.TotalBytes = history.UploadBytes + history.DownloadBytes
+	return history, nil
+}
+
+// Top ranks customers by traffic in the period containing at.
+func (u *UsageUsecase) Top(period string, at time.Time, limit int) ([]CustomerUsage, error) {
+	if period == "" {
+		period = entities.UsageMonth
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if limit <= 0 {
+		limit = defaultUsageTopLimit
+	}
+	start, err := UsagePeriodStart(period, at)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := u.usageRepo.FindTop(period, start, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]CustomerUsage, 0, len(records))
+	for _, r := range records {
+		usage := CustomerUsage{
+			CustomerID:    r.CustomerID,
+			UploadBytes:   r.UploadBytes,
+			DownloadBytes: r.DownloadBytes,
+			TotalBytes:    r.UploadBytes + r.DownloadBytes,
+		}
+		if customer, err := u.customerRepo.FindByID(r.CustomerID); err == nil {
+			usage.Name = customer.Name
+			usage.PPPoEUsername = customer.PPPoEUsername
+		}
+		result = append(result, usage)
+	}
+	return result, nil
+}
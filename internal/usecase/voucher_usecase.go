@@ -0,0 +1,527 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/mikrotik"
+	"github.com/alijayanet/gembok-backend/pkg/logger"
+	"github.com/alijayanet/gembok-backend/pkg/qrcode"
+	"go.uber.org/zap"
+)
+
+// Voucher sale statuses.
+const (
+	VoucherStatusAvailable = "available"
+	VoucherStatusSold      = "sold"
+)
+
+const (
+	// MaxVoucherBatch bounds a single generation request.
+	MaxVoucherBatch = 500
+
+	defaultUsernamePattern = "??####"
+	usernameAttempts       = 5
+)
+
+// Pattern characters: '#' is a digit, '?' a lowercase letter and '*' a
+// letter or digit; anything else is copied as is. Letters and digits that
+// are easily confused on paper (0, 1, i, l, o) are left out.
+const (
+	voucherDigits   = "23456789"
+	voucherLetters  = "abcdefghjkmnpqrstuvwxyz"
+	voucherAlphaNum = voucherDigits + voucherLetters
+)
+
+var routerOSDuration = regexp.MustCompile(`^(\d+[wdhms])+$|^\d+:\d{2}:\d{2}$`)
+
+// VoucherBatchRequest generates Count hotspot users on a router. An empty
+// PasswordPattern makes each password equal to its username. TimeLimit is a
+// RouterOS duration ("3h", "1d"); DataLimit is a size such as "500M" or "2G".
+type VoucherBatchRequest struct {
+	RouterID        uint    `json:"router_id"`
+	Name            string  `json:"name"`
+	Profile         string  `json:"profile" binding:"required"`
+	Server          string  `json:"server"`
+	Count           int     `json:"count" binding:"required"`
+	UsernamePattern string  `json:"username_pattern"`
+	PasswordPattern string  `json:"password_pattern"`
+	TimeLimit       string  `json:"time_limit"`
+	DataLimit       string  `json:"data_limit"`
+	Price           float64 `json:"price"`
+	LoginURL        string  `json:"login_url"`
+}
+
+// VoucherFailure is a voucher that could not be written to the router.
+type VoucherFailure struct {
+	Username string `json:"username"`
+	Error    string `json:"error"`
+}
+
+type VoucherBatchResult struct {
+	Batch  *entities.VoucherBatch `json:"batch"`
+	Failed []VoucherFailure       `json:"failed"`
+}
+
+type VoucherUsecase struct {
+	voucherRepo    repositories.VoucherRepository
+	routerRepo     repositories.RouterRepository
+	hotspotService *mikrotik.HotspotService
+	appName        string
+}
+
+func NewVoucherUsecase(voucherRepo repositories.VoucherRepository, routerRepo repositories.RouterRepository, hotspotService *mikrotik.HotspotService, appName string) *VoucherUsecase {
+	return &VoucherUsecase{
+		voucherRepo:    voucherRepo,
+		routerRepo:     routerRepo,
+		hotspotService: hotspotService,
+		appName:        appName,
+	}
+}
+
+// expandPattern fills the pattern placeholders with random characters.
+func expandPattern(pattern string) (string, error) {
+	buf := make([]byte, len(pattern))
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate voucher: %w", err)
+	}
+
+	var sb strings.Builder
+	for i, ch := range []byte(pattern) {
+		switch ch {
+		case '#':
+			sb.WriteByte(voucherDigits[int(buf[i])%len(voucherDigits)])
+		case '?':
+			sb.WriteByte(voucherLetters[int(buf[i])%len(voucherLetters)])
+		case '*':
+			sb.WriteByte(voucherAlphaNum[int(buf[i])%len(voucherAlphaNum)])
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String(), nil
+}
+
+// patternSpace returns how many distinct values a pattern can produce,
+// capped to avoid overflow.
+func patternSpace(pattern string) int {
+	space := 1
+	for _, ch := range pattern {
+		switch ch {
+		case '#':
+			space *= len(voucherDigits)
+		case '?':
+			space *= len(voucherLetters)
+		case '*':
+			space *= len(voucherAlphaNum)
+		}
+		if space > 1<<30 {
+			return 1 << 30
+		}
+	}
+	return space
+}
+
+// parseDataLimit converts sizes like "500M", "2G" or "1048576" to bytes.
+func parseDataLimit(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+	s = strings.TrimSuffix(s, "B")
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid data limit %q", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// uniqueUsernames generates count usernames that are unique within the
+// batch and not yet used on the router.
+func (u *VoucherUsecase) uniqueUsernames(routerID uint, pattern string, count int) ([]string, error) {
+	taken := make(map[string]bool, count)
+	var names []string
+
+	for attempt := 0; attempt < usernameAttempts && len(names) < count; attempt++ {
+		var candidates []string
+		for len(candidates) < count-len(names) {
+			name, err := expandPattern(pattern)
+			if err != nil {
+				return nil, err
+			}
+			if taken[name] {
+				continue
+			}
+			taken[name] = true
+			candidates = append(candidates, name)
+		}
+
+		existing, err := u.voucherRepo.FindExistingUsernames(routerID, candidates)
+		if err != nil {
+			return nil, err
+		}
+		used := make(map[string]bool, len(existing))
+		for _, name := range existing {
+			used[name] = true
+		}
+		for _, name := range candidates {
+			if !used[name] {
+				names = append(names, name)
+			}
+		}
+	}
+
+	if len(names) < count {
+		return nil, fmt.Errorf("username pattern %q does not leave enough unused names", pattern)
+	}
+	return names, nil
+}
+
+// Generate creates a batch of vouchers, writes them to /ip/hotspot/user and
+// stores the ones the router accepted. RouterID 0 uses the active router.
+func (u *VoucherUsecase) Generate(ctx context.Context, req VoucherBatchRequest, createdBy *uint) (*VoucherBatchResult, error) {
+	if req.Count < 1 || req.Count > MaxVoucherBatch {
+		return nil, fmt.Errorf("count must be between 1 and %d", MaxVoucherBatch)
+	}
+	if req.UsernamePattern == "" {
+		req.UsernamePattern = defaultUsernamePattern
+	}
+	if patternSpace(req.UsernamePattern) < req.Count*4 {
+		return nil, fmt.Errorf("username pattern %q is too short for %d vouchers", req.UsernamePattern, req.Count)
+	}
+	if req.TimeLimit != "" && !routerOSDuration.MatchString(req.TimeLimit) {
+		return nil, fmt.Errorf("invalid time limit %q", req.TimeLimit)
+	}
+	dataLimit, err := parseDataLimit(req.DataLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var router *entities.Router
+	if req.RouterID == 0 {
+		router, err = u.routerRepo.FindActive()
+	} else {
+		router, err = u.routerRepo.FindByID(req.RouterID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+
+	usernames, err := u.uniqueUsernames(router.ID, req.UsernamePattern, req.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &entities.VoucherBatch{
+		RouterID:        router.ID,
+		Name:            req.Name,
+		Profile:         req.Profile,
+		Server:          req.Server,
+		Count:           req.Count,
+		UsernamePattern: req.UsernamePattern,
+		PasswordPattern: req.PasswordPattern,
+		TimeLimit:       req.TimeLimit,
+		DataLimit:       dataLimit,
+		Price:           req.Price,
+		LoginURL:        req.LoginURL,
+		CreatedBy:       createdBy,
+	}
+	if batch.Name == "" {
+		batch.Name = fmt.Sprintf("%s %s", req.Profile, time.Now().Format("2006-01-02 15:04"))
+	}
+	if err := u.voucherRepo.CreateBatch(batch); err != nil {
+		return nil, fmt.Errorf("failed to create voucher batch: %w", err)
+	}
+
+	comment := fmt.Sprintf("voucher-batch:%d", batch.ID)
+	specs := make([]mikrotik.Voucher, 0, len(usernames))
+	passwords := make(map[string]string, len(usernames))
+	for _, username := range usernames {
+		password := username
+		if req.PasswordPattern != "" {
+			if password, err = expandPattern(req.PasswordPattern); err != nil {
+				return nil, err
+			}
+		}
+		passwords[username] = password
+		specs = append(specs, mikrotik.Voucher{
+			Username:    username,
+			Password:    password,
+			Profile:     req.Profile,
+			Server:      req.Server,
+			LimitUptime: req.TimeLimit,
+			LimitBytes:  dataLimit,
+			Comment:     comment,
+		})
+	}
+
+	added, failed := u.hotspotService.AddVouchers(ctx, router.ID, specs)
+
+	result := &VoucherBatchResult{Batch: batch, Failed: []VoucherFailure{}}
+	for _, username := range usernames {
+		if err, ok := failed[username]; ok {
+			result.Failed = append(result.Failed, VoucherFailure{Username: username, Error: err.Error()})
+		}
+	}
+
+	if len(added) == 0 {
+		if err := u.voucherRepo.DeleteBatch(batch.ID); err != nil {
+			logger.Error("Failed to remove empty voucher batch", zap.Uint("batch_id", batch.ID), zap.Error(err))
+		}
+		if len(result.Failed) > 0 {
+			return nil, fmt.Errorf("no vouchers were added to the router: %s", result.Failed[0].Error)
+		}
+		return nil, fmt.Errorf("no vouchers were added to the router")
+	}
+
+	vouchers := make([]*entities.HotspotVoucher, 0, len(added))
+	for _, username := range added {
+		vouchers = append(vouchers, &entities.HotspotVoucher{
+			BatchID:  batch.ID,
+			RouterID: router.ID,
+			Username: username,
+			Password: passwords[username],
+			Profile:  req.Profile,
+			Price:    req.Price,
+			Status:   VoucherStatusAvailable,
+		})
+	}
+	if err := u.voucherRepo.CreateVouchers(vouchers); err != nil {
+		return nil, fmt.Errorf("vouchers were added to the router but could not be saved: %w", err)
+	}
+
+	if batch.Count != len(vouchers) {
+		batch.Count = len(vouchers)
+		if err := u.voucherRepo.UpdateBatch(batch); err != nil {
+			logger.Error("Failed to update voucher batch count", zap.Uint("batch_id", batch.ID), zap.Error(err))
+		}
+	}
+	batch.Vouchers = vouchers
+	batch.Router = router
+
+	logger.Info("Hotspot voucher batch generated",
+		zap.Uint("batch_id", batch.ID),
+		zap.Uint("router_id", router.ID),
+		zap.Int("vouchers", len(vouchers)),
+		zap.Int("failed", len(result.Failed)),
+	)
+
+	return result, nil
+}
+
+func (u *VoucherUsecase) GetBatches(page, perPage int, routerID uint) ([]*entities.VoucherBatch, int64, error) {
+	return u.voucherRepo.FindBatches(page, perPage, routerID)
+}
+
+func (u *VoucherUsecase) GetBatch(id uint) (*entities.VoucherBatch, error) {
+	batch, err := u.voucherRepo.FindBatchByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("voucher batch not found")
+	}
+	return batch, nil
+}
+
+func (u *VoucherUsecase) GetVouchers(page, perPage int, batchID uint, status string) ([]*entities.HotspotVoucher, int64, error) {
+	return u.voucherRepo.FindAll(page, perPage, batchID, status)
+}
+
+// SetSold marks a voucher as sold or returns it to stock.
+func (u *VoucherUsecase) SetSold(id uint, sold bool) (*entities.HotspotVoucher, error) {
+	voucher, err := u.voucherRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("voucher not found")
+	}
+
+	if sold {
+		if voucher.Status == VoucherStatusSold {
+			return nil, fmt.Errorf("voucher is already sold")
+		}
+		now := time.Now()
+		voucher.Status = VoucherStatusSold
+		voucher.SoldAt = &now
+	} else {
+		voucher.Status = VoucherStatusAvailable
+		voucher.SoldAt = nil
+	}
+
+	if err := u.voucherRepo.Update(voucher); err != nil {
+		return nil, err
+	}
+	return voucher, nil
+}
+
+// DeleteBatch removes the batch's hotspot users from the router and then
+// the batch itself. Users that cannot be removed are reported and the batch
+// is kept.
+func (u *VoucherUsecase) DeleteBatch(ctx context.Context, id uint) error {
+	batch, err := u.voucherRepo.FindBatchByID(id)
+	if err != nil {
+		return fmt.Errorf("voucher batch not found")
+	}
+
+	var failed []string
+	for _, voucher := range batch.Vouchers {
+		if err := u.hotspotService.RemoveUser(ctx, batch.RouterID, voucher.Username); err != nil {
+			failed = append(failed, voucher.Username)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to remove %d hotspot users from router (first: %s)", len(failed), failed[0])
+	}
+
+	return u.voucherRepo.DeleteBatch(id)
+}
+
+// VoucherLoginURL builds the hotspot login link encoded in a voucher's QR
+// code, or "" when the batch has no login URL.
+func VoucherLoginURL(loginURL string, voucher *entities.HotspotVoucher) string {
+	if loginURL == "" {
+		return ""
+	}
+	u, err := url.Parse(loginURL)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	q.Set("username", voucher.Username)
+	q.Set("password", voucher.Password)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// formatDataLimit renders a byte count the way vouchers print it.
+func formatDataLimit(b int64) string {
+	switch {
+	case b <= 0:
+		return ""
+	case b%(1<<30) == 0:
+		return fmt.Sprintf("%dGB", b>>30)
+	case b%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", b>>20)
+	}
+	return fmt.Sprintf("%.1fMB", float64(b)/(1<<20))
+}
+
+var voucherSheetTemplate = template.Must(template.New("vouchers").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Voucher {{.Batch.Name}}</title>
+<style>
+  body { font-family: Arial, sans-serif; margin: 10mm; font-size: 11px; }
+  .sheet { display: flex; flex-wrap: wrap; gap: 3mm; }
+  .card { width: 60mm; border: 1px dashed #555; padding: 2mm; box-sizing: border-box; page-break-inside: avoid; display: flex; gap: 2mm; }
+  .card .qr svg { width: 22mm; height: 22mm; }
+  .title { font-weight: bold; font-size: 12px; }
+  .code { font-family: monospace; font-size: 14px; font-weight: bold; }
+  .price { font-weight: bold; margin-top: 1mm; }
+  @media print { body { margin: 5mm; } .no-print { display: none; } }
+</style>
+</head>
+<body>
+<div class="no-print"><h3>{{.Batch.Name}} ({{len .Cards}} voucher)</h3><button onclick="window.print()">Cetak</button></div>
+<div class="sheet">
+{{range .Cards}}
+  <div class="card">
+    {{if .QR}}<div class="qr">{{.QR}}</div>{{end}}
+    <div>
+      <div class="title">{{$.AppName}}</div>
+      <div>{{$.Batch.Profile}}{{if $.Limits}} · {{$.Limits}}{{end}}</div>
+      {{if .SamePassword}}<div>Kode: <span class="code">{{.Username}}</span></div>
+      {{else}}<div>User: <span class="code">{{.Username}}</span></div>
+      <div>Pass: <span class="code">{{.Password}}</span></div>{{end}}
+      {{if gt $.Batch.Price 0.0}}<div class="price">Rp {{printf "%.0f" $.Batch.Price}}</div>{{end}}
+    </div>
+  </div>
+{{end}}
+</div>
+</body>
+</html>
+`))
+
+// RenderBatchSheet renders a printable HTML sheet of voucher cards, each
+// with a QR code of its login link. A non-empty status limits the cards.
+func (u *VoucherUsecase) RenderBatchSheet(id uint, status string) ([]byte, error) {
+	batch, err := u.GetBatch(id)
+	if err != nil {
+		return nil, err
+	}
+
+	type card struct {
+		Username     string
+		Password     string
+		SamePassword bool
+		QR           template.HTML
+	}
+	data := struct {
+		AppName string
+		Batch   *entities.VoucherBatch
+		Limits  string
+		Cards   []card
+	}{
+		AppName: u.appName,
+		Batch:   batch,
+	}
+
+	var limits []string
+	if batch.TimeLimit != "" {
+		limits = append(limits, batch.TimeLimit)
+	}
+	if dl := formatDataLimit(batch.DataLimit); dl != "" {
+		limits = append(limits, dl)
+	}
+	data.Limits = strings.Join(limits, " / ")
+
+	for _, voucher := range batch.Vouchers {
+		if status != "" && voucher.Status != status {
+			continue
+		}
+		c := card{
+			Username:     voucher.Username,
+			Password:     voucher.Password,
+			SamePassword: voucher.Username == voucher.Password,
+		}
+		if link := VoucherLoginURL(batch.LoginURL, voucher); link != "" {
+			code, err := qrcode.Encode(link, qrcode.Medium)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode voucher QR: %w", err)
+			}
+			svg := code.SVG(4, qrcode.DefaultBorder)
+			if i := strings.Index(svg, "<svg"); i >= 0 {
+				svg = svg[i:] // drop the XML declaration when inlining
+			}
+			c.QR = template.HTML(svg)
+		}
+		data.Cards = append(data.Cards, c)
+	}
+
+	var buf bytes.Buffer
+	if err := voucherSheetTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render vouchers: %w", err)
+	}
+	return buf.Bytes(), nil
+}
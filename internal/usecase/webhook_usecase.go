@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/alijayanet/gembok-backend/internal/domain/entities"
+	"github.com/alijayanet/gembok-backend/internal/domain/repositories"
+	"github.com/alijayanet/gembok-backend/internal/infrastructure/external/webhook"
+)
+
+type WebhookUsecase struct {
+	endpointRepo repositories.WebhookEndpointRepository
+	logRepo      repositories.WebhookLogRepository
+	dispatcher   *webhook.Dispatcher
+}
+
+func NewWebhookUsecase(
+	endpointRepo repositories.WebhookEndpointRepository,
+	logRepo repositories.WebhookLogRepository,
+	dispatcher *webhook.Dispatcher,
+) *WebhookUsecase {
+	return &WebhookUsecase{
+		endpointRepo: endpointRepo,
+		logRepo:      logRepo,
+		dispatcher:   dispatcher,
+	}
+}
+
+type WebhookEndpointRequest struct {
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events"`
+	IsActive    *bool    `json:"is_active"`
+	Description string   `json:"description"`
+}
+
+// WebhookEndpointWithSecret is returned once when an endpoint is created so
+// the receiver can be configured with the signing secret.
+type WebhookEndpointWithSecret struct {
+	*entities.WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+func (u *WebhookUsecase) GetEvents() []string {
+	return webhook.Events
+}
+
+func (u *WebhookUsecase) GetEndpoints() ([]*entities.WebhookEndpoint, error) {
+	return u.endpointRepo.FindAll()
+}
+
+func (u *WebhookUsecase) GetEndpoint(id uint) (*entities.WebhookEndpoint, error) {
+	endpoint, err := u.endpointRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("webhook endpoint not found")
+	}
+	return endpoint, nil
+}
+
+func (u *WebhookUsecase) CreateEndpoint(req WebhookEndpointRequest) (*WebhookEndpointWithSecret, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	events, err := joinWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret, err = generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint := &entities.WebhookEndpoint{
+		Name:        req.Name,
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      events,
+		IsActive:    true,
+		Description: req.Description,
+	}
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+
+	if err := u.endpointRepo.Create(endpoint); err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	return &WebhookEndpointWithSecret{WebhookEndpoint: endpoint, Secret: secret}, nil
+}
+
+func (u *WebhookUsecase) UpdateEndpoint(id uint, req WebhookEndpointRequest) (*entities.WebhookEndpoint, error) {
+	endpoint, err := u.endpointRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("webhook endpoint not found")
+	}
+
+	if req.Name != "" {
+		endpoint.Name = req.Name
+	}
+	if req.URL != "" {
+		if err := validateWebhookURL(req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = req.URL
+	}
+	if req.Secret != "" {
+		endpoint.Secret = req.Secret
+	}
+	if req.Events != nil {
+		events, err := joinWebhookEvents(req.Events)
+		if err != nil {
+			return nil, err
+		}
+		endpoint.Events = events
+	}
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+	if req.Description != "" {
+		endpoint.Description = req.Description
+	}
+
+	if err := u.endpointRepo.Update(endpoint); err != nil {
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+func (u *WebhookUsecase) DeleteEndpoint(id uint) error {
+	return u.endpointRepo.Delete(id)
+}
+
+func (u *WebhookUsecase) GetLogs(page, perPage int, event string, endpointID uint) ([]*entities.WebhookLog, int64, error) {
+	return u.logRepo.FindAll(page, perPage, event, endpointID)
+}
+
+func (u *WebhookUsecase) ResendLog(id uint) (*entities.WebhookLog, error) {
+	return u.dispatcher.Resend(id)
+}
+
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("url must be a valid http or https URL")
+	}
+	return nil
+}
+
+func joinWebhookEvents(events []string) (string, error) {
+	if len(events) == 0 {
+		return "", fmt.Errorf("at least one event is required")
+	}
+	for _, event := range events {
+		if !webhook.IsValidEvent(event) {
+			return "", fmt.Errorf("unknown event: %s", event)
+		}
+	}
+	return strings.Join(events, ","), nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
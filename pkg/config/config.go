@@ -2,25 +2,35 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Mikrotik MikrotikConfig `mapstructure:"mikrotik"`
-	GenieACS GenieACSConfig `mapstructure:"genieacs"`
-	WhatsApp WhatsAppConfig `mapstructure:"whatsapp"`
-	Tripay   TripayConfig   `mapstructure:"tripay"`
-	App      AppDetails     `mapstructure:"app"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	JWT        JWTConfig        `mapstructure:"jwt"`
+	Mikrotik   MikrotikConfig   `mapstructure:"mikrotik"`
+	GenieACS   GenieACSConfig   `mapstructure:"genieacs"`
+	WhatsApp   WhatsAppConfig   `mapstructure:"whatsapp"`
+	Tripay     TripayConfig     `mapstructure:"tripay"`
+	Webhook    WebhookConfig    `mapstructure:"webhook"`
+	Monitoring MonitoringConfig `mapstructure:"monitoring"`
+	Isolation  IsolationConfig  `mapstructure:"isolation"`
+	Encryption EncryptionConfig `mapstructure:"encryption"`
+	App        AppDetails       `mapstructure:"app"`
 }
 
+// ServerConfig holds the HTTP server settings. TrustedProxies lists the
+// reverse proxies, as IPs or CIDRs, whose X-Forwarded-For header is taken as
+// the client address; with none the connection's source address is used.
 type ServerConfig struct {
-	Port string `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port           string   `mapstructure:"port"`
+	Mode           string   `mapstructure:"mode"`
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -40,10 +50,26 @@ type JWTConfig struct {
 }
 
 type MikrotikConfig struct {
-	Host     string `mapstructure:"host"`
-	User     string `mapstructure:"user"`
-	Password string `mapstructure:"password"`
-	Port     int    `mapstructure:"port"`
+	Host              string        `mapstructure:"host"`
+	User              string        `mapstructure:"user"`
+	Password          string        `mapstructure:"password"`
+	Port              int           `mapstructure:"port"`
+	DialTimeout       time.Duration `mapstructure:"dial_timeout"`
+	CommandTimeout    time.Duration `mapstructure:"command_timeout"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	KeepaliveInterval time.Duration `mapstructure:"keepalive_interval"`
+	MaxConcurrent     int           `mapstructure:"max_concurrent"`
+	MaxBackoff        time.Duration `mapstructure:"max_backoff"`
+	PoolWarnThreshold float64       `mapstructure:"pool_warn_threshold"`
+	LogShipInterval   time.Duration `mapstructure:"log_ship_interval"`
+	LogRetention      time.Duration `mapstructure:"log_retention"`
+	UsageInterval     time.Duration `mapstructure:"usage_interval"`
+	QuotaInterval     time.Duration `mapstructure:"quota_interval"`
+	BackupInterval    time.Duration `mapstructure:"backup_interval"`
+	BackupRetention   time.Duration `mapstructure:"backup_retention"`
+	BackupKeep        int           `mapstructure:"backup_keep"`
+	ScheduleInterval  time.Duration `mapstructure:"schedule_interval"`
+	ScheduleReconnect bool          `mapstructure:"schedule_reconnect"`
 }
 
 type GenieACSConfig struct {
@@ -71,6 +97,45 @@ type TripayConfig struct {
 	Mode         string `mapstructure:"mode"`
 }
 
+type WebhookConfig struct {
+	Timeout      time.Duration `mapstructure:"timeout"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+}
+
+// MonitoringConfig drives router resource polling and the alerts sent to
+// WhatsApp admin phones. A zero threshold or duration disables its rule.
+type MonitoringConfig struct {
+	Interval         time.Duration `mapstructure:"interval"`
+	Retention        time.Duration `mapstructure:"retention"`
+	CPUThreshold     float64       `mapstructure:"cpu_threshold"`
+	CPUDuration      time.Duration `mapstructure:"cpu_duration"`
+	MemoryThreshold  int           `mapstructure:"memory_threshold"`
+	MemoryDuration   time.Duration `mapstructure:"memory_duration"`
+	UnreachableAfter time.Duration `mapstructure:"unreachable_after"`
+	RebootAlerts     bool          `mapstructure:"reboot_alerts"`
+}
+
+// IsolationConfig drives address-list isolation. The captive page tells
+// customers apart by source address, so CaptiveAddress and CaptivePort must
+// reach this server directly or through one of server.trusted_proxies.
+// RefreshInterval is how often the address-lists follow session changes.
+type IsolationConfig struct {
+	AddressList     string        `mapstructure:"address_list"`
+	CaptiveAddress  string        `mapstructure:"captive_address"`
+	CaptivePort     int           `mapstructure:"captive_port"`
+	AllowedHosts    []string      `mapstructure:"allowed_hosts"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// EncryptionConfig holds the keys for secrets stored in the database, as
+// base64 encoded 32-byte AES keys by ID. New values are encrypted with
+// ActiveKey; older keys stay listed until rotate-keys has re-encrypted them.
+type EncryptionConfig struct {
+	Keys      map[string]string `mapstructure:"keys"`
+	ActiveKey string            `mapstructure:"active_key"`
+}
+
 type AppDetails struct {
 	Name    string `mapstructure:"name"`
 	Version string `mapstructure:"version"`
@@ -94,7 +159,36 @@ func LoadConfig(path string) (*Config, error) {
 	viper.SetDefault("database.max_lifetime", 3600)
 	viper.SetDefault("jwt.expiration", 3600*time.Second)
 	viper.SetDefault("mikrotik.port", 8728)
+	viper.SetDefault("mikrotik.dial_timeout", 10*time.Second)
+	viper.SetDefault("mikrotik.command_timeout", 15*time.Second)
+	viper.SetDefault("mikrotik.read_timeout", 30*time.Second)
+	viper.SetDefault("mikrotik.keepalive_interval", 30*time.Second)
+	viper.SetDefault("mikrotik.max_concurrent", 4)
+	viper.SetDefault("mikrotik.max_backoff", time.Minute)
+	viper.SetDefault("mikrotik.pool_warn_threshold", 80)
+	viper.SetDefault("mikrotik.log_ship_interval", 0)
+	viper.SetDefault("mikrotik.log_retention", 30*24*time.Hour)
+	viper.SetDefault("mikrotik.usage_interval", 5*time.Minute)
+	viper.SetDefault("mikrotik.quota_interval", 15*time.Minute)
+	viper.SetDefault("mikrotik.backup_interval", 24*time.Hour)
+	viper.SetDefault("mikrotik.backup_retention", 90*24*time.Hour)
+	viper.SetDefault("mikrotik.backup_keep", 10)
+	viper.SetDefault("mikrotik.schedule_interval", time.Minute)
+	viper.SetDefault("mikrotik.schedule_reconnect", true)
 	viper.SetDefault("tripay.mode", "production")
+	viper.SetDefault("webhook.timeout", 10*time.Second)
+	viper.SetDefault("webhook.max_attempts", 5)
+	viper.SetDefault("webhook.retry_backoff", 30*time.Second)
+	viper.SetDefault("monitoring.interval", time.Minute)
+	viper.SetDefault("monitoring.retention", 30*24*time.Hour)
+	viper.SetDefault("monitoring.cpu_threshold", 90)
+	viper.SetDefault("monitoring.cpu_duration", 5*time.Minute)
+	viper.SetDefault("monitoring.memory_threshold", 0)
+	viper.SetDefault("monitoring.memory_duration", 5*time.Minute)
+	viper.SetDefault("monitoring.unreachable_after", 2*time.Minute)
+	viper.SetDefault("monitoring.reboot_alerts", true)
+	viper.SetDefault("isolation.address_list", "gembok-isolir")
+	viper.SetDefault("isolation.refresh_interval", 30*time.Second)
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -105,6 +199,19 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	for _, proxy := range cfg.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return nil, fmt.Errorf("invalid server.trusted_proxies entry %q", proxy)
+			}
+		}
+	}
+
+	// The captive page is served by this server unless told otherwise.
+	if cfg.Isolation.CaptivePort == 0 {
+		cfg.Isolation.CaptivePort, _ = strconv.Atoi(cfg.Server.Port)
+	}
+
 	AppConfig = &cfg
 	return &cfg, nil
 }
@@ -0,0 +1,235 @@
+// Package diff produces line based unified diffs.
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is one line of the edit script. a and b are the number of lines of
+// each side consumed before it.
+type op struct {
+	kind opKind
+	line string
+	a, b int
+}
+
+// MaxEdits is the most changed lines Unified will work out a diff for.
+// Finding the edits takes time proportional to their number times the
+// input size, so inputs that differ more only report that they differ.
+const MaxEdits = 10000
+
+// ErrTooManyChanges is returned by Unified when a and b differ in more than
+// MaxEdits lines.
+var ErrTooManyChanges = errors.New("too many changes to diff")
+
+// Unified returns the unified diff turning a into b with context lines
+// around each change, or "" when both are equal.
+func Unified(fromName, toName, a, b string, context int) (string, error) {
+	ops := script(splitLines(a), splitLines(b))
+	if ops == nil && a != b {
+		return "", ErrTooManyChanges
+	}
+
+	var changes []int
+	for i, o := range ops {
+		if o.kind != opEqual {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(changes); {
+		start := max(changes[i]-context, 0)
+		end := changes[i] + context + 1
+		// Merge following changes whose context overlaps this hunk.
+		for i++; i < len(changes) && changes[i]-context <= end; i++ {
+			end = changes[i] + context + 1
+		}
+		end = min(end, len(ops))
+		writeHunk(&sb, ops[start:end])
+	}
+	return sb.String(), nil
+}
+
+func writeHunk(sb *strings.Builder, ops []op) {
+	aCount, bCount := 0, 0
+	for _, o := range ops {
+		if o.kind != opInsert {
+			aCount++
+		}
+		if o.kind != opDelete {
+			bCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(ops[0].a, aCount), hunkRange(ops[0].b, bCount))
+
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			sb.WriteString(" ")
+		case opDelete:
+			sb.WriteString("-")
+		case opInsert:
+			sb.WriteString("+")
+		}
+		sb.WriteString(o.line)
+		sb.WriteString("\n")
+	}
+}
+
+// hunkRange formats a hunk side; an empty side names the line before it.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// script returns a shortest edit script from a to b, or nil when more than
+// MaxEdits lines would have to change. It uses the linear space variant of
+// Myers' algorithm, which splits the problem at the middle snake of the
+// shortest path, so memory stays proportional to the input.
+func script(a, b []string) []op {
+	s := &scripter{a: a, b: b, ops: make([]op, 0, len(a)+len(b))}
+	size := len(a) + len(b) + 4
+	s.vf = make([]int, size)
+	s.vb = make([]int, size)
+	if !s.compare(0, len(a), 0, len(b)) {
+		return nil
+	}
+	return s.ops
+}
+
+type scripter struct {
+	a, b   []string
+	vf, vb []int
+	ops    []op
+}
+
+// compare appends the edit script of a[aLo:aHi] to b[bLo:bHi] to s.ops. It
+// reports false when the middle snake search gives up on MaxEdits.
+func (s *scripter) compare(aLo, aHi, bLo, bHi int) bool {
+	for aLo < aHi && bLo < bHi && s.a[aLo] == s.b[bLo] {
+		s.ops = append(s.ops, op{kind: opEqual, line: s.a[aLo], a: aLo, b: bLo})
+		aLo++
+		bLo++
+	}
+	aEnd := aHi
+	for aLo < aHi && bLo < bHi && s.a[aHi-1] == s.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			s.ops = append(s.ops, op{kind: opInsert, line: s.b[y], a: aLo, b: y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			s.ops = append(s.ops, op{kind: opDelete, line: s.a[x], a: x, b: bLo})
+		}
+	default:
+		// Both sides are non-empty and differ at both ends, so at least two
+		// edits are needed and each half has fewer than the whole.
+		x, y, u, v, ok := s.middleSnake(aLo, aHi, bLo, bHi)
+		if !ok || !s.compare(aLo, x, bLo, y) {
+			return false
+		}
+		for ; x < u; x, y = x+1, y+1 {
+			s.ops = append(s.ops, op{kind: opEqual, line: s.a[x], a: x, b: y})
+		}
+		if !s.compare(u, aHi, v, bHi) {
+			return false
+		}
+	}
+
+	for x, y := aHi, bHi; x < aEnd; x, y = x+1, y+1 {
+		s.ops = append(s.ops, op{kind: opEqual, line: s.a[x], a: x, b: y})
+	}
+	return true
+}
+
+// middleSnake finds the snake (x, y) to (u, v) in the middle of a shortest
+// path from (aLo, bLo) to (aHi, bHi) by searching forward from the start and
+// backward from the end until the two meet.
+func (s *scripter) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int, ok bool) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	if maxD > MaxEdits/2+1 {
+		maxD = MaxEdits/2 + 1
+	}
+	// vf is indexed by the forward diagonal k = x - y and holds the
+	// furthest x reached; vb does the same for the reversed sequences.
+	offset := maxD + 1
+	vf, vb := s.vf[:2*maxD+3], s.vb[:2*maxD+3]
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && s.a[aLo+x] == s.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && x+vb[offset+c] >= n {
+				return aLo + startX, bLo + startY, aLo + x, bLo + y, true
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && s.a[aHi-1-x] == s.b[bHi-1-y] {
+				x++
+				y++
+			}
+			vb[offset+k] = x
+			if c := delta - k; !odd && c >= -d && c <= d && x+vf[offset+c] >= n {
+				return aHi - x, bHi - y, aHi - startX, bHi - startY, true
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
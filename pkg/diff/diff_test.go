@@ -0,0 +1,196 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	lines := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	changed := "1\ntwo\n3\n4\n5\n6\n7\n8\nnine\n10\n"
+
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{"both empty", "", "", 3, ""},
+		{"identical", lines, lines, 3, ""},
+		{"line endings only", "a\nb\n", "a\r\nb\r\n", 3, ""},
+		{
+			"from empty", "", "x\ny\n", 3,
+			"--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n",
+		},
+		{
+			"to empty", "x\ny\n", "", 3,
+			"--- a\n+++ b\n@@ -1,2 +0,0 @@\n-x\n-y\n",
+		},
+		{
+			"single line", "x\n", "y\n", 3,
+			"--- a\n+++ b\n@@ -1 +1 @@\n-x\n+y\n",
+		},
+		{
+			"two hunks", lines, changed, 1,
+			"--- a\n+++ b\n" +
+				"@@ -1,3 +1,3 @@\n 1\n-2\n+two\n 3\n" +
+				"@@ -8,3 +8,3 @@\n 8\n-9\n+nine\n 10\n",
+		},
+		{
+			"overlapping context merges hunks", lines, changed, 3,
+			"--- a\n+++ b\n" +
+				"@@ -1,10 +1,10 @@\n 1\n-2\n+two\n 3\n 4\n 5\n 6\n 7\n 8\n-9\n+nine\n 10\n",
+		},
+		{
+			"insert and delete", lines, "1\n2\n2.5\n3\n4\n5\n6\n7\n9\n10\n", 0,
+			"--- a\n+++ b\n@@ -2,0 +3 @@\n+2.5\n@@ -8 +8,0 @@\n-8\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Unified("a", "b", tt.a, tt.b, tt.context)
+			if err != nil {
+				t.Fatalf("Unified: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedTooManyChanges(t *testing.T) {
+	a, b := numbered("a", MaxEdits), numbered("b", MaxEdits)
+	if _, err := Unified("a", "b", a, b, 3); !errors.Is(err, ErrTooManyChanges) {
+		t.Fatalf("err = %v, want ErrTooManyChanges", err)
+	}
+
+	// Just under the cap the diff is still worked out.
+	a, b = numbered("a", MaxEdits/2), numbered("b", MaxEdits/2)
+	got, err := Unified("a", "b", a, b, 3)
+	if err != nil {
+		t.Fatalf("Unified: %v", err)
+	}
+	if deleted, inserted := countEdits(got); deleted != MaxEdits/2 || inserted != MaxEdits/2 {
+		t.Fatalf("%d deleted and %d inserted lines, want %d each", deleted, inserted, MaxEdits/2)
+	}
+}
+
+// TestUnifiedApplies checks on random inputs that applying the diff to a
+// gives b and that the number of changed lines is the shortest possible.
+func TestUnifiedApplies(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(rng), randomLines(rng)
+		got, err := Unified("a", "b", a, b, rng.Intn(4))
+		if err != nil {
+			t.Fatalf("case %d: Unified: %v", i, err)
+		}
+		patched, err := apply(a, got)
+		if err != nil {
+			t.Fatalf("case %d: %v\n%s", i, err, got)
+		}
+		if patched != b {
+			t.Fatalf("case %d: patched %q, want %q\n%s", i, patched, b, got)
+		}
+		deleted, inserted := countEdits(got)
+		if shortest := lcsEdits(splitLines(a), splitLines(b)); deleted+inserted != shortest {
+			t.Fatalf("case %d: %d edits, shortest is %d\n%s", i, deleted+inserted, shortest, got)
+		}
+	}
+}
+
+func numbered(prefix string, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		sb.WriteString(prefix + strconv.Itoa(i) + "\n")
+	}
+	return sb.String()
+}
+
+// countEdits counts the deleted and inserted lines of a unified diff.
+func countEdits(patch string) (deleted, inserted int) {
+	for _, line := range splitLines(patch) {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+		case strings.HasPrefix(line, "-"):
+			deleted++
+		case strings.HasPrefix(line, "+"):
+			inserted++
+		}
+	}
+	return deleted, inserted
+}
+
+func randomLines(rng *rand.Rand) string {
+	var sb strings.Builder
+	for i := rng.Intn(12); i > 0; i-- {
+		sb.WriteString(string(rune('a'+rng.Intn(4))) + "\n")
+	}
+	return sb.String()
+}
+
+// apply patches a with a unified diff, checking every context and deleted
+// line against a.
+func apply(a, patch string) (string, error) {
+	src := splitLines(a)
+	var out []string
+	pos := 0
+	for _, line := range splitLines(patch) {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+		case strings.HasPrefix(line, "@@"):
+			// "-N,C" starts at line N, or after line N when C is 0.
+			start, count, found := strings.Cut(strings.Fields(line)[1][1:], ",")
+			from, _ := strconv.Atoi(start)
+			if !found || count != "0" {
+				from--
+			}
+			if from < pos {
+				return "", fmt.Errorf("hunk at %d overlaps the previous one", from+1)
+			}
+			out = append(out, src[pos:from]...)
+			pos = from
+		case strings.HasPrefix(line, "+"):
+			out = append(out, line[1:])
+		default:
+			if pos >= len(src) || src[pos] != line[1:] {
+				return "", fmt.Errorf("line %d does not match %q", pos+1, line)
+			}
+			if line[0] == ' ' {
+				out = append(out, line[1:])
+			}
+			pos++
+		}
+	}
+	out = append(out, src[pos:]...)
+	if len(out) == 0 {
+		return "", nil
+	}
+	return strings.Join(out, "\n") + "\n", nil
+}
+
+// lcsEdits returns the length of a shortest edit script between a and b.
+func lcsEdits(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		for j := 1; j <= len(b); j++ {
+			switch {
+			case a[i-1] == b[j-1]:
+				cur[j] = prev[j-1] + 1
+			case prev[j] > cur[j-1]:
+				cur[j] = prev[j]
+			default:
+				cur[j] = cur[j-1]
+			}
+		}
+		prev = cur
+	}
+	return len(a) + len(b) - 2*prev[len(b)]
+}
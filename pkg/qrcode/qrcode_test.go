@@ -0,0 +1,366 @@
+package qrcode
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecodes(t *testing.T) {
+	qris := "00020101021226670016COM.NOBUBANK.WWW01189360050300000879140214123456789012340303UMI51440014ID.CO.QRIS.WWW0215ID20232108765430303UMI5204541153033605406250005802ID5912GEMBOK ISP6007JAKARTA61051234062070703A0163046D2F"
+
+	tests := []struct {
+		name        string
+		content     string
+		level       Level
+		wantVersion int
+	}{
+		{"empty", "", Medium, 1},
+		{"one byte", "a", Low, 1},
+		{"full v1 low", strings.Repeat("x", 17), Low, 1},
+		{"overflow v1 low", strings.Repeat("x", 18), Low, 2},
+		{"full v1 medium", strings.Repeat("x", 14), Medium, 1},
+		{"overflow v1 medium", strings.Repeat("x", 15), Medium, 2},
+		{"full v1 high", strings.Repeat("x", 7), High, 1},
+		{"full v2 quartile", strings.Repeat("x", 20), Quartile, 2},
+		{"qris payload", qris, Medium, 11},
+		{"full v9 low", strings.Repeat("y", 230), Low, 9},
+		{"16-bit count", strings.Repeat("z", 271), Low, 10},
+		{"multi block high", strings.Repeat("q", 119), High, 10},
+		{"overflow v10 high", strings.Repeat("q", 120), High, 11},
+		{"binary bytes", string([]byte{0, 1, 0xEC, 0x11, 0xFF, 0x80}), Quartile, 1},
+		{"full v40 low", strings.Repeat("w", 2953), Low, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Encode(tt.content, tt.level)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if q.Version != tt.wantVersion {
+				t.Fatalf("version = %d, want %d", q.Version, tt.wantVersion)
+			}
+			if q.Size != 4*tt.wantVersion+17 {
+				t.Fatalf("size = %d, want %d", q.Size, 4*tt.wantVersion+17)
+			}
+			got := decode(t, q)
+			if got != tt.content {
+				t.Fatalf("decoded %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	if _, err := Encode(strings.Repeat("w", 2954), Low); err == nil {
+		t.Fatal("expected an error for content over the version 40 capacity")
+	}
+	if _, err := Encode("x", Level(7)); err == nil {
+		t.Fatal("expected an error for an invalid level")
+	}
+}
+
+// TestReedSolomonKnownVector checks the error correction codewords of the
+// worked "HELLO WORLD" 1-M example from the specification.
+func TestReedSolomonKnownVector(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Fatalf("ecc = %v, want %v", got, want)
+	}
+}
+
+// TestFormatAndVersionBits checks against the format and version
+// information tables of the specification.
+func TestFormatAndVersionBits(t *testing.T) {
+	for level, want := range map[Level]int{Low: 0x77C4, Medium: 0x5412, Quartile: 0x355F, High: 0x1689} {
+		q := newCode(1, level)
+		q.drawFormatBits(0)
+		if got := readFormat(q); got != want {
+			t.Errorf("level %d mask 0 format = %#x, want %#x", level, got, want)
+		}
+	}
+
+	for version, want := range map[int]int{7: 0x07C94, 8: 0x085BC, 40: 0x28C69} {
+		q := newCode(version, Low)
+		q.drawVersion()
+		got := 0
+		for i := 17; i >= 0; i-- {
+			got <<= 1
+			if q.modules[i/3][q.Size-11+i%3] {
+				got |= 1
+			}
+		}
+		if got != want {
+			t.Errorf("version %d info = %#x, want %#x", version, got, want)
+		}
+	}
+}
+
+func TestAlignmentPatternPositions(t *testing.T) {
+	tests := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		10: {6, 28, 50},
+		32: {6, 34, 60, 86, 112, 138},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	}
+	for version, want := range tests {
+		got := alignmentPatternPositions(version)
+		if len(got) != len(want) {
+			t.Errorf("version %d: %v, want %v", version, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("version %d: %v, want %v", version, got, want)
+				break
+			}
+		}
+	}
+}
+
+// readFormat returns the 15 format bits from the copy around the top-left
+// finder pattern.
+func readFormat(q *Code) int {
+	var coords [15][2]int
+	for i := 0; i <= 5; i++ {
+		coords[i] = [2]int{8, i}
+	}
+	coords[6] = [2]int{8, 7}
+	coords[7] = [2]int{8, 8}
+	coords[8] = [2]int{7, 8}
+	for i := 9; i < 15; i++ {
+		coords[i] = [2]int{14 - i, 8}
+	}
+	bits := 0
+	for i, c := range coords {
+		if q.modules[c[1]][c[0]] {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits
+}
+
+// decode reads a symbol back the way a scanner would: it checks the fixed
+// patterns, reads the format information, unmasks and de-interleaves the
+// codewords, verifies every block's Reed-Solomon syndromes and parses the
+// byte mode segment.
+func decode(t *testing.T, q *Code) string {
+	t.Helper()
+	size := q.Size
+
+	for _, origin := range [][2]int{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				ring := dx
+				for _, d := range []int{dy, 6 - dx, 6 - dy} {
+					if d < ring {
+						ring = d
+					}
+				}
+				if want := ring != 1; q.Dark(origin[0]+dx, origin[1]+dy) != want {
+					t.Fatalf("finder pattern at %v is damaged", origin)
+				}
+			}
+		}
+	}
+	for i := 8; i < size-8; i++ {
+		if q.Dark(i, 6) != (i%2 == 0) || q.Dark(6, i) != (i%2 == 0) {
+			t.Fatalf("timing pattern is damaged at %d", i)
+		}
+	}
+	if !q.Dark(8, size-8) {
+		t.Fatal("dark module is missing")
+	}
+
+	format := readFormat(q) ^ 0x5412
+	rem := format >> 10
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	if rem&0x3FF != format&0x3FF {
+		t.Fatalf("format information %#x fails its BCH check", format)
+	}
+	levelBits, mask := format>>13, (format>>10)&7
+	if levelBits != formatBits[q.Level] || mask != q.Mask {
+		t.Fatalf("format says level bits %d mask %d, symbol has %d/%d", levelBits, mask, formatBits[q.Level], q.Mask)
+	}
+	second := 0
+	for i := 0; i < 8; i++ {
+		if q.Dark(size-1-i, 8) {
+			second |= 1 << uint(i)
+		}
+	}
+	for i := 8; i < 15; i++ {
+		if q.Dark(8, size-15+i) {
+			second |= 1 << uint(i)
+		}
+	}
+	if second != format^0x5412 {
+		t.Fatalf("second format copy %#x differs from %#x", second, format^0x5412)
+	}
+
+	reserved := newCode(q.Version, q.Level)
+	reserved.drawFunctionPatterns()
+
+	var raw []byte
+	var cur byte
+	n := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if reserved.isFunction[y][x] {
+					continue
+				}
+				dark := q.Dark(x, y) != maskBit(mask, x, y)
+				cur <<= 1
+				if dark {
+					cur |= 1
+				}
+				if n++; n%8 == 0 {
+					raw = append(raw, cur)
+					cur = 0
+				}
+			}
+		}
+	}
+
+	numBlocks := numErrorCorrectionBlocks[q.Level][q.Version]
+	eccLen := eccCodewordsPerBlock[q.Level][q.Version]
+	total := numRawDataModules(q.Version) / 8
+	if len(raw) != total {
+		t.Fatalf("read %d codewords, want %d", len(raw), total)
+	}
+	shortLen := total / numBlocks
+	numShort := numBlocks - total%numBlocks
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i < shortLen+1; i++ {
+		for b := range blocks {
+			if i == shortLen-eccLen && b < numShort {
+				continue // short blocks have one data codeword less
+			}
+			blocks[b] = append(blocks[b], raw[k])
+			k++
+		}
+	}
+
+	var data []byte
+	for b, block := range blocks {
+		for i := 0; i < eccLen; i++ {
+			if s := syndrome(block, gfPow(i)); s != 0 {
+				t.Fatalf("block %d syndrome %d = %d", b, i, s)
+			}
+		}
+		data = append(data, block[:len(block)-eccLen]...)
+	}
+
+	bits := func(pos, count int) int {
+		v := 0
+		for i := pos; i < pos+count; i++ {
+			v = v<<1 | int(data[i/8]>>(7-uint(i%8))&1)
+		}
+		return v
+	}
+	if m := bits(0, 4); m != 0x4 {
+		t.Fatalf("mode = %#x, want byte mode", m)
+	}
+	countBits := 8
+	if q.Version > 9 {
+		countBits = 16
+	}
+	length := bits(4, countBits)
+	pos := 4 + countBits
+	content := make([]byte, length)
+	for i := range content {
+		content[i] = byte(bits(pos, 8))
+		pos += 8
+	}
+
+	// What follows is the terminator, zero bits up to a byte boundary and
+	// alternating pad codewords.
+	end := pos + 4
+	if end > len(data)*8 {
+		end = len(data) * 8
+	}
+	for ; pos < end || pos%8 != 0; pos++ {
+		if bits(pos, 1) != 0 {
+			t.Fatalf("non-zero terminator bit at %d", pos)
+		}
+	}
+	for pad := byte(0xEC); pos < len(data)*8; pos, pad = pos+8, pad^0xEC^0x11 {
+		if got := byte(bits(pos, 8)); got != pad {
+			t.Fatalf("pad codeword %#x, want %#x", got, pad)
+		}
+	}
+	return string(content)
+}
+
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (y+x)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (y+x)%3 == 0
+	case 4:
+		return (y/2+x/3)%2 == 0
+	case 5:
+		return (y*x)%2+(y*x)%3 == 0
+	case 6:
+		return ((y*x)%2+(y*x)%3)%2 == 0
+	default:
+		return ((y+x)%2+(y*x)%3)%2 == 0
+	}
+}
+
+// gfPow returns 2^i in GF(2^8) with the QR polynomial 0x11D.
+func gfPow(i int) byte {
+	v := 1
+	for ; i > 0; i-- {
+		v <<= 1
+		if v&0x100 != 0 {
+			v ^= 0x11D
+		}
+	}
+	return byte(v)
+}
+
+// syndrome evaluates the codeword polynomial, highest power first, at x.
+func syndrome(codeword []byte, x byte) byte {
+	var s byte
+	for _, c := range codeword {
+		s = gfMul(s, x) ^ c
+	}
+	return s
+}
+
+func gfMul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1D
+		}
+		b >>= 1
+	}
+	return p
+}
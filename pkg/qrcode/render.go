@@ -0,0 +1,70 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// DefaultBorder is the quiet zone, in modules, required around a symbol.
+const DefaultBorder = 4
+
+// PNG renders the symbol as a black-on-white PNG. scale is the size of one
+// module in pixels and border the quiet zone in modules.
+func (q *Code) PNG(scale, border int) ([]byte, error) {
+	if scale < 1 {
+		return nil, fmt.Errorf("scale must be positive")
+	}
+	if border < 0 {
+		return nil, fmt.Errorf("border must not be negative")
+	}
+
+	dim := (q.Size + border*2) * scale
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, dim, dim), palette)
+
+	for y := 0; y < dim; y++ {
+		for x := 0; x < dim; x++ {
+			if q.Dark(x/scale-border, y/scale-border) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the symbol as an SVG document. The viewBox is expressed in
+// modules so the image scales cleanly; scale only sets the default size.
+func (q *Code) SVG(scale, border int) string {
+	if scale < 1 {
+		scale = 1
+	}
+	if border < 0 {
+		border = 0
+	}
+
+	dim := q.Size + border*2
+	var path strings.Builder
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.modules[y][x] {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+border, y+border)
+			}
+		}
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">
+<rect width="100%%" height="100%%" fill="#FFFFFF"/>
+<path d="%s" fill="#000000"/>
+</svg>
+`, dim*scale, dim*scale, dim, dim, path.String())
+}
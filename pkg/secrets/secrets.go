@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// prefix marks an encrypted value. The full format is
+// "enc:<key id>:<base64 of nonce and AES-GCM ciphertext>".
+const prefix = "enc:"
+
+// Keyring encrypts secrets with AES-256-GCM under the active key and
+// decrypts them with whichever configured key they were written with, so
+// old keys can be kept around while rows are rotated to a new one.
+type Keyring struct {
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// NewKeyring builds a keyring from base64 encoded 32-byte keys by ID. The
+// active key may be left empty when there is only one key. Without any keys
+// the keyring is disabled: values are stored and read as plain text.
+func NewKeyring(keys map[string]string, activeKey string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, encoded := range keys {
+		id = strings.ToLower(id)
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes, base64 encoded", id)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+	}
+	if len(k.keys) == 0 {
+		return k, nil
+	}
+
+	k.active = strings.ToLower(activeKey)
+	if k.active == "" && len(k.keys) == 1 {
+		for id := range k.keys {
+			k.active = id
+		}
+	}
+	if _, ok := k.keys[k.active]; !ok {
+		return nil, fmt.Errorf("active key %q must be one of the configured keys", activeKey)
+	}
+	return k, nil
+}
+
+// Enabled reports whether new values are encrypted.
+func (k *Keyring) Enabled() bool {
+	return k.active != ""
+}
+
+// ActiveKey returns the ID of the key new values are encrypted with.
+func (k *Keyring) ActiveKey() string {
+	return k.active
+}
+
+// Encrypt encrypts plaintext under the active key. Empty values stay empty
+// and a disabled keyring returns plaintext unchanged.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || !k.Enabled() {
+		return plaintext, nil
+	}
+	aead := k.keys[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + k.active + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plaintext of value. Values without the encryption
+// prefix are rows written before encryption was enabled and are returned
+// as they are.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	aead, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("value is encrypted with unknown key %q", id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("value could not be decrypted with key %q", id)
+	}
+	return string(plaintext), nil
+}
+
+// IsCurrent reports whether value needs no rotation: it is empty or
+// already encrypted under the active key.
+func (k *Keyring) IsCurrent(value string) bool {
+	if value == "" {
+		return true
+	}
+	if !k.Enabled() {
+		return !IsEncrypted(value)
+	}
+	return strings.HasPrefix(value, prefix+k.active+":")
+}
+
+// IsEncrypted reports whether value was produced by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}